@@ -0,0 +1,185 @@
+package openai
+
+// types and functions for rebuilding chat completions from streamed chunks
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ChatCompletionAccumulator struct for folding the chunks of a streaming chat completion
+// into a complete ChatCompletion, which looks like the response of a non-streaming request
+//
+// Contents and refusals are concatenated per choice, parallel tool calls are merged by their indices,
+// and finish reasons and the usage (of the last chunk, with `stream_options.include_usage`) are kept.
+type ChatCompletionAccumulator struct {
+	mu         sync.Mutex
+	completion ChatCompletion
+	choices    map[int]*accumulatedChoice
+}
+
+// accumulatedChoice struct for a choice being accumulated
+type accumulatedChoice struct {
+	role         ChatMessageRole
+	content      strings.Builder
+	hasContent   bool
+	refusal      strings.Builder
+	hasRefusal   bool
+	toolCalls    []ToolCall
+	toolIndices  map[int]int // index in chunks => index in `toolCalls`
+	finishReason string
+}
+
+// NewChatCompletionAccumulator returns a new ChatCompletionAccumulator.
+func NewChatCompletionAccumulator() *ChatCompletionAccumulator {
+	return &ChatCompletionAccumulator{
+		choices: map[int]*accumulatedChoice{},
+	}
+}
+
+// Add folds given chunk into the accumulated chat completion.
+func (a *ChatCompletionAccumulator) Add(chunk ChatCompletion) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.completion.ID == "" {
+		a.completion.ID = chunk.ID
+	}
+	if a.completion.Created == 0 {
+		a.completion.Created = chunk.Created
+	}
+	if a.completion.Model == "" {
+		a.completion.Model = chunk.Model
+	}
+	if chunk.Usage.TotalTokens > 0 || chunk.Usage.PromptTokens > 0 {
+		a.completion.Usage = chunk.Usage
+	}
+
+	for _, c := range chunk.Choices {
+		choice, exists := a.choices[c.Index]
+		if !exists {
+			choice = &accumulatedChoice{toolIndices: map[int]int{}}
+			a.choices[c.Index] = choice
+		}
+		choice.add(c)
+	}
+}
+
+// add folds the delta of given choice.
+func (c *accumulatedChoice) add(choice ChatCompletionChoice) {
+	delta := choice.Delta
+
+	if delta.Role != "" {
+		c.role = delta.Role
+	}
+	if delta.Content != nil {
+		if content, err := delta.ContentString(); err == nil {
+			c.content.WriteString(content)
+			c.hasContent = true
+		}
+	}
+	if delta.Refusal != nil {
+		c.refusal.WriteString(*delta.Refusal)
+		c.hasRefusal = true
+	}
+	for _, toolCall := range delta.ToolCalls {
+		c.addToolCall(toolCall)
+	}
+	if choice.FinishReason != "" {
+		c.finishReason = choice.FinishReason
+	}
+}
+
+// addToolCall merges given partial tool call into the one with the same index.
+//
+// Tool calls without indices are merged into the last one, unless they have new ids.
+func (c *accumulatedChoice) addToolCall(toolCall ToolCall) {
+	i := -1
+	if toolCall.Index != nil {
+		if index, exists := c.toolIndices[*toolCall.Index]; exists {
+			i = index
+		}
+	} else if len(c.toolCalls) > 0 && toolCall.ID == "" {
+		i = len(c.toolCalls) - 1
+	}
+
+	if i < 0 {
+		i = len(c.toolCalls)
+		c.toolCalls = append(c.toolCalls, ToolCall{Type: "function"})
+		if toolCall.Index != nil {
+			c.toolIndices[*toolCall.Index] = i
+		}
+	}
+
+	merged := &c.toolCalls[i]
+	if toolCall.ID != "" {
+		merged.ID = toolCall.ID
+	}
+	if toolCall.Type != "" {
+		merged.Type = toolCall.Type
+	}
+	if toolCall.Function.Name != "" {
+		merged.Function.Name = toolCall.Function.Name
+	}
+	merged.Function.Arguments += toolCall.Function.Arguments
+}
+
+// message returns the accumulated message of the choice.
+func (c *accumulatedChoice) message() ChatMessage {
+	message := ChatMessage{
+		Role: c.role,
+	}
+	if message.Role == "" {
+		message.Role = ChatMessageRoleAssistant
+	}
+	if c.hasContent {
+		message.Content = c.content.String()
+	}
+	if c.hasRefusal {
+		refusal := c.refusal.String()
+		message.Refusal = &refusal
+	}
+	if len(c.toolCalls) > 0 {
+		message.ToolCalls = append([]ToolCall{}, c.toolCalls...)
+	}
+	return message
+}
+
+// ToolCalls returns the tool calls accumulated so far for the choice with given index.
+func (a *ChatCompletionAccumulator) ToolCalls(index int) []ToolCall {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if choice, exists := a.choices[index]; exists && len(choice.toolCalls) > 0 {
+		return append([]ToolCall{}, choice.toolCalls...)
+	}
+	return nil
+}
+
+// ChatCompletion returns the chat completion accumulated so far, with choices ordered by their indices.
+func (a *ChatCompletionAccumulator) ChatCompletion() ChatCompletion {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	completion := a.completion
+	object := "chat.completion"
+	completion.Object = &object
+
+	indices := make([]int, 0, len(a.choices))
+	for index := range a.choices {
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+
+	completion.Choices = make([]ChatCompletionChoice, 0, len(indices))
+	for _, index := range indices {
+		choice := a.choices[index]
+		completion.Choices = append(completion.Choices, ChatCompletionChoice{
+			Index:        index,
+			Message:      choice.message(),
+			FinishReason: choice.finishReason,
+		})
+	}
+	return completion
+}
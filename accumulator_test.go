@@ -0,0 +1,141 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// chunks of a streaming chat completion with 2 choices, parallel tool calls, a refusal, and usage
+var accumulatorTestChunks = []string{
+	`{"id":"chatcmpl-test","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","content":""}},{"index":1,"delta":{"role":"assistant","content":"Hel"}}]}`,
+	`{"id":"chatcmpl-test","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}},{"index":1,"id":"call_2","type":"function","function":{"name":"get_time","arguments":""}}]}}]}`,
+	`{"id":"chatcmpl-test","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"function":{"arguments":"{\"city\":"}},{"index":0,"function":{"arguments":"{\"city\":"}}]}},{"index":1,"delta":{"content":"lo"}}]}`,
+	`{"id":"chatcmpl-test","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Seoul\"}"}},{"index":1,"function":{"arguments":"\"Tokyo\"}"}}]}},{"index":1,"delta":{"refusal":"I can't"}}]}`,
+	`{"id":"chatcmpl-test","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":1,"delta":{"refusal":" help."},"finish_reason":"stop"}]}`,
+	`{"id":"chatcmpl-test","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+	`{"id":"chatcmpl-test","object":"chat.completion.chunk","created":1700000000,"model":"gpt-4o","choices":[],"usage":{"prompt_tokens":10,"completion_tokens":20,"total_tokens":30}}`,
+}
+
+// checks the chat completion rebuilt from `accumulatorTestChunks`
+func checkAccumulatedChatCompletion(t *testing.T, completion ChatCompletion) {
+	t.Helper()
+
+	if completion.ID != "chatcmpl-test" || completion.Model != "gpt-4o" || completion.Created != 1700000000 {
+		t.Errorf("unexpected id, model, or created: %s, %s, %d", completion.ID, completion.Model, completion.Created)
+	}
+	if completion.Object == nil || *completion.Object != "chat.completion" {
+		t.Errorf("expected object 'chat.completion', got %v", completion.Object)
+	}
+	if completion.Usage.TotalTokens != 30 {
+		t.Errorf("expected 30 total tokens, got %d", completion.Usage.TotalTokens)
+	}
+	if len(completion.Choices) != 2 {
+		t.Fatalf("expected 2 choices, got %d", len(completion.Choices))
+	}
+
+	first := completion.Choices[0]
+	if first.Index != 0 || first.FinishReason != "tool_calls" || first.Message.Role != ChatMessageRoleAssistant {
+		t.Errorf("unexpected first choice: %+v", first)
+	}
+	if content, _ := first.Message.ContentString(); content != "" {
+		t.Errorf("expected empty content, got '%s'", content)
+	}
+	if len(first.Message.ToolCalls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %d", len(first.Message.ToolCalls))
+	}
+	for i, expected := range []ToolCallFunction{
+		{Name: "get_weather", Arguments: `{"city":"Seoul"}`},
+		{Name: "get_time", Arguments: `{"city":"Tokyo"}`},
+	} {
+		toolCall := first.Message.ToolCalls[i]
+		if toolCall.ID != fmt.Sprintf("call_%d", i+1) || toolCall.Type != "function" || toolCall.Function != expected {
+			t.Errorf("unexpected tool call #%d: %+v", i, toolCall)
+		}
+	}
+
+	second := completion.Choices[1]
+	if second.Index != 1 || second.FinishReason != "stop" {
+		t.Errorf("unexpected second choice: %+v", second)
+	}
+	if content, _ := second.Message.ContentString(); content != "Hello" {
+		t.Errorf("expected content 'Hello', got '%s'", content)
+	}
+	if second.Message.Refusal == nil || *second.Message.Refusal != "I can't help." {
+		t.Errorf("expected refusal 'I can't help.', got %v", second.Message.Refusal)
+	}
+}
+
+func TestChatCompletionAccumulator(t *testing.T) {
+	acc := NewChatCompletionAccumulator()
+	for _, data := range accumulatorTestChunks {
+		var chunk ChatCompletion
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("failed to decode chunk: %s", err)
+		}
+		acc.Add(chunk)
+	}
+	checkAccumulatedChatCompletion(t, acc.ChatCompletion())
+
+	// tool calls without indices
+	acc = NewChatCompletionAccumulator()
+	acc.Add(ChatCompletion{Choices: []ChatCompletionChoice{{Delta: ChatMessage{ToolCalls: []ToolCall{{ID: "call_1", Function: ToolCallFunction{Name: "a", Arguments: "{"}}}}}}})
+	acc.Add(ChatCompletion{Choices: []ChatCompletionChoice{{Delta: ChatMessage{ToolCalls: []ToolCall{{Function: ToolCallFunction{Arguments: "}"}}}}}}})
+	acc.Add(ChatCompletion{Choices: []ChatCompletionChoice{{Delta: ChatMessage{ToolCalls: []ToolCall{{ID: "call_2", Function: ToolCallFunction{Name: "b", Arguments: "{}"}}}}}}})
+	if toolCalls := acc.ToolCalls(0); len(toolCalls) != 2 || toolCalls[0].Function.Arguments != "{}" || toolCalls[1].Function.Name != "b" {
+		t.Errorf("unexpected tool calls: %+v", toolCalls)
+	}
+	if completion := acc.ChatCompletion(); completion.Choices[0].Message.Content != nil {
+		t.Errorf("expected no content, got %v", completion.Choices[0].Message.Content)
+	}
+}
+
+func TestChatCompletionAccumulatorMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "data: %s\n\n", strings.Join(accumulatorTestChunks, "\n\ndata: "))
+		fmt.Fprintf(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.baseURL = &server.URL
+
+	messages := []ChatMessage{NewChatUserMessage("Hello")}
+
+	// callback: the last item is the whole chat completion
+	completed := make(chan ChatCompletion, 1)
+	if err := client.CreateChatCompletionStreamWithContext(context.TODO(), "gpt-4o", messages, nil, func(response ChatCompletion, done bool, err error) {
+		if err != nil {
+			t.Errorf("failed to receive chunk: %s", err)
+		}
+		if done {
+			completed <- response
+		}
+	}); err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	checkAccumulatedChatCompletion(t, <-completed)
+
+	// stream reader
+	stream, err := client.StreamChatCompletion(context.TODO(), "gpt-4o", messages, nil)
+	if err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	defer stream.Close()
+	for {
+		if _, err := stream.Recv(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("failed to receive chunk: %s", err)
+		}
+	}
+	checkAccumulatedChatCompletion(t, stream.ChatCompletion())
+}
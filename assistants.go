@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -68,6 +69,7 @@ type ToolFunction struct {
 	Name        string                 `json:"name"`
 	Description string                 `json:"description"`
 	Parameters  ToolFunctionParameters `json:"parameters"`
+	Strict      *bool                  `json:"strict,omitempty"`
 }
 
 // CreateAssistantOptions for creating assistant
@@ -124,14 +126,23 @@ func (o CreateAssistantOptions) SetMetadata(metadata map[string]string) CreateAs
 // CreateAssistant creates an assitant with given `model` and `options`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/createAssistant
-func (c *Client) CreateAssistant(model string, options CreateAssistantOptions) (response Assistant, err error) {
+func (c *Client) CreateAssistant(model string, options CreateAssistantOptions, opts ...RequestOption) (response Assistant, err error) {
+	return c.CreateAssistantWithContext(context.Background(), model, options, opts...)
+}
+
+// CreateAssistantWithContext creates an assitant with given `model` and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/createAssistant
+func (c *Client) CreateAssistantWithContext(ctx context.Context, model string, options CreateAssistantOptions, opts ...RequestOption) (response Assistant, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = CreateAssistantOptions{}
 	}
 	options["model"] = model
 
 	var bytes []byte
-	if bytes, err = c.post("v1/assistants", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/assistants", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -139,11 +150,6 @@ func (c *Client) CreateAssistant(model string, options CreateAssistantOptions) (
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Assistant{}, err
@@ -152,9 +158,18 @@ func (c *Client) CreateAssistant(model string, options CreateAssistantOptions) (
 // RetrieveAssistant retrieves an assistant with given `assistantID`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/getAssistant
-func (c *Client) RetrieveAssistant(assistantID string) (response Assistant, err error) {
+func (c *Client) RetrieveAssistant(assistantID string, opts ...RequestOption) (response Assistant, err error) {
+	return c.RetrieveAssistantWithContext(context.Background(), assistantID, opts...)
+}
+
+// RetrieveAssistantWithContext retrieves an assistant with given `assistantID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/getAssistant
+func (c *Client) RetrieveAssistantWithContext(ctx context.Context, assistantID string, opts ...RequestOption) (response Assistant, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/assistants/%s", assistantID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/assistants/%s", assistantID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -162,11 +177,6 @@ func (c *Client) RetrieveAssistant(assistantID string) (response Assistant, err
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Assistant{}, err
@@ -234,13 +244,22 @@ func (o ModifyAssistantOptions) SetMetadata(metadata map[string]string) ModifyAs
 // ModifyAssistant modifies an assistant with given `assistantID` and `options`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/modifyAssistant
-func (c *Client) ModifyAssistant(assistantID string, options ModifyAssistantOptions) (response Assistant, err error) {
+func (c *Client) ModifyAssistant(assistantID string, options ModifyAssistantOptions, opts ...RequestOption) (response Assistant, err error) {
+	return c.ModifyAssistantWithContext(context.Background(), assistantID, options, opts...)
+}
+
+// ModifyAssistantWithContext modifies an assistant with given `assistantID` and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/modifyAssistant
+func (c *Client) ModifyAssistantWithContext(ctx context.Context, assistantID string, options ModifyAssistantOptions, opts ...RequestOption) (response Assistant, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ModifyAssistantOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/assistants/%s", assistantID), options); err == nil {
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/assistants/%s", assistantID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -248,11 +267,6 @@ func (c *Client) ModifyAssistant(assistantID string, options ModifyAssistantOpti
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Assistant{}, err
@@ -269,9 +283,18 @@ type AssistantDeletionStatus struct {
 // DeleteAssistant deletes an assistant with given `assistantID`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/deleteAssistant
-func (c *Client) DeleteAssistant(assistantID string) (response AssistantDeletionStatus, err error) {
+func (c *Client) DeleteAssistant(assistantID string, opts ...RequestOption) (response AssistantDeletionStatus, err error) {
+	return c.DeleteAssistantWithContext(context.Background(), assistantID, opts...)
+}
+
+// DeleteAssistantWithContext deletes an assistant with given `assistantID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/deleteAssistant
+func (c *Client) DeleteAssistantWithContext(ctx context.Context, assistantID string, opts ...RequestOption) (response AssistantDeletionStatus, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.delete(fmt.Sprintf("v1/assistants/%s", assistantID), nil); err == nil {
+	if bytes, err = c.deleteWithContext(ctx, fmt.Sprintf("v1/assistants/%s", assistantID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -279,11 +302,6 @@ func (c *Client) DeleteAssistant(assistantID string) (response AssistantDeletion
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return AssistantDeletionStatus{}, err
@@ -339,13 +357,22 @@ type Assistants struct {
 // ListAssistants lists all assistants with given `options`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/getAssistants
-func (c *Client) ListAssistants(options ListAssistantsOptions) (response Assistants, err error) {
+func (c *Client) ListAssistants(options ListAssistantsOptions, opts ...RequestOption) (response Assistants, err error) {
+	return c.ListAssistantsWithContext(context.Background(), options, opts...)
+}
+
+// ListAssistantsWithContext lists all assistants with given `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/getAssistants
+func (c *Client) ListAssistantsWithContext(ctx context.Context, options ListAssistantsOptions, opts ...RequestOption) (response Assistants, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ListAssistantsOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.get("v1/assistants", options); err == nil {
+	if bytes, err = c.getWithContext(ctx, "v1/assistants", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -353,11 +380,6 @@ func (c *Client) ListAssistants(options ListAssistantsOptions) (response Assista
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Assistants{}, err
@@ -377,9 +399,18 @@ type AssistantFile struct {
 // CreateAssistantFile creates an assistant file by attaching given `fileID` to an assistant with `assistantID`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/createAssistantFile
-func (c *Client) CreateAssistantFile(assistantID, fileID string) (response AssistantFile, err error) {
+func (c *Client) CreateAssistantFile(assistantID, fileID string, opts ...RequestOption) (response AssistantFile, err error) {
+	return c.CreateAssistantFileWithContext(context.Background(), assistantID, fileID, opts...)
+}
+
+// CreateAssistantFileWithContext creates an assistant file by attaching given `fileID` to an assistant with `assistantID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/createAssistantFile
+func (c *Client) CreateAssistantFileWithContext(ctx context.Context, assistantID, fileID string, opts ...RequestOption) (response AssistantFile, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/assistants/%s/files", assistantID), map[string]any{
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/assistants/%s/files", assistantID), map[string]any{
 		"file_id": fileID,
 	}); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
@@ -389,11 +420,6 @@ func (c *Client) CreateAssistantFile(assistantID, fileID string) (response Assis
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return AssistantFile{}, err
@@ -402,9 +428,18 @@ func (c *Client) CreateAssistantFile(assistantID, fileID string) (response Assis
 // RetrieveAssistantFile retrieves an assistant file by given `assistantID` and `fileID`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/getAssistantFile
-func (c *Client) RetrieveAssistantFile(assistantID, fileID string) (response AssistantFile, err error) {
+func (c *Client) RetrieveAssistantFile(assistantID, fileID string, opts ...RequestOption) (response AssistantFile, err error) {
+	return c.RetrieveAssistantFileWithContext(context.Background(), assistantID, fileID, opts...)
+}
+
+// RetrieveAssistantFileWithContext retrieves an assistant file by given `assistantID` and `fileID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/getAssistantFile
+func (c *Client) RetrieveAssistantFileWithContext(ctx context.Context, assistantID, fileID string, opts ...RequestOption) (response AssistantFile, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/assistants/%s/files/%s", assistantID, fileID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/assistants/%s/files/%s", assistantID, fileID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -412,11 +447,6 @@ func (c *Client) RetrieveAssistantFile(assistantID, fileID string) (response Ass
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return AssistantFile{}, err
@@ -433,9 +463,18 @@ type AssistantFileDeletionStatus struct {
 // DeleteAssistantFile deletes an assistant file by given `assistantID` and `fileID`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/deleteAssistantFile
-func (c *Client) DeleteAssistantFile(assistantID, fileID string) (response AssistantFileDeletionStatus, err error) {
+func (c *Client) DeleteAssistantFile(assistantID, fileID string, opts ...RequestOption) (response AssistantFileDeletionStatus, err error) {
+	return c.DeleteAssistantFileWithContext(context.Background(), assistantID, fileID, opts...)
+}
+
+// DeleteAssistantFileWithContext deletes an assistant file by given `assistantID` and `fileID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/deleteAssistantFile
+func (c *Client) DeleteAssistantFileWithContext(ctx context.Context, assistantID, fileID string, opts ...RequestOption) (response AssistantFileDeletionStatus, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.delete(fmt.Sprintf("v1/assistants/%s/files/%s", assistantID, fileID), nil); err == nil {
+	if bytes, err = c.deleteWithContext(ctx, fmt.Sprintf("v1/assistants/%s/files/%s", assistantID, fileID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -443,11 +482,6 @@ func (c *Client) DeleteAssistantFile(assistantID, fileID string) (response Assis
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return AssistantFileDeletionStatus{}, err
@@ -503,13 +537,22 @@ func (o ListAssistantFilesOptions) SetBefore(before string) ListAssistantFilesOp
 // ListAssistantFiles lists all assistant files with given `assistantID` and `options`.
 //
 // https://platform.openai.com/docs/api-reference/assistants/listAssistantFiles
-func (c *Client) ListAssistantFiles(assistantID string, options ListAssistantFilesOptions) (response AssistantFiles, err error) {
+func (c *Client) ListAssistantFiles(assistantID string, options ListAssistantFilesOptions, opts ...RequestOption) (response AssistantFiles, err error) {
+	return c.ListAssistantFilesWithContext(context.Background(), assistantID, options, opts...)
+}
+
+// ListAssistantFilesWithContext lists all assistant files with given `assistantID` and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants/listAssistantFiles
+func (c *Client) ListAssistantFilesWithContext(ctx context.Context, assistantID string, options ListAssistantFilesOptions, opts ...RequestOption) (response AssistantFiles, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ListAssistantFilesOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/assistants/%s/files", assistantID), options); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/assistants/%s/files", assistantID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -517,11 +560,6 @@ func (c *Client) ListAssistantFiles(assistantID string, options ListAssistantFil
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return AssistantFiles{}, err
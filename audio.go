@@ -1,8 +1,8 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 )
 
 // https://platform.openai.com/docs/api-reference/audio
@@ -58,7 +58,16 @@ func (o SpeechOptions) SetSpeed(speed float32) SpeechOptions {
 // CreateSpeech generates audio from the input text.
 //
 // https://platform.openai.com/docs/api-reference/audio/createSpeech
-func (c *Client) CreateSpeech(model string, input string, voice SpeechVoice, options SpeechOptions) (audio []byte, err error) {
+func (c *Client) CreateSpeech(model string, input string, voice SpeechVoice, options SpeechOptions, opts ...RequestOption) (audio []byte, err error) {
+	return c.CreateSpeechWithContext(context.Background(), model, input, voice, options, opts...)
+}
+
+// CreateSpeechWithContext generates audio from the input text with context support.
+//
+// https://platform.openai.com/docs/api-reference/audio/createSpeech
+func (c *Client) CreateSpeechWithContext(ctx context.Context, model string, input string, voice SpeechVoice, options SpeechOptions, opts ...RequestOption) (audio []byte, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = SpeechOptions{}
 	}
@@ -67,13 +76,8 @@ func (c *Client) CreateSpeech(model string, input string, voice SpeechVoice, opt
 	options["voice"] = voice
 
 	var bytes []byte
-	if bytes, err = c.post("v1/audio/speech", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/audio/speech", options); err == nil {
 		return bytes, nil
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return nil, err
@@ -128,7 +132,16 @@ func (o TranscriptionOptions) SetLanguage(language string) TranscriptionOptions
 // CreateTranscription transcribes given audio file into the input language.
 //
 // https://platform.openai.com/docs/api-reference/audio/create
-func (c *Client) CreateTranscription(file FileParam, model string, options TranscriptionOptions) (response Transcription, err error) {
+func (c *Client) CreateTranscription(file FileParam, model string, options TranscriptionOptions, opts ...RequestOption) (response Transcription, err error) {
+	return c.CreateTranscriptionWithContext(context.Background(), file, model, options, opts...)
+}
+
+// CreateTranscriptionWithContext transcribes given audio file into the input language with context support.
+//
+// https://platform.openai.com/docs/api-reference/audio/create
+func (c *Client) CreateTranscriptionWithContext(ctx context.Context, file FileParam, model string, options TranscriptionOptions, opts ...RequestOption) (response Transcription, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = TranscriptionOptions{}
 	}
@@ -136,7 +149,7 @@ func (c *Client) CreateTranscription(file FileParam, model string, options Trans
 	options["model"] = model
 
 	var bytes []byte
-	if bytes, err = c.post("v1/audio/transcriptions", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/audio/transcriptions", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -144,11 +157,6 @@ func (c *Client) CreateTranscription(file FileParam, model string, options Trans
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Transcription{}, err
@@ -190,7 +198,16 @@ func (o TranslationOptions) SetTemperature(temperature float64) TranslationOptio
 // CreateTranslation translates given audio file into English.
 //
 // https://platform.openai.com/docs/api-reference/audio/create
-func (c *Client) CreateTranslation(file FileParam, model string, options TranslationOptions) (response Translation, err error) {
+func (c *Client) CreateTranslation(file FileParam, model string, options TranslationOptions, opts ...RequestOption) (response Translation, err error) {
+	return c.CreateTranslationWithContext(context.Background(), file, model, options, opts...)
+}
+
+// CreateTranslationWithContext translates given audio file into English with context support.
+//
+// https://platform.openai.com/docs/api-reference/audio/create
+func (c *Client) CreateTranslationWithContext(ctx context.Context, file FileParam, model string, options TranslationOptions, opts ...RequestOption) (response Translation, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = TranslationOptions{}
 	}
@@ -198,7 +215,7 @@ func (c *Client) CreateTranslation(file FileParam, model string, options Transla
 	options["model"] = model
 
 	var bytes []byte
-	if bytes, err = c.post("v1/audio/translations", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/audio/translations", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -206,11 +223,6 @@ func (c *Client) CreateTranslation(file FileParam, model string, options Transla
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Translation{}, err
@@ -0,0 +1,149 @@
+package openai
+
+// types and functions for Azure OpenAI
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	kAzureAPIKey = "Api-Key"
+
+	kAzureAPIVersion = "api-version"
+)
+
+// endpoints which are scoped to a deployment on Azure OpenAI
+var azureDeploymentEndpoints = map[string]bool{
+	"chat/completions":     true,
+	"completions":          true,
+	"embeddings":           true,
+	"images/generations":   true,
+	"images/edits":         true,
+	"images/variations":    true,
+	"audio/speech":         true,
+	"audio/transcriptions": true,
+	"audio/translations":   true,
+}
+
+// AzureTokenProvider interface for providing bearer tokens (eg. Microsoft Entra ID) for Azure OpenAI
+type AzureTokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// AzureTokenProviderFunc type for using ordinary functions as AzureTokenProvider
+type AzureTokenProviderFunc func(ctx context.Context) (string, error)
+
+// Token calls `f(ctx)`.
+func (f AzureTokenProviderFunc) Token(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// AzureConfig struct for sending requests to Azure OpenAI
+type AzureConfig struct {
+	// endpoint of the resource (eg. "https://my-resource.openai.azure.com")
+	Endpoint string
+
+	// value of `api-version` query parameter (eg. "2024-10-21")
+	APIVersion string
+
+	// deployment names for model names (model names are used as deployment names when not mapped)
+	Deployments map[string]string
+
+	// if set, bearer tokens from it are sent instead of the `api-key` header
+	TokenProvider AzureTokenProvider
+}
+
+// SetAzure makes the client send requests to Azure OpenAI with given config.
+//
+// The client's API key is sent as `api-key` header, unless a token provider is set in the config.
+func (c *Client) SetAzure(config AzureConfig) *Client {
+	c.azure = &config
+
+	return c
+}
+
+// WithAzure makes the client send requests to Azure OpenAI with given config.
+func WithAzure(config AzureConfig) ClientOption {
+	return func(c *Client) {
+		c.SetAzure(config)
+	}
+}
+
+// NewAzureClient returns a new API client for Azure OpenAI, authenticated with given API key.
+func NewAzureClient(endpoint, apiKey, apiVersion string, options ...ClientOption) *Client {
+	return NewClientWithOptions(apiKey, append([]ClientOption{
+		WithAzure(AzureConfig{
+			Endpoint:   endpoint,
+			APIVersion: apiVersion,
+		}),
+	}, options...)...)
+}
+
+// deployment returns the deployment name for given model name.
+func (a *AzureConfig) deployment(model string) string {
+	if deployment, exists := a.Deployments[model]; exists {
+		return deployment
+	}
+	return model
+}
+
+// url returns the Azure OpenAI URL and params for given endpoint and params.
+//
+// For the Responses API, the model name in params is replaced with its deployment name.
+func (a *AzureConfig) url(endpoint string, params map[string]any) (string, map[string]any, error) {
+	path, query, _ := strings.Cut(strings.TrimPrefix(endpoint, "v1/"), "?")
+	base := strings.TrimSuffix(a.Endpoint, "/")
+	model := modelOf(params)
+
+	var apiURL string
+	if azureDeploymentEndpoints[path] {
+		deployment := a.deployment(model)
+		if deployment == "" {
+			return "", nil, fmt.Errorf("no deployment for endpoint '%s': model is not specified", endpoint)
+		}
+		apiURL = fmt.Sprintf("%s/openai/deployments/%s/%s", base, url.PathEscape(deployment), path)
+	} else {
+		apiURL = fmt.Sprintf("%s/openai/%s", base, path)
+
+		if strings.HasPrefix(path, "responses") && model != "" {
+			if deployment := a.deployment(model); deployment != model {
+				copied := make(map[string]any, len(params))
+				for k, v := range params {
+					copied[k] = v
+				}
+				copied["model"] = deployment
+				params = copied
+			}
+		}
+	}
+
+	if a.APIVersion != "" {
+		if query != "" {
+			query += "&"
+		}
+		query += fmt.Sprintf("%s=%s", kAzureAPIVersion, url.QueryEscape(a.APIVersion))
+	}
+	if query != "" {
+		apiURL = fmt.Sprintf("%s?%s", apiURL, query)
+	}
+
+	return apiURL, params, nil
+}
+
+// setAuthHeaders sets the Azure OpenAI authentication headers of given request.
+func (a *AzureConfig) setAuthHeaders(ctx context.Context, req *http.Request, apiKey string) error {
+	if a.TokenProvider != nil {
+		token, err := a.TokenProvider.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get token for Azure OpenAI: %w", err)
+		}
+		req.Header.Set(kAuthorization, fmt.Sprintf("Bearer %s", token))
+	} else {
+		req.Header.Set(kAzureAPIKey, apiKey)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("api-version") != "2024-10-21" {
+			t.Errorf("unexpected api-version: %s", r.URL.RawQuery)
+		}
+		if r.Header.Get(kOrganization) != "" {
+			t.Errorf("organization header should not be sent to Azure OpenAI")
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case "/openai/deployments/my-embeddings/embeddings":
+			if r.Header.Get("api-key") != "azure-key" || r.Header.Get(kAuthorization) != "" {
+				t.Errorf("unexpected auth headers: %v", r.Header)
+			}
+			w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]}],"model":"text-embedding-3-small"}`))
+		case "/openai/responses":
+			if r.Header.Get(kAuthorization) != "Bearer entra-token" || r.Header.Get("api-key") != "" {
+				t.Errorf("unexpected auth headers: %v", r.Header)
+			}
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["model"] != "my-gpt" {
+				t.Errorf("model was not replaced with deployment: %v (%v)", body, err)
+			}
+			w.Write([]byte(`{"id":"resp-test","object":"response","status":"completed","model":"gpt-4o","output":[]}`))
+		default:
+			t.Errorf("unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewAzureClient(server.URL+"/", "azure-key", "2024-10-21", WithOrganization("test-org"))
+	client.azure.Deployments = map[string]string{
+		"text-embedding-3-small": "my-embeddings",
+		"gpt-4o":                 "my-gpt",
+	}
+
+	if _, err := client.CreateEmbedding("text-embedding-3-small", "hello", nil); err != nil {
+		t.Errorf("failed to create embedding: %s", err)
+	}
+
+	client.SetAzure(AzureConfig{
+		Endpoint:    server.URL,
+		APIVersion:  "2024-10-21",
+		Deployments: map[string]string{"gpt-4o": "my-gpt"},
+		TokenProvider: AzureTokenProviderFunc(func(ctx context.Context) (string, error) {
+			return "entra-token", nil
+		}),
+	})
+	if _, err := client.CreateResponse("gpt-4o", "hello", nil); err != nil {
+		t.Errorf("failed to create response: %s", err)
+	}
+
+	// failing token provider
+	client.azure.TokenProvider = AzureTokenProviderFunc(func(ctx context.Context) (string, error) {
+		return "", errors.New("token expired")
+	})
+	if _, err := client.CreateResponse("gpt-4o", "hello", nil); err == nil {
+		t.Errorf("should fail when token provider fails")
+	}
+
+	// missing deployment
+	if _, err := client.CreateImage("a cat", nil); err == nil {
+		t.Errorf("should fail without model for deployment-scoped endpoint")
+	}
+}
+
+func TestAzureURL(t *testing.T) {
+	config := AzureConfig{
+		Endpoint:    "https://my-resource.openai.azure.com",
+		APIVersion:  "2024-10-21",
+		Deployments: map[string]string{"whisper-1": "my whisper"},
+	}
+
+	for _, tc := range []struct {
+		endpoint string
+		params   map[string]any
+		expected string
+	}{
+		{"v1/chat/completions", map[string]any{"model": "gpt-4o"}, "https://my-resource.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21"},
+		{"v1/audio/transcriptions", map[string]any{"model": "whisper-1"}, "https://my-resource.openai.azure.com/openai/deployments/my%20whisper/audio/transcriptions?api-version=2024-10-21"},
+		{"v1/files", nil, "https://my-resource.openai.azure.com/openai/files?api-version=2024-10-21"},
+	} {
+		if url, _, err := config.url(tc.endpoint, tc.params); err != nil {
+			t.Errorf("failed to build url for %s: %s", tc.endpoint, err)
+		} else if url != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, url)
+		}
+	}
+}
@@ -0,0 +1,447 @@
+package openai
+
+// types and functions for enforcing spend budgets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// number of output tokens assumed for estimating the cost of requests without `max_tokens`, `max_completion_tokens`, or `max_output_tokens`
+	DefaultBudgetMaxOutputTokens = 4096
+)
+
+const (
+	// bytes per second assumed for estimating the duration of audio files (32kbps, lower than most encoded audio)
+	budgetAudioBytesPerSecond = 4_000
+
+	// size assumed for audio files of unknown sizes (the maximum size of audio files)
+	budgetMaxAudioFileSize = 25 * 1024 * 1024
+
+	// number of input (audio) and output (text) tokens assumed for each second of audio
+	budgetAudioInputTokensPerSecond  = 10
+	budgetAudioOutputTokensPerSecond = 5
+)
+
+// Budget struct for a hard limit of spending (in USD), eg. per tenant, per job, or per day
+//
+// Before a request is sent, its maximum cost is estimated from its prompt size, maximum number of output tokens,
+// image count and size, and the budget's pricing table. If the budget would be exceeded, the request is rejected
+// with `*BudgetExceededError`. After the response, the estimated cost is replaced with the actual one.
+//
+// Requests for models which are not in the pricing table are rejected with `*UnpricedModelError`,
+// unless they are allowed with `SetAllowUnpricedModels` (then they cost nothing).
+type Budget struct {
+	name          string
+	limit         float64
+	pricing       PricingTable
+	period        time.Duration
+	allowUnpriced bool
+
+	mu          sync.Mutex
+	spent       float64
+	reserved    float64
+	periodStart time.Time
+
+	now func() time.Time
+}
+
+// NewBudget returns a new Budget with given name, limit (in USD), and pricing table.
+func NewBudget(name string, limit float64, pricing PricingTable) *Budget {
+	return &Budget{
+		name:        name,
+		limit:       limit,
+		pricing:     pricing,
+		periodStart: time.Now(),
+		now:         time.Now,
+	}
+}
+
+// SetPeriod makes the budget reset its spending every `period` (eg. 24 hours for a daily budget, aligned to UTC).
+//
+// 0 means the budget is never reset.
+func (b *Budget) SetPeriod(period time.Duration) *Budget {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.period = period
+	b.periodStart = b.currentPeriodStart()
+
+	return b
+}
+
+// SetAllowUnpricedModels sets whether requests for models which are not in the pricing table are allowed
+// (at no cost) instead of being rejected.
+func (b *Budget) SetAllowUnpricedModels(allow bool) *Budget {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.allowUnpriced = allow
+
+	return b
+}
+
+// Name returns the name of the budget.
+func (b *Budget) Name() string {
+	return b.name
+}
+
+// Limit returns the limit of the budget.
+func (b *Budget) Limit() float64 {
+	return b.limit
+}
+
+// Spent returns the amount spent in the current period, including the estimated costs of requests in flight.
+func (b *Budget) Spent() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll()
+	return b.spent + b.reserved
+}
+
+// Remaining returns the remaining amount of the budget in the current period.
+func (b *Budget) Remaining() float64 {
+	return b.limit - b.Spent()
+}
+
+// allowsUnpriced returns whether requests for models which are not in the pricing table are allowed.
+func (b *Budget) allowsUnpriced() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.allowUnpriced
+}
+
+// Reset clears the amount spent.
+func (b *Budget) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.spent = 0
+	b.periodStart = b.currentPeriodStart()
+}
+
+// currentPeriodStart returns the start of the current period (`b.mu` should be locked).
+func (b *Budget) currentPeriodStart() time.Time {
+	now := b.now()
+	if b.period <= 0 {
+		return now
+	}
+	return now.UTC().Truncate(b.period)
+}
+
+// roll resets the amount spent if a new period has started (`b.mu` should be locked).
+//
+// Costs of requests in flight are kept.
+func (b *Budget) roll() {
+	if b.period <= 0 {
+		return
+	}
+	if start := b.currentPeriodStart(); start.After(b.periodStart) {
+		b.spent = 0
+		b.periodStart = start
+	}
+}
+
+// reserve takes given estimated cost from the budget, or returns an error if it would exceed the budget.
+func (b *Budget) reserve(estimated float64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll()
+	if b.spent+b.reserved+estimated > b.limit {
+		return &BudgetExceededError{
+			Budget:    b.name,
+			Limit:     b.limit,
+			Spent:     b.spent + b.reserved,
+			Estimated: estimated,
+		}
+	}
+	b.reserved += estimated
+	return nil
+}
+
+// settle replaces the estimated cost with the actual one.
+func (b *Budget) settle(estimated, actual float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll()
+	b.reserved -= estimated
+	if b.reserved < 0 {
+		b.reserved = 0
+	}
+	b.spent += actual
+}
+
+// SetBudgets sets the budgets which all requests of the client should be within.
+func (c *Client) SetBudgets(budgets ...*Budget) *Client {
+	c.budgets = budgets
+
+	return c
+}
+
+// WithBudgets sets the budgets which all requests of the client should be within.
+func WithBudgets(budgets ...*Budget) ClientOption {
+	return func(c *Client) {
+		c.SetBudgets(budgets...)
+	}
+}
+
+type budgetsContextKey struct{}
+
+// ContextWithBudgets returns a copy of `ctx` which makes requests with it be within given budgets
+// (in addition to the client's budgets and the ones already attached to `ctx`),
+// so that a client shared by many tenants can enforce the budget of each tenant.
+func ContextWithBudgets(ctx context.Context, budgets ...*Budget) context.Context {
+	merged := append(append([]*Budget{}, budgetsFromContext(ctx)...), budgets...)
+	return context.WithValue(ctx, budgetsContextKey{}, merged)
+}
+
+// budgetsFromContext returns the budgets in `ctx`.
+func budgetsFromContext(ctx context.Context) []*Budget {
+	budgets, _ := ctx.Value(budgetsContextKey{}).([]*Budget)
+	return budgets
+}
+
+// BudgetExceededError struct for errors of requests rejected by budgets
+type BudgetExceededError struct {
+	Budget    string  `json:"budget"`
+	Limit     float64 `json:"limit"`
+	Spent     float64 `json:"spent"`
+	Estimated float64 `json:"estimated"`
+}
+
+// Error returns the string representation of BudgetExceededError.
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("budget '%s' would be exceeded: spent $%.6f + estimated $%.6f > limit $%.6f", e.Budget, e.Spent, e.Estimated, e.Limit)
+}
+
+// AsBudgetExceededError returns the `*BudgetExceededError` in given error's chain, or nil if there is none.
+func AsBudgetExceededError(err error) *BudgetExceededError {
+	var budgetErr *BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		return budgetErr
+	}
+	return nil
+}
+
+// UnpricedModelError struct for errors of requests rejected by budgets without the prices of their models
+type UnpricedModelError struct {
+	Budget string `json:"budget"`
+	Model  string `json:"model"`
+}
+
+// Error returns the string representation of UnpricedModelError.
+func (e *UnpricedModelError) Error() string {
+	return fmt.Sprintf("budget '%s' has no pricing for model '%s'", e.Budget, e.Model)
+}
+
+// AsUnpricedModelError returns the `*UnpricedModelError` in given error's chain, or nil if there is none.
+func AsUnpricedModelError(err error) *UnpricedModelError {
+	var unpricedErr *UnpricedModelError
+	if errors.As(err, &unpricedErr) {
+		return unpricedErr
+	}
+	return nil
+}
+
+// budgetReservation struct for the estimated costs of a request, taken from budgets
+type budgetReservation struct {
+	endpoint string
+	params   map[string]any
+	holds    []budgetHold
+	settled  bool
+}
+
+// budgetHold struct for an estimated cost taken from a budget
+type budgetHold struct {
+	budget    *Budget
+	estimated float64
+}
+
+// reserveBudgets takes the estimated cost of given request from the budgets of the client and `ctx`.
+//
+// It returns nil if there are no budgets for the request.
+func (c *Client) reserveBudgets(ctx context.Context, endpoint string, params map[string]any) (*budgetReservation, error) {
+	if !trackedEndpoints[endpoint] {
+		return nil, nil
+	}
+
+	budgets := append(append([]*Budget{}, c.budgets...), budgetsFromContext(ctx)...)
+	if len(budgets) == 0 {
+		return nil, nil
+	}
+
+	params = requestOptionsFromContext(ctx).params(params)
+	estimation := estimateUsage(endpoint, params)
+
+	r := &budgetReservation{endpoint: endpoint, params: params}
+	seen := map[*Budget]bool{}
+	for _, budget := range budgets {
+		if budget == nil || seen[budget] {
+			continue
+		}
+		seen[budget] = true
+
+		var estimated float64
+		if pricing, exists := budget.pricing.pricing(estimation.Model); exists {
+			estimated = estimation.cost(pricing)
+		} else if !budget.allowsUnpriced() {
+			r.release()
+			return nil, &UnpricedModelError{Budget: budget.name, Model: estimation.Model}
+		}
+		if err := budget.reserve(estimated); err != nil {
+			r.release()
+			return nil, err
+		}
+		r.holds = append(r.holds, budgetHold{budget: budget, estimated: estimated})
+	}
+	return r, nil
+}
+
+// settle replaces the estimated costs with the actual cost in given response bytes.
+//
+// If `response` is nil, the estimated costs are kept as spent (eg. streams without usages).
+func (r *budgetReservation) settle(response []byte) {
+	if r == nil || r.settled {
+		return
+	}
+	r.settled = true
+
+	var actual *UsageRecord
+	if response != nil {
+		record := usageRecordOf(r.endpoint, r.params, response)
+		actual = &record
+
+		// transcriptions and translations in some formats (eg. text, srt, or vtt) have no usages,
+		// so the estimated costs are kept
+		if isAudioInputEndpoint(r.endpoint) && record.TotalTokens == 0 && record.AudioSeconds == 0 {
+			actual = nil
+		}
+	}
+	for _, hold := range r.holds {
+		cost := hold.estimated
+		if actual != nil {
+			// (responses may have more specific model names than the requested ones)
+			if pricing, exists := hold.budget.pricing.pricing(actual.Model); exists {
+				cost = actual.cost(pricing)
+			} else if pricing, exists := hold.budget.pricing.pricing(stringParam(r.params, "model")); exists {
+				cost = actual.cost(pricing)
+			}
+		}
+		hold.budget.settle(hold.estimated, cost)
+	}
+}
+
+// release gives the estimated costs back to the budgets (eg. for failed requests).
+func (r *budgetReservation) release() {
+	if r == nil || r.settled {
+		return
+	}
+	r.settled = true
+
+	for _, hold := range r.holds {
+		hold.budget.settle(hold.estimated, 0)
+	}
+}
+
+// isAudioInputEndpoint checks if given endpoint takes audio files (transcriptions and translations).
+func isAudioInputEndpoint(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "v1/audio/") && endpoint != "v1/audio/speech"
+}
+
+// estimateUsage estimates the maximum usage of given request.
+func estimateUsage(endpoint string, params map[string]any) UsageRecord {
+	record := UsageRecord{
+		Model:    stringParam(params, "model"),
+		Requests: 1,
+	}
+
+	switch {
+	case strings.HasPrefix(endpoint, "v1/images/"):
+		n := intParam(params, "n")
+		if n <= 0 {
+			n = 1
+		}
+		record.Images = map[string]int{imageKey(params): n}
+	case endpoint == "v1/audio/speech":
+		record.AudioCharacters = utf8.RuneCountInString(stringParam(params, "input"))
+	case isAudioInputEndpoint(endpoint):
+		// duration of audio files is not known before the response, so it is estimated from their sizes
+		record.AudioSeconds = estimateAudioSeconds(params)
+		record.InputTokens = int(record.AudioSeconds * budgetAudioInputTokensPerSecond)
+		record.OutputTokens = int(record.AudioSeconds * budgetAudioOutputTokensPerSecond)
+	case endpoint == "v1/embeddings" || endpoint == "v1/moderations":
+		record.InputTokens = estimatePromptTokens(params)
+	default:
+		record.InputTokens = estimatePromptTokens(params)
+		record.OutputTokens = DefaultBudgetMaxOutputTokens
+		for _, k := range []string{"max_tokens", "max_completion_tokens", "max_output_tokens"} {
+			if v := intParam(params, k); v > 0 {
+				record.OutputTokens = v
+				break
+			}
+		}
+		if n := intParam(params, "n"); n > 1 {
+			record.OutputTokens *= n
+		}
+	}
+	record.TotalTokens = record.InputTokens + record.OutputTokens
+
+	return record
+}
+
+// estimateAudioSeconds roughly estimates the maximum duration of the audio files in given params.
+func estimateAudioSeconds(params map[string]any) float64 {
+	var size int64
+	for _, v := range params {
+		if f, ok := v.(FileParam); ok {
+			if f.size >= 0 {
+				size += f.size
+			} else {
+				size += budgetMaxAudioFileSize
+			}
+		}
+	}
+	return float64(size) / budgetAudioBytesPerSecond
+}
+
+// estimatePromptTokens roughly estimates the number of prompt tokens (about 4 characters per token).
+func estimatePromptTokens(params map[string]any) int {
+	chars := 0
+	for _, k := range []string{"messages", "input", "prompt", "instructions", "tools"} {
+		if v, exists := params[k]; exists {
+			if bs, err := json.Marshal(v); err == nil {
+				chars += len(bs)
+			}
+		}
+	}
+	return (chars + 3) / 4
+}
+
+// intParam returns the integer value of given param, or 0 if it is missing or not a number.
+func intParam(params map[string]any, key string) int {
+	switch v := params[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case json.Number:
+		if i, err := strconv.Atoi(string(v)); err == nil {
+			return i
+		}
+	}
+	return 0
+}
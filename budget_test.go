@@ -0,0 +1,186 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBudgetMock(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/images/generations":
+			w.Write([]byte(`{"created":0,"data":[{"url":"https://example.com/1.png"}]}`))
+		default:
+			w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1000,"completion_tokens":100,"total_tokens":1100}}`))
+		}
+	}))
+	defer server.Close()
+
+	pricing := PricingTable{
+		"gpt-4o":   {InputPerMillion: 1000, OutputPerMillion: 1000}, // $0.001 per token
+		"dall-e-3": {PerImage: map[string]float64{"1024x1024": 0.5}},
+	}
+
+	global := NewBudget("global", 100, pricing)
+	client := NewClientWithOptions("test-key", WithBaseURL(server.URL), WithBudgets(global))
+
+	tenant := NewBudget("tenant-a", 2, pricing)
+	ctx := ContextWithBudgets(context.Background(), tenant)
+	messages := []ChatMessage{NewChatUserMessage("Hello")}
+
+	// estimated: ~$0.01 (prompt) + $1 (max tokens), actual: $1.1
+	if _, err := client.CreateChatCompletionWithContext(ctx, "gpt-4o", messages, ChatCompletionOptions{}.SetMaxTokens(1000)); err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	}
+	if !almostEqual(tenant.Spent(), 1.1) || !almostEqual(global.Spent(), 1.1) {
+		t.Errorf("spent should be reconciled with the actual usage: %f, %f", tenant.Spent(), global.Spent())
+	}
+
+	// rejected before sending
+	_, err := client.CreateChatCompletionWithContext(ctx, "gpt-4o", messages, ChatCompletionOptions{}.SetMaxTokens(1000))
+	if budgetErr := AsBudgetExceededError(err); budgetErr == nil {
+		t.Errorf("expected budget error, got %v", err)
+	} else if budgetErr.Budget != "tenant-a" || budgetErr.Limit != 2 || !almostEqual(budgetErr.Spent, 1.1) || budgetErr.Estimated <= 1 {
+		t.Errorf("unexpected budget error: %+v", budgetErr)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("rejected request should not be sent: %d requests", n)
+	}
+	if !almostEqual(global.Spent(), 1.1) {
+		t.Errorf("rejected request should not spend other budgets: %f", global.Spent())
+	}
+
+	// smaller requests fit in the budget
+	if _, err := client.CreateChatCompletionWithContext(ctx, "gpt-4o", messages, ChatCompletionOptions{}.SetMaxTokens(100)); err != nil {
+		t.Errorf("request within the budget should be sent: %s", err)
+	}
+
+	// other tenants are not affected
+	other := NewBudget("tenant-b", 2, pricing)
+	if _, err := client.CreateChatCompletionWithContext(ContextWithBudgets(context.Background(), other), "gpt-4o", messages, ChatCompletionOptions{}.SetMaxTokens(1000)); err != nil {
+		t.Errorf("request of another tenant should be sent: %s", err)
+	}
+
+	// images
+	images := NewBudget("images", 1, pricing)
+	imageCtx := ContextWithBudgets(context.Background(), images)
+	if _, err := client.CreateImageWithContext(imageCtx, "a cat", ImageOptions{}.SetModel("dall-e-3").SetN(3)); AsBudgetExceededError(err) == nil {
+		t.Errorf("expected budget error for 3 images, got %v", err)
+	}
+	if _, err := client.CreateImageWithContext(imageCtx, "a cat", ImageOptions{}.SetModel("dall-e-3").SetN(2)); err != nil {
+		t.Errorf("failed to create images: %s", err)
+	} else if !almostEqual(images.Spent(), 0.5) {
+		t.Errorf("spent should be reconciled with the number of generated images: %f", images.Spent())
+	}
+
+	// unpriced models are rejected unless allowed
+	_, err = client.CreateChatCompletionWithContext(context.Background(), "unpriced-model", messages, nil)
+	if unpricedErr := AsUnpricedModelError(err); unpricedErr == nil || unpricedErr.Budget != "global" || unpricedErr.Model != "unpriced-model" {
+		t.Errorf("expected unpriced model error, got %v", err)
+	}
+	global.SetAllowUnpricedModels(true)
+	before := global.Spent()
+	if _, err := client.CreateChatCompletionWithContext(context.Background(), "unpriced-model", messages, nil); err != nil {
+		t.Errorf("unpriced model should be allowed: %s", err)
+	} else if !almostEqual(global.Spent(), before) {
+		t.Errorf("unpriced model should cost nothing: %f => %f", before, global.Spent())
+	}
+	global.SetAllowUnpricedModels(false)
+
+	// failed requests give the estimated cost back
+	server.Close()
+	before = global.Spent()
+	if _, err := client.CreateChatCompletionWithContext(context.Background(), "gpt-4o", messages, nil); err == nil {
+		t.Errorf("request to closed server should fail")
+	}
+	if !almostEqual(global.Spent(), before) {
+		t.Errorf("failed request should not spend the budget: %f => %f", before, global.Spent())
+	}
+}
+
+func TestBudgetPeriod(t *testing.T) {
+	now := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	budget := NewBudget("daily", 1, nil)
+	budget.now = func() time.Time { return now }
+	budget.SetPeriod(24 * time.Hour)
+
+	if err := budget.reserve(0.8); err != nil {
+		t.Fatalf("failed to reserve: %s", err)
+	}
+	budget.settle(0.8, 0.7)
+	if err := budget.reserve(0.5); err == nil {
+		t.Errorf("should exceed the budget")
+	}
+
+	now = now.Add(2 * time.Hour) // next day
+	if err := budget.reserve(0.5); err != nil {
+		t.Errorf("budget should be reset on the next day: %s", err)
+	}
+	if !almostEqual(budget.Spent(), 0.5) || !almostEqual(budget.Remaining(), 0.5) {
+		t.Errorf("unexpected spent: %f", budget.Spent())
+	}
+}
+
+func TestBudgetAudioMock(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		switch r.FormValue("response_format") {
+		case "text":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("Hello"))
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"text":"Hello"}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"text":"Hello","duration":30}`))
+		}
+	}))
+	defer server.Close()
+
+	budget := NewBudget("audio", 1, PricingTable{
+		"whisper-1": {PerAudioMinute: 0.5},
+	})
+	client := NewClientWithOptions("test-key", WithBaseURL(server.URL), WithBudgets(budget))
+
+	// 1MB (estimated: about 4 minutes) is rejected before sending
+	large := NewFileParamFromBytesWithFilename(make([]byte, 1_000_000), "large.mp3", "audio/mpeg")
+	if _, err := client.CreateTranscription(large, "whisper-1", TranscriptionOptions{}.SetResponseFormat(TranscriptionResponseFormatVerboseJSON)); AsBudgetExceededError(err) == nil {
+		t.Errorf("expected budget error for large audio file, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 0 {
+		t.Errorf("rejected request should not be sent: %d requests", n)
+	}
+
+	// 100KB (estimated: 25 seconds) is sent, and reconciled with the actual duration
+	small := NewFileParamFromBytesWithFilename(make([]byte, 100_000), "small.mp3", "audio/mpeg")
+	if _, err := client.CreateTranscription(small, "whisper-1", TranscriptionOptions{}.SetResponseFormat(TranscriptionResponseFormatVerboseJSON)); err != nil {
+		t.Errorf("failed to create transcription: %s", err)
+	} else if !almostEqual(budget.Spent(), 0.25) {
+		t.Errorf("spent should be reconciled with the actual duration: %f", budget.Spent())
+	}
+
+	// responses without durations keep the estimated cost (25 seconds)
+	for _, format := range []TranscriptionResponseFormat{TranscriptionResponseFormatText, TranscriptionResponseFormatJSON} {
+		budget.Reset()
+		sent := atomic.LoadInt32(&requests)
+
+		// (text responses are not decoded as transcriptions, but they are sent and settled)
+		_, _ = client.CreateTranscription(small, "whisper-1", TranscriptionOptions{}.SetResponseFormat(format))
+		if atomic.LoadInt32(&requests) != sent+1 {
+			t.Errorf("[%s] request should be sent", format)
+		} else if !almostEqual(budget.Spent(), 25.0/60*0.5) {
+			t.Errorf("[%s] spent should be the estimated cost: %f", format, budget.Spent())
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package openai
+
+// types and functions for caching responses
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Cache interface for storing responses of deterministic requests
+//
+// Implementations should be safe for concurrent use.
+type Cache interface {
+	// Get returns the value for given key, and whether it was found (and not expired).
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+
+	// Set stores given value for given key. A `ttl` of 0 means no expiration.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+}
+
+// SetCache makes the client cache responses of `CreateChatCompletion`, `CreateResponse`, `CreateEmbedding`,
+// and `CreateModeration` (not streamed) in given cache for `ttl` (0 for no expiration).
+//
+// Requests are keyed by their endpoints and params, so it is useful only for deterministic requests
+// (eg. chat completions with temperature 0 and a fixed seed, or embeddings).
+// Keys also include the credentials, base URLs, and beta header the requests would be sent with,
+// so responses are not shared between different API keys, organizations, projects, or endpoints.
+// Only successful responses are cached.
+func (c *Client) SetCache(cache Cache, ttl time.Duration) *Client {
+	c.cache = cache
+	c.cacheTTL = ttl
+
+	return c
+}
+
+// WithCache makes the client cache responses in given cache for `ttl`.
+func WithCache(cache Cache, ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.SetCache(cache, ttl)
+	}
+}
+
+type cacheBypassContextKey struct{}
+
+// ContextWithCacheBypass returns a copy of `ctx` which makes requests with it bypass the client's cache
+// (the cache is neither read nor written).
+func ContextWithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassContextKey{}, true)
+}
+
+// isCacheBypassed checks if the cache is bypassed in `ctx`.
+func isCacheBypassed(ctx context.Context) bool {
+	bypassed, _ := ctx.Value(cacheBypassContextKey{}).(bool)
+	return bypassed
+}
+
+// cacheKey returns the cache key for given identity, endpoint, and params.
+func cacheKey(identity []string, endpoint string, params map[string]any) (string, error) {
+	serialized, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.New()
+	for _, s := range identity {
+		hash.Write([]byte(s))
+		hash.Write([]byte{0})
+	}
+	hash.Write([]byte(endpoint))
+	hash.Write([]byte{0})
+	hash.Write(serialized)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// cacheIdentity returns the credentials, base URLs, and beta header which requests with `ctx` would be sent with.
+//
+// The credential and base URL of a credential pool are selected when requests are sent,
+// so all of the pool's are included.
+func (c *Client) cacheIdentity(ctx context.Context) (identity []string) {
+	options := requestOptionsFromContext(ctx)
+
+	credentials := []Credential{{
+		APIKey:         c.APIKey,
+		OrganizationID: c.OrganizationID,
+		ProjectID:      c.ProjectID,
+	}}
+	var baseURLs []string
+	if c.credentials != nil {
+		c.credentials.mu.Lock()
+		if len(c.credentials.credentials) > 0 {
+			credentials = append([]Credential{}, c.credentials.credentials...)
+		}
+		baseURLs = append(baseURLs, c.credentials.baseURLs...)
+		c.credentials.mu.Unlock()
+	}
+	for _, credential := range credentials {
+		credential = options.credential(credential)
+		identity = append(identity, credential.APIKey, credential.OrganizationID, credential.ProjectID)
+	}
+
+	if c.azure != nil {
+		identity = append(identity, c.azure.Endpoint, c.azure.APIVersion)
+	} else {
+		url := baseURL
+		if c.baseURL != nil {
+			url = *c.baseURL
+		}
+		identity = append(identity, url)
+		identity = append(identity, baseURLs...)
+	}
+
+	if options.beta != nil {
+		identity = append(identity, *options.beta)
+	} else if c.beta != nil {
+		identity = append(identity, *c.beta)
+	}
+
+	return identity
+}
+
+// sends HTTP POST request with context, or returns its cached response if there is one
+func (c *Client) cachedPostWithContext(ctx context.Context, endpoint string, params map[string]any) (response []byte, err error) {
+	if c.cache == nil || isCacheBypassed(ctx) {
+		return c.postWithContext(ctx, endpoint, params)
+	}
+
+	var key string
+	if key, err = cacheKey(c.cacheIdentity(ctx), endpoint, requestOptionsFromContext(ctx).params(params)); err != nil {
+		return c.postWithContext(ctx, endpoint, params)
+	}
+
+	started := time.Now()
+	if cached, found, err := c.cache.Get(ctx, key); err != nil {
+		c.logCacheError(ctx, endpoint, err)
+	} else if found {
+		if meta := responseMetaFromContext(ctx); meta != nil {
+			*meta = newCachedResponseMeta(started)
+		}
+		c.logCacheHit(ctx, endpoint, params)
+
+		return cached, nil
+	}
+
+	if response, err = c.postWithContext(ctx, endpoint, params); err == nil {
+		if err := c.cache.Set(ctx, key, response, c.cacheTTL); err != nil {
+			c.logCacheError(ctx, endpoint, err)
+		}
+	}
+
+	return response, err
+}
+
+// expiration returns the expiration time for given ttl (zero for no expiration).
+func expiration(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// isExpired checks if given expiration time has passed.
+func isExpired(expiresAt time.Time) bool {
+	return !expiresAt.IsZero() && !time.Now().Before(expiresAt)
+}
+
+// LRUCache is an in-memory Cache which evicts the least recently used entries.
+type LRUCache struct {
+	capacity int
+
+	mu      sync.Mutex
+	entries *list.List
+	keys    map[string]*list.Element
+}
+
+// lruEntry struct for an entry of LRUCache
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRUCache returns a new LRUCache which holds up to `capacity` entries.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		entries:  list.New(),
+		keys:     map[string]*list.Element{},
+	}
+}
+
+// Get returns the value for given key.
+func (c *LRUCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, exists := c.keys[key]
+	if !exists {
+		return nil, false, nil
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if isExpired(entry.expiresAt) {
+		c.entries.Remove(elem)
+		delete(c.keys, key)
+		return nil, false, nil
+	}
+
+	c.entries.MoveToFront(elem)
+	return entry.value, true, nil
+}
+
+// Set stores given value for given key, evicting the least recently used entry if it is full.
+func (c *LRUCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, exists := c.keys[key]; exists {
+		entry := elem.Value.(*lruEntry)
+		entry.value, entry.expiresAt = value, expiration(ttl)
+		c.entries.MoveToFront(elem)
+		return nil
+	}
+
+	c.keys[key] = c.entries.PushFront(&lruEntry{
+		key:       key,
+		value:     value,
+		expiresAt: expiration(ttl),
+	})
+	for c.capacity > 0 && c.entries.Len() > c.capacity {
+		oldest := c.entries.Back()
+		c.entries.Remove(oldest)
+		delete(c.keys, oldest.Value.(*lruEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of entries (including expired ones which are not evicted yet).
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.entries.Len()
+}
+
+// DiskCache is a Cache which stores entries as files in a directory.
+type DiskCache struct {
+	dir string
+}
+
+// diskEntry struct for an entry of DiskCache
+type diskEntry struct {
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Value     []byte     `json:"value"`
+}
+
+// NewDiskCache returns a new DiskCache which stores entries in given directory (created if it does not exist).
+func NewDiskCache(dir string) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return &DiskCache{dir: dir}, nil
+}
+
+// path returns the file path for given key.
+func (c *DiskCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// Get returns the value for given key.
+func (c *DiskCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	bytes, err := os.ReadFile(c.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var entry diskEntry
+	if err := json.Unmarshal(bytes, &entry); err != nil {
+		return nil, false, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	if entry.ExpiresAt != nil && isExpired(*entry.ExpiresAt) {
+		_ = os.Remove(c.path(key))
+		return nil, false, nil
+	}
+
+	return entry.Value, true, nil
+}
+
+// Set stores given value for given key.
+func (c *DiskCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := diskEntry{Value: value}
+	if expiresAt := expiration(ttl); !expiresAt.IsZero() {
+		entry.ExpiresAt = &expiresAt
+	}
+
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	// write to a temporary file and rename it, so that readers never see partial entries
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(bytes); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// Clear removes all entries.
+func (c *DiskCache) Clear() error {
+	files, err := filepath.Glob(filepath.Join(c.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCacheMock(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}]}`))
+		case "/v1/embeddings":
+			w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"bad request","type":"invalid_request_error"}}`))
+		}
+	}))
+	defer server.Close()
+
+	cache := NewLRUCache(2)
+	client := NewClientWithOptions("test-key", WithBaseURL(server.URL), WithCache(cache, time.Hour))
+
+	var meta ResponseMeta
+	ctx := ContextWithResponseMeta(context.Background(), &meta)
+	messages := []ChatMessage{NewChatUserMessage("Hello")}
+
+	// miss, then hit
+	for i, hit := range []bool{false, true} {
+		completion, err := client.CreateChatCompletionWithContext(ctx, "gpt-4o", messages, ChatCompletionOptions{}.SetTemperature(0))
+		if err != nil {
+			t.Fatalf("failed to create chat completion: %s", err)
+		}
+		if content, _ := completion.Choices[0].Message.ContentString(); content != "Hello!" {
+			t.Errorf("unexpected completion: %+v", completion)
+		}
+		if meta.CacheHit != hit {
+			t.Errorf("[%d] expected cache hit = %t: %+v", i, hit, meta)
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("expected 1 request, got %d", n)
+	}
+
+	// different params
+	if _, err := client.CreateChatCompletionWithContext(ctx, "gpt-4o", messages, ChatCompletionOptions{}.SetTemperature(1)); err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	} else if meta.CacheHit {
+		t.Errorf("different params should not hit the cache")
+	}
+
+	// bypass
+	if _, err := client.CreateChatCompletionWithContext(ContextWithCacheBypass(ctx), "gpt-4o", messages, ChatCompletionOptions{}.SetTemperature(0)); err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	} else if meta.CacheHit {
+		t.Errorf("bypassed request should not hit the cache")
+	}
+	if n := atomic.LoadInt32(&requests); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+
+	// eviction (capacity: 2)
+	if _, err := client.CreateEmbeddingWithContext(ctx, "text-embedding-3-small", "hello", nil); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("expected 2 entries, got %d", cache.Len())
+	}
+	if _, err := client.CreateChatCompletionWithContext(ctx, "gpt-4o", messages, ChatCompletionOptions{}.SetTemperature(0)); err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	} else if meta.CacheHit {
+		t.Errorf("least recently used entry should be evicted")
+	}
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		if _, err := client.CreateModerationWithContext(ctx, "hello", nil); err == nil {
+			t.Errorf("should fail with API error")
+		}
+	}
+	if n := atomic.LoadInt32(&requests); n != 7 {
+		t.Errorf("expected 7 requests, got %d", n)
+	}
+
+	// different API keys do not share entries
+	client.SetCache(NewLRUCache(10), time.Hour)
+	for i, tenant := range []string{"tenantA", "tenantB", "tenantA"} {
+		if _, err := client.CreateEmbeddingWithContext(ctx, "text-embedding-3-small", "hello", nil, WithRequestAPIKey(tenant)); err != nil {
+			t.Fatalf("failed to create embedding: %s", err)
+		} else if meta.CacheHit != (i == 2) {
+			t.Errorf("[%d] unexpected cache hit with API key '%s': %+v", i, tenant, meta)
+		}
+	}
+
+	// different beta headers and base URLs do not share entries
+	if _, err := client.CreateEmbeddingWithContext(ctx, "text-embedding-3-small", "hello", nil, WithRequestAPIKey("tenantA"), WithRequestBetaHeader("assistants=v2")); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	} else if meta.CacheHit {
+		t.Errorf("different beta header should not hit the cache")
+	}
+	other := httptest.NewServer(server.Config.Handler)
+	defer other.Close()
+	client.SetBaseURL(other.URL)
+	if _, err := client.CreateEmbeddingWithContext(ctx, "text-embedding-3-small", "hello", nil, WithRequestAPIKey("tenantA")); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	} else if meta.CacheHit {
+		t.Errorf("different base URL should not hit the cache")
+	}
+}
+
+func TestLRUCacheExpiration(t *testing.T) {
+	ctx := context.Background()
+	cache := NewLRUCache(0)
+
+	_ = cache.Set(ctx, "short", []byte("1"), time.Millisecond)
+	_ = cache.Set(ctx, "forever", []byte("2"), 0)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found, _ := cache.Get(ctx, "short"); found {
+		t.Errorf("expired entry should not be found")
+	}
+	if value, found, _ := cache.Get(ctx, "forever"); !found || string(value) != "2" {
+		t.Errorf("entry without ttl should be found: %s", value)
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	cache, err := NewDiskCache(dir)
+	if err != nil {
+		t.Fatalf("failed to create disk cache: %s", err)
+	}
+	if err := cache.Set(ctx, "key", []byte(`{"hello":"world"}`), time.Hour); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+	if err := cache.Set(ctx, "short", []byte("1"), time.Millisecond); err != nil {
+		t.Fatalf("failed to set: %s", err)
+	}
+
+	// entries persist across instances
+	cache, _ = NewDiskCache(dir)
+	if value, found, err := cache.Get(ctx, "key"); err != nil || !found || string(value) != `{"hello":"world"}` {
+		t.Errorf("unexpected value: %s, %t, %v", value, found, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	if _, found, _ := cache.Get(ctx, "short"); found {
+		t.Errorf("expired entry should not be found")
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("failed to clear: %s", err)
+	}
+	if _, found, _ := cache.Get(ctx, "key"); found {
+		t.Errorf("cleared entry should not be found")
+	}
+}
@@ -0,0 +1,445 @@
+package openai
+
+// types and functions for recording and replaying HTTP interactions
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// CassetteMode type for the mode of a Cassette
+type CassetteMode int
+
+// CassetteMode constants
+const (
+	// replays recorded interactions only, and fails for requests which were not recorded
+	CassetteModeReplay CassetteMode = iota
+
+	// sends all requests, and records them (previously recorded interactions are discarded)
+	CassetteModeRecord
+
+	// replays recorded interactions, and sends & records requests which were not recorded
+	CassetteModeReplayOrRecord
+)
+
+// ErrCassetteInteractionNotFound is returned in replay mode when there is no recorded interaction for a request.
+var ErrCassetteInteractionNotFound = errors.New("no recorded interaction for the request")
+
+// headers which are scrubbed from recorded interactions
+var cassetteScrubbedHeaders = []string{
+	kAuthorization,
+	kAzureAPIKey,
+	kOrganization,
+	kProject,
+	"Cookie",
+	"Set-Cookie",
+}
+
+// CassetteRequest struct for a recorded request
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+
+	// normalized body (canonical JSON, or a summary of multipart form)
+	Body string `json:"body,omitempty"`
+}
+
+// CassetteResponse struct for a recorded response
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body"`
+
+	// if true, `Body` is base64-encoded (for binary bodies)
+	BodyBase64 bool `json:"body_base64,omitempty"`
+}
+
+// CassetteInteraction struct for a recorded pair of request and response
+type CassetteInteraction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+// Cassette is a `http.RoundTripper` which records HTTP interactions to a file and replays them,
+// for testing without network access.
+//
+// Requests are matched with their method, path, query, and normalized body.
+// Secrets (eg. API keys) in headers are scrubbed before saved.
+// Errors of saving the file are returned from `Err` (and from closing the bodies of recorded responses).
+//
+//	cassette, _ := NewCassette("testdata/chat.json", CassetteModeReplayOrRecord)
+//	client := NewClientWithOptions(apiKey, WithTransport(cassette))
+type Cassette struct {
+	// transport for sending requests in record mode (`http.DefaultTransport` if nil)
+	Transport http.RoundTripper
+
+	// if set, it is called with each interaction before it is saved (eg. for scrubbing more secrets)
+	Scrub func(interaction *CassetteInteraction)
+
+	path string
+	mode CassetteMode
+
+	mu           sync.Mutex
+	interactions []CassetteInteraction
+	replayed     []bool
+	err          error
+}
+
+// NewCassette returns a new Cassette with given file path and mode.
+//
+// Recorded interactions are loaded from the file unless the mode is `CassetteModeRecord`.
+func NewCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{
+		path: path,
+		mode: mode,
+	}
+
+	if mode != CassetteModeRecord {
+		bytes, err := os.ReadFile(path)
+		if err != nil {
+			if !(mode == CassetteModeReplayOrRecord && errors.Is(err, os.ErrNotExist)) {
+				return nil, fmt.Errorf("failed to read cassette: %w", err)
+			}
+		} else if err := json.Unmarshal(bytes, &c.interactions); err != nil {
+			return nil, fmt.Errorf("failed to decode cassette: %w", err)
+		}
+	}
+	c.replayed = make([]bool, len(c.interactions))
+
+	return c, nil
+}
+
+// Interactions returns a copy of the recorded interactions.
+func (c *Cassette) Interactions() []CassetteInteraction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]CassetteInteraction{}, c.interactions...)
+}
+
+// Err returns the error of the last save of recorded interactions to the file, or nil if it succeeded.
+//
+// Callers should check it after recording, as recorded interactions are saved while their responses are read.
+func (c *Cassette) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// RoundTrip replays or records given request.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	recorded := newCassetteRequest(req, body)
+
+	if c.mode != CassetteModeRecord {
+		if interaction, found := c.find(recorded); found {
+			return interaction.Response.toHTTPResponse(req)
+		}
+		if c.mode == CassetteModeReplay {
+			return nil, fmt.Errorf("%w: %s %s", ErrCassetteInteractionNotFound, req.Method, req.URL.Path)
+		}
+	}
+
+	return c.record(req, body, recorded)
+}
+
+// find returns a recorded interaction which matches given request.
+//
+// Interactions which were not replayed yet are preferred, so that identical requests are replayed in the recorded order.
+func (c *Cassette) find(req CassetteRequest) (CassetteInteraction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	last := -1
+	for i, interaction := range c.interactions {
+		if !interaction.Request.matches(req) {
+			continue
+		}
+		if !c.replayed[i] {
+			c.replayed[i] = true
+			return interaction, true
+		}
+		last = i
+	}
+	if last >= 0 {
+		return c.interactions[last], true
+	}
+	return CassetteInteraction{}, false
+}
+
+// record sends given request and records the interaction when its response body is read to the end.
+func (c *Cassette) record(req *http.Request, body []byte, recorded CassetteRequest) (*http.Response, error) {
+	transport := c.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// record while the body (eg. server-sent events) is being streamed
+	resp.Body = &recordingBody{
+		ReadCloser: resp.Body,
+		done: func(bs []byte) error {
+			return c.append(CassetteInteraction{
+				Request:  recorded,
+				Response: newCassetteResponse(resp, bs),
+			})
+		},
+	}
+
+	return resp, nil
+}
+
+// append appends given interaction and saves all interactions to the file.
+//
+// The error of saving is also kept for `Err`.
+func (c *Cassette) append(interaction CassetteInteraction) error {
+	scrubHeaders(interaction.Request.Header)
+	scrubHeaders(interaction.Response.Header)
+	if c.Scrub != nil {
+		c.Scrub(&interaction)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.interactions = append(c.interactions, interaction)
+	c.replayed = append(c.replayed, true)
+
+	if c.err = c.save(); c.err != nil {
+		c.err = fmt.Errorf("failed to save cassette: %w", c.err)
+	}
+	return c.err
+}
+
+// save saves all interactions to the file.
+func (c *Cassette) save() error {
+	bytes, err := json.MarshalIndent(c.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, bytes, 0o644)
+}
+
+// newCassetteRequest returns a new CassetteRequest for given request and its body.
+func newCassetteRequest(req *http.Request, body []byte) CassetteRequest {
+	query := req.URL.Query()
+	return CassetteRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  query.Encode(), // sorted by keys
+		Header: req.Header.Clone(),
+		Body:   normalizeBody(req.Header.Get(kContentType), body),
+	}
+}
+
+// matches checks if `r` matches given request.
+func (r CassetteRequest) matches(req CassetteRequest) bool {
+	return r.Method == req.Method &&
+		r.Path == req.Path &&
+		r.Query == req.Query &&
+		r.Body == req.Body
+}
+
+// newCassetteResponse returns a new CassetteResponse for given response and its body.
+func newCassetteResponse(resp *http.Response, body []byte) CassetteResponse {
+	header := resp.Header.Clone()
+	header.Del("Content-Length")
+
+	res := CassetteResponse{
+		StatusCode: resp.StatusCode,
+		Header:     header,
+	}
+	if utf8.Valid(body) {
+		res.Body = string(body)
+	} else {
+		res.Body = base64.StdEncoding.EncodeToString(body)
+		res.BodyBase64 = true
+	}
+	return res
+}
+
+// toHTTPResponse converts `r` to a `http.Response` for given request.
+func (r CassetteResponse) toHTTPResponse(req *http.Request) (*http.Response, error) {
+	body := []byte(r.Body)
+	if r.BodyBase64 {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(r.Body); err != nil {
+			return nil, fmt.Errorf("failed to decode recorded response body: %w", err)
+		}
+	}
+
+	header := r.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// normalizeBody returns a normalized string of given request body for matching.
+//
+// JSON bodies are re-encoded with sorted keys, and files in multipart forms are replaced with their hashes.
+func normalizeBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		var v any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&v); err == nil {
+			if normalized, err := json.Marshal(v); err == nil {
+				return string(normalized)
+			}
+		}
+	case mediaType == "multipart/form-data":
+		if normalized, err := normalizeMultipart(body, params["boundary"]); err == nil {
+			return normalized
+		}
+	}
+
+	if utf8.Valid(body) {
+		return string(body)
+	}
+	hash := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(hash[:])
+}
+
+// normalizeMultipart returns a normalized string of given multipart form, which is independent of its boundary.
+func normalizeMultipart(body []byte, boundary string) (string, error) {
+	reader := multipart.NewReader(bytes.NewReader(body), boundary)
+
+	parts := []string{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		bs, err := io.ReadAll(part)
+		if err != nil {
+			return "", err
+		}
+
+		if part.FileName() != "" {
+			hash := sha256.Sum256(bs)
+			parts = append(parts, fmt.Sprintf("%s=<file %s (%s) sha256:%s>", part.FormName(), part.FileName(), part.Header.Get(kContentType), hex.EncodeToString(hash[:])))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s=%s", part.FormName(), string(bs)))
+		}
+	}
+	sort.Strings(parts)
+
+	return strings.Join(parts, "\n"), nil
+}
+
+// scrubHeaders replaces secrets in given headers.
+func scrubHeaders(header http.Header) {
+	for _, k := range cassetteScrubbedHeaders {
+		if header.Get(k) != "" {
+			header.Set(k, redacted)
+		}
+	}
+}
+
+// recordingBody struct for capturing a response body while it is read
+type recordingBody struct {
+	io.ReadCloser
+
+	mu   sync.Mutex
+	buf  bytes.Buffer
+	once sync.Once
+	done func(body []byte) error
+	err  error
+}
+
+// Read reads from the body, and records it when the end is reached.
+func (b *recordingBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+
+	b.mu.Lock()
+	b.buf.Write(p[:n])
+	b.mu.Unlock()
+
+	if err == io.EOF {
+		_ = b.record() // returned from `Close`
+	}
+	return n, err
+}
+
+// Close closes the body, and records what was read so far if it was not recorded yet.
+//
+// The rest is not read, as it may never end (eg. abandoned streams).
+// It returns the error of recording, if any.
+func (b *recordingBody) Close() error {
+	err := b.record()
+
+	if closeErr := b.ReadCloser.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// record records the body read so far (only once), and returns the error of it.
+func (b *recordingBody) record() error {
+	b.once.Do(func() {
+		b.mu.Lock()
+		body := bytes.Clone(b.buf.Bytes())
+		b.mu.Unlock()
+
+		b.err = b.done(body)
+	})
+	return b.err
+}
@@ -0,0 +1,220 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCassetteMock(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		switch r.URL.Path {
+		case "/v1/audio/transcriptions":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"text":"hello"}`))
+		case "/v1/chat/completions":
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), `"stream":true`) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Header().Set("Set-Cookie", "session=secret")
+				w.Write([]byte("data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hel\"}}]}\n\n"))
+				w.(http.Flusher).Flush()
+				w.Write([]byte("data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"lo\"},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n"))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"chatcmpl-test","choices":[{"index":0,"message":{"role":"assistant","content":"Hello"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	path := filepath.Join(t.TempDir(), "cassettes", "test.json")
+
+	// run the same requests for recording and replaying
+	run := func(client *Client) {
+		if completion, err := client.CreateChatCompletion("test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil); err != nil {
+			t.Errorf("failed to create chat completion: %s", err)
+		} else if content, _ := completion.Choices[0].Message.ContentString(); content != "Hello" {
+			t.Errorf("unexpected chat completion: %+v", completion)
+		}
+
+		done := make(chan struct{})
+		var streamed strings.Builder
+		if err := client.CreateChatCompletionStreamWithContext(context.Background(), "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil, func(c ChatCompletion, d bool, err error) {
+			if err != nil {
+				t.Errorf("failed to stream chat completion: %s", err)
+			}
+			if len(c.Choices) > 0 {
+				content, _ := c.Choices[0].Delta.ContentString()
+				streamed.WriteString(content)
+			}
+			if d {
+				close(done)
+			}
+		}); err != nil {
+			t.Errorf("failed to create chat completion stream: %s", err)
+		} else {
+			<-done
+			if streamed.String() != "Hello" {
+				t.Errorf("unexpected streamed content: %s", streamed.String())
+			}
+		}
+
+		file, err := NewFileParamFromFilepath("./sample/test.mp3")
+		if err != nil {
+			t.Fatalf("failed to open sample file: %s", err)
+		}
+		if transcription, err := client.CreateTranscription(file, "whisper-1", nil); err != nil {
+			t.Errorf("failed to create transcription: %s", err)
+		} else if transcription.Text == nil || *transcription.Text != "hello" {
+			t.Errorf("unexpected transcription: %+v", transcription)
+		}
+	}
+
+	// record
+	recorder, err := NewCassette(path, CassetteModeRecord)
+	if err != nil {
+		t.Fatalf("failed to create cassette: %s", err)
+	}
+	client := NewClientWithOptions("sk-secret-key", WithOrganization("org-secret"), WithTransport(recorder))
+	client.SetBaseURL(server.URL)
+	run(client)
+
+	if len(recorder.Interactions()) != 3 {
+		t.Errorf("unexpected number of recorded interactions: %d", len(recorder.Interactions()))
+	}
+	if bs, err := os.ReadFile(path); err != nil {
+		t.Fatalf("failed to read cassette file: %s", err)
+	} else if s := string(bs); strings.Contains(s, "sk-secret-key") || strings.Contains(s, "org-secret") || strings.Contains(s, "session=secret") {
+		t.Errorf("secrets should be scrubbed from the cassette: %s", s)
+	}
+
+	// replay without network access
+	server.Close()
+	recorded := atomic.LoadInt32(&calls)
+
+	player, err := NewCassette(path, CassetteModeReplay)
+	if err != nil {
+		t.Fatalf("failed to load cassette: %s", err)
+	}
+	client = NewClientWithOptions("another-key", WithTransport(player))
+	client.SetBaseURL(server.URL)
+	run(client)
+
+	if atomic.LoadInt32(&calls) != recorded {
+		t.Errorf("requests should not be sent while replaying")
+	}
+
+	// requests which were not recorded
+	if _, err := client.CreateChatCompletion("other-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil); !errors.Is(err, ErrCassetteInteractionNotFound) {
+		t.Errorf("should fail with ErrCassetteInteractionNotFound, got: %v", err)
+	}
+}
+
+func TestNormalizeBody(t *testing.T) {
+	if normalizeBody("application/json", []byte(`{"b": 1, "a": {"d": 2.50, "c": true}}`)) != normalizeBody("application/json; charset=utf-8", []byte(`{"a":{"c":true,"d":2.50},"b":1}`)) {
+		t.Errorf("JSON bodies with different key orders should be normalized to the same one")
+	}
+
+	form := func(boundary string) []byte {
+		return []byte("--" + boundary + "\r\n" +
+			"Content-Disposition: form-data; name=\"model\"\r\n\r\n" +
+			"whisper-1\r\n" +
+			"--" + boundary + "\r\n" +
+			"Content-Disposition: form-data; name=\"file\"; filename=\"test.mp3\"\r\n" +
+			"Content-Type: audio/mpeg\r\n\r\n" +
+			"\xff\xfb\x90\x00\r\n" +
+			"--" + boundary + "--\r\n")
+	}
+	if normalizeBody("multipart/form-data; boundary=aaaa", form("aaaa")) != normalizeBody("multipart/form-data; boundary=bbbb", form("bbbb")) {
+		t.Errorf("multipart bodies with different boundaries should be normalized to the same one")
+	}
+}
+
+func TestRecordingBodyClose(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	recorded := make(chan []byte, 1)
+	body := &recordingBody{
+		ReadCloser: pr,
+		done: func(bs []byte) error {
+			recorded <- bs
+			return nil
+		},
+	}
+
+	go pw.Write([]byte("data: {}\n\n"))
+	buf := make([]byte, 64)
+	if n, err := body.Read(buf); err != nil || string(buf[:n]) != "data: {}\n\n" {
+		t.Fatalf("unexpected read: %q, %v", buf[:n], err)
+	}
+
+	// closing a body which never ends (eg. an abandoned stream) should not block
+	closed := make(chan struct{})
+	go func() {
+		body.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		t.Fatalf("closing a body which never ends should not block")
+	}
+
+	if bs := <-recorded; string(bs) != "data: {}\n\n" {
+		t.Errorf("body read so far should be recorded: %q", bs)
+	}
+}
+
+func TestCassetteSaveErrorMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer server.Close()
+
+	// a path under a regular file, which cannot be written
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatalf("failed to create file: %s", err)
+	}
+	recorder, err := NewCassette(filepath.Join(file, "cassette.json"), CassetteModeRecord)
+	if err != nil {
+		t.Fatalf("failed to create cassette: %s", err)
+	}
+	client := NewClientWithOptions("test-key", WithTransport(recorder))
+	client.SetBaseURL(server.URL)
+
+	if _, err := client.ListModels(); err != nil {
+		t.Fatalf("failed to list models: %s", err)
+	}
+	if err := recorder.Err(); err == nil {
+		t.Errorf("expected an error of saving the cassette")
+	}
+
+	// also returned from closing the body
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v1/models", nil)
+	if err != nil {
+		t.Fatalf("failed to create request: %s", err)
+	}
+	resp, err := recorder.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("failed to send request: %s", err)
+	}
+	if err := resp.Body.Close(); err == nil {
+		t.Errorf("expected an error of saving the cassette from closing the body")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 )
 
@@ -80,23 +81,42 @@ func NewChatMessageContentWithImageURL(url string) ChatMessageContent {
 }
 
 // converts given bytes array to base64-encoded data URL
-func bytesToDataURL(bytes []byte) string {
-	return fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(bytes), base64.StdEncoding.EncodeToString(bytes))
+//
+// If `contentType` is empty, it is detected from the bytes.
+func bytesToDataURL(bytes []byte, contentType string) string {
+	if contentType == "" {
+		contentType = detectContentType("", bytes)
+	}
+	return fmt.Sprintf("data:%s;base64,%s", contentType, base64.StdEncoding.EncodeToString(bytes))
 }
 
 // NewChatMessageContentWithBytes returns a ChatMessageContent struct with given `bytes`.
 func NewChatMessageContentWithBytes(bytes []byte) ChatMessageContent {
+	return newChatMessageContentWithBytes(bytes, "")
+}
+
+// newChatMessageContentWithBytes returns a ChatMessageContent struct with given `bytes` and `contentType`.
+func newChatMessageContentWithBytes(bytes []byte, contentType string) ChatMessageContent {
 	return ChatMessageContent{
 		Type: "image_url",
 		ImageURL: map[string]string{
-			"url": bytesToDataURL(bytes),
+			"url": bytesToDataURL(bytes, contentType),
 		},
 	}
 }
 
 // NewChatMessageContentWithFileParam returns a ChatMessageContent struct with given `file`.
+//
+// The whole file is read into memory, and an empty content is returned if it fails.
 func NewChatMessageContentWithFileParam(file FileParam) ChatMessageContent {
-	return NewChatMessageContentWithBytes(file.bs)
+	bs, err := file.readAll()
+	if err != nil {
+		return ChatMessageContent{Type: "image_url"}
+	}
+	if file.contentType == "" && file.filename != "" {
+		return newChatMessageContentWithBytes(bs, detectContentType(file.filename, bs))
+	}
+	return newChatMessageContentWithBytes(bs, file.contentType)
 }
 
 // ChatMessage struct for chat completion
@@ -105,6 +125,7 @@ func NewChatMessageContentWithFileParam(file FileParam) ChatMessageContent {
 type ChatMessage struct {
 	Role    ChatMessageRole `json:"role"`
 	Content any             `json:"content,omitempty"` // NOTE: string | []ChatMessageContent
+	Refusal *string         `json:"refusal,omitempty"` // when role == 'assistant', and the model refused to answer
 
 	// for function call
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`   // when role == 'assistant'
@@ -155,6 +176,7 @@ type ChatCompletionToolFunction struct {
 	Name        string                 `json:"name"`
 	Description *string                `json:"description,omitempty"`
 	Parameters  ToolFunctionParameters `json:"parameters"`
+	Strict      *bool                  `json:"strict,omitempty"`
 }
 
 // NewChatCompletionTool returns a ChatCompletionTool.
@@ -171,6 +193,14 @@ func NewChatCompletionTool(name, description string, parameters ToolFunctionPara
 	return tool
 }
 
+// SetStrict sets whether the model should follow the schema of the parameters exactly (strict function calling).
+//
+// Parameters for strict mode can be generated with `NewToolFunctionParametersFromType` or `NewToolFunctionParametersFromFunc`.
+func (t ChatCompletionTool) SetStrict(strict bool) ChatCompletionTool {
+	t.Function.Strict = &strict
+	return t
+}
+
 // NewToolFunctionParameters returns an empty ToolFunctionParameters.
 func NewToolFunctionParameters() ToolFunctionParameters {
 	return ToolFunctionParameters{
@@ -292,13 +322,25 @@ type ChatCompletion struct {
 
 	ID      string                 `json:"id"`
 	Created int64                  `json:"created"`
+	Model   string                 `json:"model,omitempty"`
 	Choices []ChatCompletionChoice `json:"choices"`
 	Usage   Usage                  `json:"usage"`
 }
 
 // ChatCompletionResponseFormat struct for chat completion request
 type ChatCompletionResponseFormat struct {
-	Type ChatCompletionResponseFormatType `json:"type,omitempty"`
+	Type       ChatCompletionResponseFormatType        `json:"type,omitempty"`
+	JSONSchema *ChatCompletionResponseFormatJSONSchema `json:"json_schema,omitempty"` // when type == 'json_schema'
+}
+
+// ChatCompletionResponseFormatJSONSchema struct for the JSON schema of `json_schema` response format
+//
+// https://platform.openai.com/docs/guides/structured-outputs
+type ChatCompletionResponseFormatJSONSchema struct {
+	Name        string     `json:"name"`
+	Description *string    `json:"description,omitempty"`
+	Schema      JSONSchema `json:"schema"`
+	Strict      *bool      `json:"strict,omitempty"`
 }
 
 // ChatCompletionResponseFormatType type for constants
@@ -308,6 +350,7 @@ type ChatCompletionResponseFormatType string
 const (
 	ChatCompletionResponseFormatTypeText       ChatCompletionResponseFormatType = "text"
 	ChatCompletionResponseFormatTypeJSONObject ChatCompletionResponseFormatType = "json_object"
+	ChatCompletionResponseFormatTypeJSONSchema ChatCompletionResponseFormatType = "json_schema"
 )
 
 // ChatCompletionOptions for creating chat completions
@@ -379,6 +422,9 @@ func (o ChatCompletionOptions) SetStop(stop any) ChatCompletionOptions {
 
 // SetStream sets the `stream` parameter of chat completions.
 //
+// `cb` is called with each chunk, and then with `done` and the whole chat completion rebuilt from the chunks
+// (the same as the response of a non-streaming request).
+//
 // https://developer.mozilla.org/en-US/docs/Web/API/Server-sent_events/Using_server-sent_events#event_stream_format
 //
 // https://platform.openai.com/docs/api-reference/chat/create#chat/create-stream
@@ -443,7 +489,16 @@ func (o ChatCompletionOptions) SetUser(user string) ChatCompletionOptions {
 // CreateChatCompletion creates a completion for chat messages.
 //
 // https://platform.openai.com/docs/api-reference/chat/create
-func (c *Client) CreateChatCompletion(model string, messages []ChatMessage, options ChatCompletionOptions) (response ChatCompletion, err error) {
+func (c *Client) CreateChatCompletion(model string, messages []ChatMessage, options ChatCompletionOptions, opts ...RequestOption) (response ChatCompletion, err error) {
+	return c.CreateChatCompletionWithContext(context.Background(), model, messages, options, opts...)
+}
+
+// CreateChatCompletionWithContext creates a completion for the chat message with context support.
+//
+// https://platform.openai.com/docs/api-reference/chat/create
+func (c *Client) CreateChatCompletionWithContext(ctx context.Context, model string, messages []ChatMessage, options ChatCompletionOptions, opts ...RequestOption) (response ChatCompletion, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ChatCompletionOptions{}
 	}
@@ -453,13 +508,13 @@ func (c *Client) CreateChatCompletion(model string, messages []ChatMessage, opti
 	if options["stream"] != nil {
 		cb := options["stream"].(callback)
 		options["stream"] = true
-		_, err := c.postCB("v1/chat/completions", options, cb)
+		_, err := c.postCBWithContext(ctx, "v1/chat/completions", options, cb)
 
 		return ChatCompletion{}, err
 	}
 
 	var bytes []byte
-	if bytes, err = c.post("v1/chat/completions", options); err == nil {
+	if bytes, err = c.cachedPostWithContext(ctx, "v1/chat/completions", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -467,57 +522,46 @@ func (c *Client) CreateChatCompletion(model string, messages []ChatMessage, opti
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return ChatCompletion{}, err
 }
 
-// CreateChatCompletionWithContext creates a completion for the chat message with context support.
+// CreateChatCompletionStreamWithContext creates a completion for the chat message with context and streaming support.
 //
 // https://platform.openai.com/docs/api-reference/chat/create
-func (c *Client) CreateChatCompletionWithContext(ctx context.Context, model string, messages []ChatMessage, options ChatCompletionOptions) (response ChatCompletion, err error) {
+func (c *Client) CreateChatCompletionStreamWithContext(ctx context.Context, model string, messages []ChatMessage, options ChatCompletionOptions, cb callback, opts ...RequestOption) (err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ChatCompletionOptions{}
 	}
 	options["model"] = model
 	options["messages"] = messages
+	options["stream"] = true
 
-	if options["stream"] != nil {
-		cb := options["stream"].(callback)
-		options["stream"] = true
-		_, err := c.postCBWithContext(ctx, "v1/chat/completions", options, cb)
-
-		return ChatCompletion{}, err
-	}
-
-	var bytes []byte
-	if bytes, err = c.postWithContext(ctx, "v1/chat/completions", options); err == nil {
-		if err = json.Unmarshal(bytes, &response); err == nil {
-			if response.Error == nil {
-				return response, nil
-			}
-
-			err = response.Error.err()
-		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
-	}
+	_, err = c.postCBWithContext(ctx, "v1/chat/completions", options, cb)
+	return err
+}
 
-	return ChatCompletion{}, err
+// ChatCompletionStream struct for reading the chunks of a streaming chat completion one by one
+//
+// It should be closed after use (or have its context canceled) to release the connection.
+type ChatCompletionStream struct {
+	reader *streamReader[ChatCompletion]
+	acc    *ChatCompletionAccumulator
+	err    error
 }
 
-// CreateChatCompletionStreamWithContext creates a completion for the chat message with context and streaming support.
+// StreamChatCompletion creates a completion for the chat message with streaming support,
+// and returns a stream for reading its chunks with `Recv`.
+//
+// It returns after the response headers arrive. Canceling `ctx` aborts the stream.
 //
 // https://platform.openai.com/docs/api-reference/chat/create
-func (c *Client) CreateChatCompletionStreamWithContext(ctx context.Context, model string, messages []ChatMessage, options ChatCompletionOptions, cb callback) (err error) {
+func (c *Client) StreamChatCompletion(ctx context.Context, model string, messages []ChatMessage, options ChatCompletionOptions, opts ...RequestOption) (stream *ChatCompletionStream, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ChatCompletionOptions{}
 	}
@@ -525,6 +569,48 @@ func (c *Client) CreateChatCompletionStreamWithContext(ctx context.Context, mode
 	options["messages"] = messages
 	options["stream"] = true
 
-	_, err = c.postCBWithContext(ctx, "v1/chat/completions", options, cb)
-	return err
+	var reader *streamReader[ChatCompletion]
+	if reader, err = openStream(ctx, c, http.MethodPost, "v1/chat/completions", options, newChatCompletionHandler); err != nil {
+		return nil, err
+	}
+
+	return &ChatCompletionStream{reader: reader, acc: NewChatCompletionAccumulator()}, nil
+}
+
+// Recv returns the next chunk of the stream.
+//
+// It returns `io.EOF` after the last chunk, and keeps returning the same error after any error.
+// Tool calls are returned aggregated, in the chunk with `finish_reason` "tool_calls".
+func (s *ChatCompletionStream) Recv() (chunk ChatCompletion, err error) {
+	if s.err != nil {
+		return ChatCompletion{}, s.err
+	}
+
+	var done bool
+	if chunk, done, err = s.reader.recv(); !done {
+		s.acc.Add(chunk)
+		return chunk, nil
+	}
+
+	if err == nil {
+		err = io.EOF
+	}
+	s.err = err
+
+	return ChatCompletion{}, err
+}
+
+// ChatCompletion returns the chat completion rebuilt from the chunks received so far,
+// which is the same as the response of a non-streaming request after `Recv` returned `io.EOF`.
+func (s *ChatCompletionStream) ChatCompletion() ChatCompletion {
+	return s.acc.ChatCompletion()
+}
+
+// Close aborts the stream if it is not finished yet, and releases its connection.
+//
+// It can be called from another goroutine for aborting `Recv`, and more than once.
+func (s *ChatCompletionStream) Close() error {
+	s.reader.close()
+
+	return nil
 }
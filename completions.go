@@ -3,8 +3,9 @@ package openai
 // https://platform.openai.com/docs/api-reference/completions
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
+	"net/http"
 )
 
 // CompletionOptions for creating completions
@@ -60,7 +61,7 @@ func (o CompletionOptions) SetN(n int) CompletionOptions {
 
 // SetStream sets the `stream` parameter of completion request.
 //
-// NOTE: (not implemented) https://developer.mozilla.org/en-US/docs/Web/API/Server-sent_events/Using_server-sent_events#event_stream_format
+// NOTE: use `CreateCompletionStream` for receiving streamed completions
 //
 // https://platform.openai.com/docs/api-reference/completions/create#completions/create-stream
 func (o CompletionOptions) SetStream(stream bool) CompletionOptions {
@@ -154,14 +155,23 @@ type Completion struct {
 // CreateCompletion creates a completion.
 //
 // https://platform.openai.com/docs/api-reference/completions/create
-func (c *Client) CreateCompletion(model string, options CompletionOptions) (response Completion, err error) {
+func (c *Client) CreateCompletion(model string, options CompletionOptions, opts ...RequestOption) (response Completion, err error) {
+	return c.CreateCompletionWithContext(context.Background(), model, options, opts...)
+}
+
+// CreateCompletionWithContext creates a completion with context support.
+//
+// https://platform.openai.com/docs/api-reference/completions/create
+func (c *Client) CreateCompletionWithContext(ctx context.Context, model string, options CompletionOptions, opts ...RequestOption) (response Completion, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = CompletionOptions{}
 	}
 	options["model"] = model
 
 	var bytes []byte
-	if bytes, err = c.post("v1/completions", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/completions", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -169,12 +179,32 @@ func (c *Client) CreateCompletion(model string, options CompletionOptions) (resp
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Completion{}, err
 }
+
+// CompletionCallback type for streamed completions
+type CompletionCallback func(response Completion, done bool, err error)
+
+// CreateCompletionStream creates a completion, and streams it to given callback.
+//
+// https://platform.openai.com/docs/api-reference/completions/create#completions/create-stream
+func (c *Client) CreateCompletionStream(model string, options CompletionOptions, cb CompletionCallback, opts ...RequestOption) (err error) {
+	return c.CreateCompletionStreamWithContext(context.Background(), model, options, cb, opts...)
+}
+
+// CreateCompletionStreamWithContext creates a completion, and streams it to given callback with context support.
+//
+// https://platform.openai.com/docs/api-reference/completions/create#completions/create-stream
+func (c *Client) CreateCompletionStreamWithContext(ctx context.Context, model string, options CompletionOptions, cb CompletionCallback, opts ...RequestOption) (err error) {
+	ctx = withRequestOptions(ctx, opts)
+
+	if options == nil {
+		options = CompletionOptions{}
+	}
+	options["model"] = model
+	options["stream"] = true
+
+	return startStream(ctx, c, http.MethodPost, "v1/completions", options, newCompletionHandler, cb)
+}
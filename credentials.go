@@ -0,0 +1,331 @@
+package openai
+
+// types and functions for rotating credentials and failing over between base URLs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// default cooldown of credentials and base URLs
+	DefaultCredentialCooldown = 60 * time.Second
+)
+
+// Credential struct for an API key with its organization and project
+type Credential struct {
+	APIKey         string
+	OrganizationID string
+	ProjectID      string
+
+	// name of the credential, reported in ResponseMeta (masked API key if empty)
+	Name string
+}
+
+// name returns the name of the credential.
+func (c Credential) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	if len(c.APIKey) <= 8 {
+		return redacted
+	}
+	return c.APIKey[:3] + "..." + c.APIKey[len(c.APIKey)-4:]
+}
+
+// CredentialSelection type for strategies of selecting credentials
+type CredentialSelection int
+
+// CredentialSelection constants
+const (
+	// selects credentials in turn
+	CredentialSelectionRoundRobin CredentialSelection = iota
+
+	// selects the credential which was rate-limited least recently
+	CredentialSelectionLeastRecentlyLimited
+)
+
+// CredentialPool struct for spreading requests across credentials and base URLs
+//
+// Credentials which get 401 or 429 responses are put on cooldown,
+// and base URLs which get connection errors or 5xx responses are put on cooldown.
+// Failed requests are sent again immediately with another credential or base URL (if any),
+// but only idempotent requests or requests with idempotency keys are sent to another base URL.
+//
+// Base URLs are tried in the given order (eg. a primary endpoint first, then a backup gateway).
+// They are ignored for Azure OpenAI.
+type CredentialPool struct {
+	credentials []Credential
+	baseURLs    []string
+	selection   CredentialSelection
+	cooldown    time.Duration
+
+	mu              sync.Mutex
+	next            int
+	credentialState []credentialState
+	baseURLCooldown []time.Time
+}
+
+// credentialState struct for the state of a credential in a pool
+type credentialState struct {
+	cooldownUntil time.Time
+	lastLimited   time.Time
+	lastUsed      time.Time
+}
+
+// NewCredentialPool returns a new CredentialPool with given credentials.
+func NewCredentialPool(credentials ...Credential) *CredentialPool {
+	return &CredentialPool{
+		credentials:     credentials,
+		cooldown:        DefaultCredentialCooldown,
+		credentialState: make([]credentialState, len(credentials)),
+	}
+}
+
+// SetBaseURLs sets the base URLs to fail over between, in order of priority.
+//
+// If not set, the client's base URL is used.
+func (p *CredentialPool) SetBaseURLs(baseURLs ...string) *CredentialPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.baseURLs = baseURLs
+	p.baseURLCooldown = make([]time.Time, len(baseURLs))
+
+	return p
+}
+
+// SetSelection sets the strategy of selecting credentials.
+func (p *CredentialPool) SetSelection(selection CredentialSelection) *CredentialPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.selection = selection
+
+	return p
+}
+
+// SetCooldown sets the cooldown of failed credentials and base URLs.
+//
+// Credentials which get 429 responses are cooled down for longer if the response has a longer `Retry-After` header.
+func (p *CredentialPool) SetCooldown(cooldown time.Duration) *CredentialPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cooldown = cooldown
+
+	return p
+}
+
+// SetCredentialPool makes the client send requests with credentials and base URLs from given pool.
+//
+// The client's API key, organization, project, and base URL are not used while a pool is set.
+func (c *Client) SetCredentialPool(pool *CredentialPool) *Client {
+	c.credentials = pool
+
+	return c
+}
+
+// WithCredentialPool makes the client send requests with credentials and base URLs from given pool.
+func WithCredentialPool(pool *CredentialPool) ClientOption {
+	return func(c *Client) {
+		c.SetCredentialPool(pool)
+	}
+}
+
+// poolSelection struct for the credential and base URL selected for a request
+type poolSelection struct {
+	credential int // index of the credential (-1 if there is none)
+	baseURL    int // index of the base URL (-1 if there is none)
+
+	// name of the credential and the base URL, for ResponseMeta
+	credentialName string
+	baseURLString  string
+
+	triedCredentials map[int]bool
+	triedBaseURLs    map[int]bool
+}
+
+// failoverKind type for the kinds of failures which can be failed over
+type failoverKind int
+
+const (
+	failoverNone failoverKind = iota
+	failoverCredential
+	failoverBaseURL
+)
+
+// selectFor selects a credential and base URL for the next attempt of a request.
+//
+// Credentials and base URLs which were not tried for the request and not cooling down are preferred.
+func (p *CredentialPool) selectFor(selection *poolSelection) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	if selection.triedCredentials == nil {
+		selection.triedCredentials = map[int]bool{}
+		selection.triedBaseURLs = map[int]bool{}
+	}
+
+	selection.credential = -1
+	if len(p.credentials) > 0 {
+		selection.credential = p.selectCredential(now, selection.triedCredentials)
+		selection.triedCredentials[selection.credential] = true
+		selection.credentialName = p.credentials[selection.credential].name()
+		p.credentialState[selection.credential].lastUsed = now
+	}
+
+	selection.baseURL = -1
+	if len(p.baseURLs) > 0 {
+		selection.baseURL = selectAvailable(len(p.baseURLs), now, selection.triedBaseURLs, func(i int) time.Time {
+			return p.baseURLCooldown[i]
+		}, func(candidates []int) int {
+			return candidates[0] // in order of priority
+		})
+		selection.triedBaseURLs[selection.baseURL] = true
+		selection.baseURLString = p.baseURLs[selection.baseURL]
+	}
+}
+
+// selectCredential selects a credential with the pool's strategy (`p.mu` should be locked).
+func (p *CredentialPool) selectCredential(now time.Time, tried map[int]bool) int {
+	return selectAvailable(len(p.credentials), now, tried, func(i int) time.Time {
+		return p.credentialState[i].cooldownUntil
+	}, func(candidates []int) int {
+		switch p.selection {
+		case CredentialSelectionLeastRecentlyLimited:
+			selected := candidates[0]
+			for _, i := range candidates[1:] {
+				s, c := p.credentialState[selected], p.credentialState[i]
+				if c.lastLimited.Before(s.lastLimited) ||
+					(c.lastLimited.Equal(s.lastLimited) && c.lastUsed.Before(s.lastUsed)) {
+					selected = i
+				}
+			}
+			return selected
+		default:
+			// the first candidate from the next index
+			for offset := 0; offset < len(p.credentials); offset++ {
+				i := (p.next + offset) % len(p.credentials)
+				for _, candidate := range candidates {
+					if candidate == i {
+						p.next = (i + 1) % len(p.credentials)
+						return i
+					}
+				}
+			}
+			return candidates[0]
+		}
+	})
+}
+
+// selectAvailable selects one of `n` items with `choose`, preferring the ones which were not tried and not cooling down.
+//
+// If all items are cooling down, the one whose cooldown ends first is selected.
+func selectAvailable(n int, now time.Time, tried map[int]bool, cooldownUntil func(int) time.Time, choose func(candidates []int) int) int {
+	untried, available := []int{}, []int{}
+	soonest := 0
+	for i := 0; i < n; i++ {
+		if cooldownUntil(i).Before(cooldownUntil(soonest)) {
+			soonest = i
+		}
+		if now.Before(cooldownUntil(i)) {
+			continue
+		}
+		available = append(available, i)
+		if !tried[i] {
+			untried = append(untried, i)
+		}
+	}
+
+	switch {
+	case len(untried) > 0:
+		return choose(untried)
+	case len(available) > 0:
+		return choose(available)
+	default:
+		return soonest
+	}
+}
+
+// report puts the selected credential or base URL on cooldown if given response or error is a failure of them,
+// and returns the kind of the failure.
+func (p *CredentialPool) report(ctx context.Context, selection *poolSelection, resp *http.Response, err error) failoverKind {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	switch {
+	case err != nil:
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return failoverNone
+		}
+		if selection.baseURL >= 0 {
+			p.baseURLCooldown[selection.baseURL] = now.Add(p.cooldown)
+		}
+		return failoverBaseURL
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusTooManyRequests:
+		if selection.credential >= 0 {
+			cooldown := p.cooldown
+			if delay := delayFromHeaders(resp); delay > cooldown {
+				cooldown = delay
+			}
+			state := &p.credentialState[selection.credential]
+			state.cooldownUntil = now.Add(cooldown)
+			state.lastLimited = now
+		}
+		return failoverCredential
+	case resp.StatusCode >= http.StatusInternalServerError:
+		if selection.baseURL >= 0 {
+			p.baseURLCooldown[selection.baseURL] = now.Add(p.cooldown)
+		}
+		return failoverBaseURL
+	}
+	return failoverNone
+}
+
+// canFailover checks if there is a credential or base URL which was not tried for the request, for given kind of failure.
+func (p *CredentialPool) canFailover(selection *poolSelection, kind failoverKind) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+
+	switch kind {
+	case failoverCredential:
+		for i := range p.credentials {
+			if !selection.triedCredentials[i] && !now.Before(p.credentialState[i].cooldownUntil) {
+				return true
+			}
+		}
+	case failoverBaseURL:
+		for i := range p.baseURLs {
+			if !selection.triedBaseURLs[i] && !now.Before(p.baseURLCooldown[i]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// credential returns the selected credential, or nil if there is none.
+func (p *CredentialPool) credential(selection *poolSelection) *Credential {
+	if p == nil || selection.triedCredentials == nil || selection.credential < 0 {
+		return nil
+	}
+	return &p.credentials[selection.credential]
+}
+
+// baseURL returns the selected base URL, or an empty string if there is none.
+func (p *CredentialPool) baseURL(selection *poolSelection) string {
+	if p == nil || selection.triedBaseURLs == nil || selection.baseURL < 0 {
+		return ""
+	}
+	return selection.baseURLString
+}
@@ -0,0 +1,239 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCredentialPoolMock(t *testing.T) {
+	var mu sync.Mutex
+	keys := []string{}
+	limited := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		mu.Lock()
+		keys = append(keys, key+"/"+r.Header.Get("Openai-Organization"))
+		isLimited := limited[key]
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case key == "key-invalid":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"invalid api key","type":"invalid_request_error"}}`))
+		case isLimited:
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"rate limited","type":"rate_limit_exceeded"}}`))
+		default:
+			w.Write([]byte(`{"object":"list","data":[]}`))
+		}
+	}))
+	defer server.Close()
+
+	takeKeys := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		taken := keys
+		keys = []string{}
+		return taken
+	}
+
+	pool := NewCredentialPool(
+		Credential{APIKey: "key-1", OrganizationID: "org-1", Name: "first"},
+		Credential{APIKey: "key-2", OrganizationID: "org-2", Name: "second"},
+		Credential{APIKey: "sk-test-key-3", OrganizationID: "org-3"},
+	)
+	client := NewClientWithOptions("unused-key", WithBaseURL(server.URL), WithCredentialPool(pool))
+
+	// round-robin
+	for i := 0; i < 4; i++ {
+		if _, err := client.ListModels(); err != nil {
+			t.Fatalf("failed to list models: %s", err)
+		}
+	}
+	if got := strings.Join(takeKeys(), ","); got != "key-1/org-1,key-2/org-2,sk-test-key-3/org-3,key-1/org-1" {
+		t.Errorf("unexpected keys: %s", got)
+	}
+
+	// rate-limited key is put on cooldown, and the request fails over to another key
+	mu.Lock()
+	limited["key-2"] = true
+	mu.Unlock()
+
+	var meta ResponseMeta
+	ctx := ContextWithResponseMeta(context.Background(), &meta)
+	if _, err := client.ListModelsWithContext(ctx); err != nil {
+		t.Fatalf("should fail over to another key: %s", err)
+	}
+	if meta.Credential != "sk-...ey-3" || meta.Attempts != 2 || meta.BaseURL != "" {
+		t.Errorf("unexpected response meta: %+v", meta)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := client.ListModels(); err != nil {
+			t.Fatalf("failed to list models: %s", err)
+		}
+	}
+	if got := strings.Join(takeKeys(), ","); got != "key-2/org-2,sk-test-key-3/org-3,key-1/org-1,sk-test-key-3/org-3,key-1/org-1" {
+		t.Errorf("key on cooldown should be skipped: %s", got)
+	}
+
+	// all keys are failing
+	pool = NewCredentialPool(
+		Credential{APIKey: "key-invalid", Name: "invalid"},
+		Credential{APIKey: "key-2", Name: "limited"},
+	)
+	client.SetCredentialPool(pool)
+	if _, err := client.ListModelsWithContext(ctx); err == nil {
+		t.Errorf("should fail when all keys are failing")
+	} else if meta.Credential != "limited" || meta.StatusCode != http.StatusTooManyRequests || meta.Attempts != 2 {
+		t.Errorf("unexpected response meta: %+v", meta)
+	}
+	takeKeys()
+
+	// least recently limited
+	mu.Lock()
+	limited["key-2"] = false
+	limited["key-1"] = true
+	mu.Unlock()
+
+	pool = NewCredentialPool(
+		Credential{APIKey: "key-1"},
+		Credential{APIKey: "key-2"},
+		Credential{APIKey: "key-3"},
+	).SetSelection(CredentialSelectionLeastRecentlyLimited).SetCooldown(time.Millisecond)
+	client.SetCredentialPool(pool)
+	if _, err := client.ListModels(); err != nil {
+		t.Fatalf("failed to list models: %s", err)
+	}
+
+	mu.Lock()
+	limited["key-1"] = false
+	mu.Unlock()
+	time.Sleep(5 * time.Millisecond) // cooldown of key-1 ends
+
+	for i := 0; i < 4; i++ {
+		if _, err := client.ListModels(); err != nil {
+			t.Fatalf("failed to list models: %s", err)
+		}
+	}
+	if got := strings.Join(takeKeys(), ","); got != "key-1/,key-2/,key-3/,key-2/,key-3/,key-2/" {
+		t.Errorf("recently limited key should be avoided: %s", got)
+	}
+}
+
+func TestCredentialPoolBaseURLFailoverMock(t *testing.T) {
+	var mu sync.Mutex
+	failing := true
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`{"error":{"message":"bad gateway","type":"server_error"}}`))
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"id":"primary"}]}`))
+	}))
+	defer primary.Close()
+
+	backup := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"object":"list","data":[{"id":"backup"}]}`))
+	}))
+	defer backup.Close()
+
+	unreachable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	unreachable.Close()
+
+	pool := NewCredentialPool(Credential{APIKey: "key-1"}).
+		SetBaseURLs(primary.URL+"/v1", backup.URL+"/v1").
+		SetCooldown(50 * time.Millisecond)
+	client := NewClientWithOptions("unused-key", WithCredentialPool(pool))
+
+	// 5xx
+	var meta ResponseMeta
+	ctx := ContextWithResponseMeta(context.Background(), &meta)
+	if models, err := client.ListModelsWithContext(ctx); err != nil {
+		t.Fatalf("should fail over to the backup: %s", err)
+	} else if models.Data[0].ID != "backup" || meta.BaseURL != backup.URL+"/v1" || meta.Attempts != 2 {
+		t.Errorf("unexpected response: %+v, meta: %+v", models, meta)
+	}
+
+	// primary is cooling down
+	if models, err := client.ListModelsWithContext(ctx); err != nil || models.Data[0].ID != "backup" || meta.Attempts != 1 {
+		t.Errorf("primary on cooldown should be skipped: %+v, %v, meta: %+v", models, err, meta)
+	}
+
+	// primary is back after cooldown
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	time.Sleep(60 * time.Millisecond)
+	if models, err := client.ListModelsWithContext(ctx); err != nil || models.Data[0].ID != "primary" || meta.BaseURL != primary.URL+"/v1" {
+		t.Errorf("should use primary after cooldown: %+v, %v, meta: %+v", models, err, meta)
+	}
+
+	// connection errors
+	client.SetCredentialPool(NewCredentialPool(Credential{APIKey: "key-1"}).SetBaseURLs(unreachable.URL+"/v1", backup.URL+"/v1"))
+	if models, err := client.ListModelsWithContext(ctx); err != nil {
+		t.Fatalf("should fail over on connection errors: %s", err)
+	} else if models.Data[0].ID != "backup" || meta.Attempts != 2 {
+		t.Errorf("unexpected response: %+v, meta: %+v", models, meta)
+	}
+}
+
+func TestCredentialPoolFailoverNonIdempotentMock(t *testing.T) {
+	var mu sync.Mutex
+	hits := map[string]int{}
+	newServer := func(name string, status int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			hits[name]++
+			mu.Unlock()
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			if status != http.StatusOK {
+				w.Write([]byte(`{"error":{"message":"internal error","type":"server_error"}}`))
+				return
+			}
+			w.Write([]byte(`{"object":"list","data":[{"object":"embedding","embedding":[0.1],"index":0}]}`))
+		}))
+	}
+	primary := newServer("primary", http.StatusInternalServerError)
+	defer primary.Close()
+	backup := newServer("backup", http.StatusOK)
+	defer backup.Close()
+
+	newClient := func() *Client {
+		pool := NewCredentialPool(Credential{APIKey: "key-1"}).
+			SetBaseURLs(primary.URL+"/v1", backup.URL+"/v1")
+		return NewClientWithOptions("unused-key", WithCredentialPool(pool))
+	}
+
+	// POST without an idempotency key is not sent again
+	if _, err := newClient().CreateEmbedding("text-embedding-3-small", "hello", nil); err == nil {
+		t.Errorf("should fail without failing over")
+	}
+	if hits["primary"] != 1 || hits["backup"] != 0 {
+		t.Errorf("unexpected hits: %+v", hits)
+	}
+
+	// POST with an idempotency key fails over
+	ctx := ContextWithIdempotencyKey(context.Background(), "embedding-1")
+	if _, err := newClient().CreateEmbeddingWithContext(ctx, "text-embedding-3-small", "hello", nil); err != nil {
+		t.Errorf("should fail over to the backup: %s", err)
+	}
+	if hits["primary"] != 2 || hits["backup"] != 1 {
+		t.Errorf("unexpected hits: %+v", hits)
+	}
+}
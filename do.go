@@ -0,0 +1,108 @@
+package openai
+
+// types and functions for sending raw requests to any endpoint
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// Do sends a request to given `path` (eg. "v1/batches") with the client's configuration
+// (authentication, base URL, headers, retries, rate limits, middlewares, and logging),
+// for endpoints which are not supported by this library yet.
+//
+// `body` is sent as JSON (or multipart/form-data if it includes any FileParam),
+// or as query parameters for GET/HEAD/DELETE/OPTIONS requests. It should be nil, a map, or a struct which is encoded as a JSON object.
+//
+// The response body is decoded as JSON into `out`, unless it is nil, a `*[]byte`, or an `io.Writer`.
+// Errors in responses are returned as `*APIError`.
+func (c *Client) Do(ctx context.Context, method, path string, body any, out any, opts ...RequestOption) (err error) {
+	ctx = withRequestOptions(ctx, opts)
+
+	var params map[string]any
+	if params, err = paramsFromBody(body); err != nil {
+		return err
+	}
+
+	var bytes []byte
+	if bytes, err = c.doWithContext(ctx, method, strings.TrimPrefix(path, "/"), params); err != nil {
+		return err
+	}
+
+	return decodeResponse(bytes, out)
+}
+
+// DoStream sends a request to given `path` like `Do`, and calls `cb` with the server-sent events of the response.
+//
+// It returns after the response headers arrive, and `cb` is called in another goroutine.
+// A data of "[DONE]" or the end of the stream finishes it.
+func (c *Client) DoStream(ctx context.Context, method, path string, body any, cb ServerSentEventCallback, opts ...RequestOption) (err error) {
+	ctx = withRequestOptions(ctx, opts)
+
+	var params map[string]any
+	if params, err = paramsFromBody(body); err != nil {
+		return err
+	}
+
+	return startStream(ctx, c, method, strings.TrimPrefix(path, "/"), params, newServerSentEventHandler, cb)
+}
+
+// paramsFromBody converts given request body to params.
+func paramsFromBody(body any) (map[string]any, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case map[string]any:
+		return b, nil
+	}
+
+	// maps with string keys (eg. `ChatCompletionOptions`)
+	v := reflect.ValueOf(body)
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		params := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			params[iter.Key().String()] = iter.Value().Interface()
+		}
+		return params, nil
+	}
+
+	// others (eg. structs) are converted through JSON
+	serialized, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request body: %w", err)
+	}
+	var params map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(serialized))
+	decoder.UseNumber()
+	if err := decoder.Decode(&params); err != nil {
+		return nil, fmt.Errorf("request body should be encoded as a JSON object: %w", err)
+	}
+	return params, nil
+}
+
+// decodeResponse decodes given response bytes into `out`.
+func decodeResponse(bs []byte, out any) error {
+	var res CommonResponse
+	if err := json.Unmarshal(bs, &res); err == nil && res.Error != nil {
+		return res.Error.err()
+	}
+
+	switch o := out.(type) {
+	case nil:
+		return nil
+	case *[]byte:
+		*o = bs
+		return nil
+	case io.Writer:
+		_, err := o.Write(bs)
+		return err
+	default:
+		return json.Unmarshal(bs, out)
+	}
+}
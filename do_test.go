@@ -0,0 +1,143 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDoMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(kAuthorization) != "Bearer test-key" || r.Header.Get(kBeta) != "assistants=v2" {
+			t.Errorf("unexpected headers: %v", r.Header)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/batches":
+			if r.URL.Query().Get("limit") != "2" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"object":"list","data":[{"id":"batch_1"},{"id":"batch_2"}]}`))
+		case "POST /v1/batches":
+			var body map[string]any
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["input_file_id"] != "file-1" || body["completion_window"] != "24h" {
+				t.Errorf("unexpected body: %v (%v)", body, err)
+			}
+			w.Write([]byte(`{"id":"batch_3","object":"batch"}`))
+		case "PATCH /v1/batches/batch_3":
+			if bs, _ := io.ReadAll(r.Body); string(bs) != `{"metadata":{"key":"value"}}` {
+				t.Errorf("unexpected body: %s", string(bs))
+			}
+			w.Write([]byte(`{"id":"batch_3","object":"batch"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"Invalid URL","type":"invalid_request_error"}}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+	client.SetBetaHeader("assistants=v2")
+
+	type batch struct {
+		ID     string `json:"id"`
+		Object string `json:"object"`
+	}
+	ctx := context.Background()
+
+	// GET with struct params in query string
+	var list struct {
+		Data []batch `json:"data"`
+	}
+	if err := client.Do(ctx, http.MethodGet, "/v1/batches", struct {
+		Limit int `json:"limit"`
+	}{Limit: 2}, &list); err != nil {
+		t.Errorf("failed to list batches: %s", err)
+	} else if len(list.Data) != 2 {
+		t.Errorf("unexpected list: %+v", list)
+	}
+
+	// POST with map params
+	var created batch
+	if err := client.Do(ctx, http.MethodPost, "v1/batches", map[string]any{
+		"input_file_id":     "file-1",
+		"completion_window": "24h",
+	}, &created); err != nil {
+		t.Errorf("failed to create batch: %s", err)
+	} else if created.ID != "batch_3" {
+		t.Errorf("unexpected batch: %+v", created)
+	}
+
+	// PATCH with body, and raw bytes out
+	var raw []byte
+	if err := client.Do(ctx, http.MethodPatch, "v1/batches/batch_3", map[string]any{
+		"metadata": map[string]string{"key": "value"},
+	}, &raw); err != nil {
+		t.Errorf("failed to modify batch: %s", err)
+	} else if string(raw) != `{"id":"batch_3","object":"batch"}` {
+		t.Errorf("unexpected raw response: %s", string(raw))
+	}
+
+	// error
+	if err := client.Do(ctx, http.MethodGet, "v1/unknown", nil, nil); AsAPIError(err) == nil || AsAPIError(err).StatusCode != http.StatusNotFound {
+		t.Errorf("expected API error, got: %v", err)
+	}
+}
+
+func TestDoStreamMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("event: progress\ndata: {\"step\":1}\n\n: keep-alive\n\nevent: progress\ndata: {\"step\":2}\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+
+	var mu sync.Mutex
+	steps := []int{}
+	done := make(chan error, 1)
+	if err := client.DoStream(context.Background(), http.MethodPost, "v1/something", map[string]any{"stream": true}, func(event ServerSentEvent, d bool, err error) {
+		if d {
+			done <- err
+			return
+		}
+
+		var data struct {
+			Step int `json:"step"`
+		}
+		if event.Event != "progress" {
+			t.Errorf("unexpected event: %+v", event)
+		} else if err := event.Decode(&data); err != nil {
+			t.Errorf("failed to decode event data: %s", err)
+		}
+		mu.Lock()
+		steps = append(steps, data.Step)
+		mu.Unlock()
+	}); err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("stream failed: %s", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("stream did not finish in time")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(steps) != 2 || steps[0] != 1 || steps[1] != 2 {
+		t.Errorf("unexpected steps: %v", steps)
+	}
+}
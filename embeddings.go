@@ -3,8 +3,8 @@ package openai
 // https://platform.openai.com/docs/api-reference/embeddings
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 )
 
 // Embeddings struct for response
@@ -56,7 +56,16 @@ func (o EmbeddingOptions) SetUser(user string) EmbeddingOptions {
 // CreateEmbedding creates an embedding with given input.
 //
 // https://platform.openai.com/docs/api-reference/embeddings/create
-func (c *Client) CreateEmbedding(model string, input any, options EmbeddingOptions) (response Embeddings, err error) {
+func (c *Client) CreateEmbedding(model string, input any, options EmbeddingOptions, opts ...RequestOption) (response Embeddings, err error) {
+	return c.CreateEmbeddingWithContext(context.Background(), model, input, options, opts...)
+}
+
+// CreateEmbeddingWithContext creates an embedding with given input with context support.
+//
+// https://platform.openai.com/docs/api-reference/embeddings/create
+func (c *Client) CreateEmbeddingWithContext(ctx context.Context, model string, input any, options EmbeddingOptions, opts ...RequestOption) (response Embeddings, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = EmbeddingOptions{}
 	}
@@ -64,7 +73,7 @@ func (c *Client) CreateEmbedding(model string, input any, options EmbeddingOptio
 	options["input"] = input
 
 	var bytes []byte
-	if bytes, err = c.post("v1/embeddings", options); err == nil {
+	if bytes, err = c.cachedPostWithContext(ctx, "v1/embeddings", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -72,11 +81,6 @@ func (c *Client) CreateEmbedding(model string, input any, options EmbeddingOptio
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Embeddings{}, err
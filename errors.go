@@ -0,0 +1,170 @@
+package openai
+
+// types and functions for API errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	kRequestID = "X-Request-Id"
+)
+
+// error codes/types returned from the API
+const (
+	errorCodeRateLimitExceeded     = "rate_limit_exceeded"
+	errorCodeInsufficientQuota     = "insufficient_quota"
+	errorCodeContextLengthExceeded = "context_length_exceeded"
+	errorCodeInvalidAPIKey         = "invalid_api_key"
+)
+
+// APIError struct for errors returned from the API
+//
+// https://platform.openai.com/docs/guides/error-codes/api-errors
+type APIError struct {
+	StatusCode int    `json:"status_code"`
+	RequestID  string `json:"request_id,omitempty"`
+
+	Message string `json:"message"`
+	Type    string `json:"type,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Param   any    `json:"param,omitempty"`
+
+	// raw response body
+	Body []byte `json:"-"`
+}
+
+// Error returns the string representation of APIError.
+func (e *APIError) Error() string {
+	es := map[string]any{
+		"type":    e.Type,
+		"message": e.Message,
+	}
+	if e.Code != "" {
+		es["code"] = e.Code
+	}
+	if e.Param != nil {
+		es["param"] = e.Param
+	}
+
+	var detail string
+	if bytes, err := json.Marshal(es); err == nil {
+		detail = string(bytes)
+	} else {
+		detail = fmt.Sprintf("%+v", es)
+	}
+
+	if e.StatusCode == 0 {
+		return detail
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("http status %d (request id: %s): %s", e.StatusCode, e.RequestID, detail)
+	}
+	return fmt.Sprintf("http status %d: %s", e.StatusCode, detail)
+}
+
+// IsRateLimited returns if the error was caused by exceeding rate limits.
+func (e *APIError) IsRateLimited() bool {
+	if e.IsInsufficientQuota() {
+		return false
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.Code == errorCodeRateLimitExceeded
+}
+
+// IsInsufficientQuota returns if the error was caused by running out of quota.
+func (e *APIError) IsInsufficientQuota() bool {
+	return e.Code == errorCodeInsufficientQuota || e.Type == errorCodeInsufficientQuota
+}
+
+// IsContextLengthExceeded returns if the error was caused by exceeding the context length of the model.
+func (e *APIError) IsContextLengthExceeded() bool {
+	return e.Code == errorCodeContextLengthExceeded
+}
+
+// IsAuth returns if the error was caused by invalid authentication or insufficient permissions.
+func (e *APIError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized ||
+		e.StatusCode == http.StatusForbidden ||
+		e.Code == errorCodeInvalidAPIKey
+}
+
+// IsServerError returns if the error was caused by the server.
+func (e *APIError) IsServerError() bool {
+	return e.StatusCode >= 500
+}
+
+// AsAPIError returns the *APIError in given error's chain, or nil if there is none.
+func AsAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return nil
+}
+
+// IsRateLimited returns if given error is an *APIError caused by exceeding rate limits.
+func IsRateLimited(err error) bool {
+	apiErr := AsAPIError(err)
+	return apiErr != nil && apiErr.IsRateLimited()
+}
+
+// IsInsufficientQuota returns if given error is an *APIError caused by running out of quota.
+func IsInsufficientQuota(err error) bool {
+	apiErr := AsAPIError(err)
+	return apiErr != nil && apiErr.IsInsufficientQuota()
+}
+
+// IsContextLengthExceeded returns if given error is an *APIError caused by exceeding the context length.
+func IsContextLengthExceeded(err error) bool {
+	apiErr := AsAPIError(err)
+	return apiErr != nil && apiErr.IsContextLengthExceeded()
+}
+
+// IsAuth returns if given error is an *APIError caused by authentication or permission failures.
+func IsAuth(err error) bool {
+	apiErr := AsAPIError(err)
+	return apiErr != nil && apiErr.IsAuth()
+}
+
+// IsServerError returns if given error is an *APIError caused by the server.
+func IsServerError(err error) bool {
+	apiErr := AsAPIError(err)
+	return apiErr != nil && apiErr.IsServerError()
+}
+
+// newAPIError returns an *APIError built from given HTTP response and its body bytes.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(kRequestID),
+		Body:       body,
+	}
+
+	errbody := struct {
+		Error *Error `json:"error"`
+	}{}
+	if err := json.Unmarshal(body, &errbody); err == nil && errbody.Error != nil {
+		apiErr.fill(*errbody.Error)
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+		if apiErr.Message == "" {
+			apiErr.Message = http.StatusText(resp.StatusCode)
+		}
+	}
+
+	return apiErr
+}
+
+// fill copies the fields of given `Error` to APIError.
+func (e *APIError) fill(err Error) {
+	e.Message = err.Message
+	e.Type = err.Type
+	e.Param = err.Param
+	if err.Code != nil {
+		e.Code = *err.Code
+	}
+}
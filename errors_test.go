@@ -0,0 +1,102 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-request-id", "req_test")
+
+		switch r.URL.Path {
+		case "/v1/embeddings":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests","param":null,"code":"rate_limit_exceeded"}}`))
+		case "/v1/models":
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","param":null,"code":"invalid_api_key"}}`))
+		case "/v1/chat/completions":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"This model's maximum context length is 4097 tokens","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`))
+		default:
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`bad gateway`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+
+	// rate limited
+	_, err := client.CreateEmbedding("test-model", "test", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected *APIError, got: %v", err)
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests || apiErr.RequestID != "req_test" || apiErr.Code != "rate_limit_exceeded" {
+		t.Errorf("unexpected api error: %+v", apiErr)
+	}
+	if !IsRateLimited(fmt.Errorf("wrapped: %w", err)) || IsServerError(err) {
+		t.Errorf("wrong classification of error: %s", err)
+	}
+
+	// auth
+	_, err = client.ListModels()
+	if !IsAuth(err) {
+		t.Errorf("expected auth error, got: %v", err)
+	}
+
+	// context length (streaming)
+	err = client.CreateChatCompletionStreamWithContext(context.Background(), "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil, func(_ ChatCompletion, _ bool, _ error) {})
+	if !IsContextLengthExceeded(err) {
+		t.Errorf("expected context length error, got: %v", err)
+	} else if apiErr := AsAPIError(err); apiErr.Param != "messages" {
+		t.Errorf("expected param 'messages', got: %v", apiErr.Param)
+	}
+
+	// server error with non-JSON body
+	_, err = client.CreateResponse("test-model", "Hello!", nil)
+	if !IsServerError(err) {
+		t.Errorf("expected server error, got: %v", err)
+	} else if apiErr := AsAPIError(err); apiErr.Message != "bad gateway" || string(apiErr.Body) != "bad gateway" {
+		t.Errorf("unexpected api error: %+v", apiErr)
+	}
+}
+
+func TestAPIErrorInStreamMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("event: error\ndata: {\"type\":\"error\",\"code\":\"insufficient_quota\",\"message\":\"You exceeded your current quota\",\"param\":null}\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+
+	errs := make(chan error, 1)
+	if err := client.CreateResponseStream("test-model", "Hello!", nil, func(_ ResponseStreamEvent, done bool, err error) {
+		if done {
+			errs <- err
+		}
+	}); err != nil {
+		t.Fatalf("failed to create response stream: %s", err)
+	}
+
+	select {
+	case err := <-errs:
+		if !IsInsufficientQuota(err) || IsRateLimited(err) {
+			t.Errorf("expected insufficient quota error, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("stream did not finish in time")
+	}
+}
@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -42,9 +43,18 @@ type Files struct {
 // ListFiles returns a list of files that belong to the requested organization id.
 //
 // https://platform.openai.com/docs/api-reference/files/list
-func (c *Client) ListFiles() (response Files, err error) {
+func (c *Client) ListFiles(opts ...RequestOption) (response Files, err error) {
+	return c.ListFilesWithContext(context.Background(), opts...)
+}
+
+// ListFilesWithContext returns a list of files that belong to the requested organization id with context support.
+//
+// https://platform.openai.com/docs/api-reference/files/list
+func (c *Client) ListFilesWithContext(ctx context.Context, opts ...RequestOption) (response Files, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get("v1/files", nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, "v1/files", nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -52,11 +62,6 @@ func (c *Client) ListFiles() (response Files, err error) {
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Files{}, err
@@ -65,9 +70,18 @@ func (c *Client) ListFiles() (response Files, err error) {
 // UploadFile uploads given file.
 //
 // https://platform.openai.com/docs/api-reference/files/create
-func (c *Client) UploadFile(file FileParam, purpose string) (response UploadedFile, err error) {
+func (c *Client) UploadFile(file FileParam, purpose string, opts ...RequestOption) (response UploadedFile, err error) {
+	return c.UploadFileWithContext(context.Background(), file, purpose, opts...)
+}
+
+// UploadFileWithContext uploads given file with context support.
+//
+// https://platform.openai.com/docs/api-reference/files/create
+func (c *Client) UploadFileWithContext(ctx context.Context, file FileParam, purpose string, opts ...RequestOption) (response UploadedFile, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.post("v1/files", map[string]any{
+	if bytes, err = c.postWithContext(ctx, "v1/files", map[string]any{
 		"file":    file,
 		"purpose": purpose,
 	}); err == nil {
@@ -78,11 +92,6 @@ func (c *Client) UploadFile(file FileParam, purpose string) (response UploadedFi
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return UploadedFile{}, err
@@ -91,9 +100,18 @@ func (c *Client) UploadFile(file FileParam, purpose string) (response UploadedFi
 // DeleteFile deletes given file.
 //
 // https://platform.openai.com/docs/api-reference/files/delete
-func (c *Client) DeleteFile(fileID string) (response DeletedFile, err error) {
+func (c *Client) DeleteFile(fileID string, opts ...RequestOption) (response DeletedFile, err error) {
+	return c.DeleteFileWithContext(context.Background(), fileID, opts...)
+}
+
+// DeleteFileWithContext deletes given file with context support.
+//
+// https://platform.openai.com/docs/api-reference/files/delete
+func (c *Client) DeleteFileWithContext(ctx context.Context, fileID string, opts ...RequestOption) (response DeletedFile, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.delete(fmt.Sprintf("v1/files/%s", fileID), nil); err == nil {
+	if bytes, err = c.deleteWithContext(ctx, fmt.Sprintf("v1/files/%s", fileID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -101,11 +119,6 @@ func (c *Client) DeleteFile(fileID string) (response DeletedFile, err error) {
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return DeletedFile{}, err
@@ -114,9 +127,18 @@ func (c *Client) DeleteFile(fileID string) (response DeletedFile, err error) {
 // RetrieveFile returns the information of given file.
 //
 // https://platform.openai.com/docs/api-reference/files/retrieve
-func (c *Client) RetrieveFile(fileID string) (response RetrievedFile, err error) {
+func (c *Client) RetrieveFile(fileID string, opts ...RequestOption) (response RetrievedFile, err error) {
+	return c.RetrieveFileWithContext(context.Background(), fileID, opts...)
+}
+
+// RetrieveFileWithContext returns the information of given file with context support.
+//
+// https://platform.openai.com/docs/api-reference/files/retrieve
+func (c *Client) RetrieveFileWithContext(ctx context.Context, fileID string, opts ...RequestOption) (response RetrievedFile, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/files/%s", fileID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/files/%s", fileID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -124,11 +146,6 @@ func (c *Client) RetrieveFile(fileID string) (response RetrievedFile, err error)
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return RetrievedFile{}, err
@@ -137,15 +154,19 @@ func (c *Client) RetrieveFile(fileID string) (response RetrievedFile, err error)
 // RetrieveFileContent returns the content of given file.
 //
 // https://platform.openai.com/docs/api-reference/files/retrieve-content
-func (c *Client) RetrieveFileContent(fileID string) (response []byte, err error) {
+func (c *Client) RetrieveFileContent(fileID string, opts ...RequestOption) (response []byte, err error) {
+	return c.RetrieveFileContentWithContext(context.Background(), fileID, opts...)
+}
+
+// RetrieveFileContentWithContext returns the content of given file with context support.
+//
+// https://platform.openai.com/docs/api-reference/files/retrieve-content
+func (c *Client) RetrieveFileContentWithContext(ctx context.Context, fileID string, opts ...RequestOption) (response []byte, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/files/%s/content", fileID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/files/%s/content", fileID), nil); err == nil {
 		return bytes, nil
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return nil, err
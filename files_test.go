@@ -45,11 +45,11 @@ func TestFiles(t *testing.T) {
 			if bytes, err := client.RetrieveFileContent(fileID); err != nil {
 				t.Errorf("failed to retrieve content of file: %s", err)
 			} else {
-				if len(bytes) != len(file.bs) {
+				if int64(len(bytes)) != file.Size() {
 					// test
 					log.Printf("bytes = %s", string(bytes))
 
-					t.Errorf("retrieved file content's bytes count does not match the original one: %d - %d", len(bytes), len(file.bs))
+					t.Errorf("retrieved file content's bytes count does not match the original one: %d - %d", len(bytes), file.Size())
 				}
 			}
 
@@ -0,0 +1,205 @@
+package openai
+
+// types and functions for detecting types of files
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultFileContentType = "application/octet-stream"
+	defaultFileExtension   = "bin"
+)
+
+// file extensions for content types
+var extensionsForContentTypes = map[string]string{
+	// audio
+	"audio/mpeg": "mp3",
+	"audio/wav":  "wav",
+	"audio/ogg":  "ogg",
+	"audio/flac": "flac",
+	"audio/mp4":  "m4a",
+	"audio/webm": "webm",
+	"video/mp4":  "mp4",
+	"video/webm": "webm",
+
+	// image
+	"image/png":  "png",
+	"image/jpeg": "jpg",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+
+	// document
+	"application/pdf":   "pdf",
+	"application/jsonl": "jsonl",
+	"application/json":  "json",
+	"text/plain":        "txt",
+	"text/markdown":     "md",
+	"text/html":         "html",
+	"text/csv":          "csv",
+}
+
+// content types for file extensions which are not (or wrongly) registered in package `mime`
+var contentTypesForExtensions = map[string]string{
+	".mp3":   "audio/mpeg",
+	".mpga":  "audio/mpeg",
+	".mpeg":  "audio/mpeg",
+	".wav":   "audio/wav",
+	".ogg":   "audio/ogg",
+	".oga":   "audio/ogg",
+	".flac":  "audio/flac",
+	".m4a":   "audio/mp4",
+	".mp4":   "video/mp4",
+	".webm":  "audio/webm",
+	".png":   "image/png",
+	".jpg":   "image/jpeg",
+	".jpeg":  "image/jpeg",
+	".gif":   "image/gif",
+	".webp":  "image/webp",
+	".pdf":   "application/pdf",
+	".jsonl": "application/jsonl",
+	".json":  "application/json",
+	".txt":   "text/plain",
+	".md":    "text/markdown",
+	".csv":   "text/csv",
+}
+
+// sniffContentType detects the content type of given head bytes of a file with their magic numbers.
+//
+// It returns an empty string if the type could not be detected.
+func sniffContentType(head []byte) string {
+	switch {
+	// audio
+	case bytes.HasPrefix(head, []byte("ID3")):
+		return "audio/mpeg"
+	case len(head) >= 2 && head[0] == 0xFF && head[1]&0xE0 == 0xE0 && head[1]&0x06 != 0: // mpeg audio frame sync (layer I/II/III)
+		return "audio/mpeg"
+	case len(head) >= 12 && bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")):
+		return "audio/wav"
+	case bytes.HasPrefix(head, []byte("OggS")):
+		return "audio/ogg"
+	case bytes.HasPrefix(head, []byte("fLaC")):
+		return "audio/flac"
+	case len(head) >= 12 && bytes.Equal(head[4:8], []byte("ftyp")):
+		switch string(head[8:12]) {
+		case "M4A ", "M4B ", "M4P ":
+			return "audio/mp4"
+		default:
+			return "video/mp4"
+		}
+	case bytes.HasPrefix(head, []byte{0x1A, 0x45, 0xDF, 0xA3}): // EBML (webm, matroska)
+		return "audio/webm"
+
+	// image
+	case bytes.HasPrefix(head, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return "image/jpeg"
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return "image/gif"
+	case len(head) >= 12 && bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WEBP")):
+		return "image/webp"
+
+	// document
+	case bytes.HasPrefix(head, []byte("%PDF-")):
+		return "application/pdf"
+	}
+
+	// json / jsonl
+	trimmed := bytes.TrimLeft(head, " \t\r\n\xef\xbb\xbf")
+	if bytes.HasPrefix(trimmed, []byte("{")) || bytes.HasPrefix(trimmed, []byte("[")) {
+		if lines := bytes.Split(bytes.TrimSpace(trimmed), []byte("\n")); len(lines) > 1 &&
+			bytes.HasSuffix(bytes.TrimSpace(lines[0]), []byte("}")) &&
+			bytes.HasPrefix(bytes.TrimSpace(lines[1]), []byte("{")) {
+			return "application/jsonl"
+		} else if len(lines) == 1 && bytes.HasPrefix(trimmed, []byte("{")) && !json.Valid(lines[0]) {
+			// an object cut off in its first line (eg. a long record of a fine-tuning file)
+			return "application/jsonl"
+		}
+		return "application/json"
+	}
+
+	return ""
+}
+
+// contentTypeForFilename returns the content type for the extension of given filename,
+// or an empty string if it is unknown.
+func contentTypeForFilename(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		return ""
+	}
+	if contentType, exists := contentTypesForExtensions[ext]; exists {
+		return contentType
+	}
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		return mediaType
+	}
+	return ""
+}
+
+// detectContentType returns the content type of a file with given filename and head bytes.
+//
+// Magic numbers are checked first, then the extension of the filename, and then `http.DetectContentType`.
+func detectContentType(filename string, head []byte) string {
+	sniffed := sniffContentType(head)
+
+	// prefer the extension for formats which share magic numbers
+	if sniffed == "" || sniffed == "application/json" || strings.HasPrefix(sniffed, "video/") || strings.HasSuffix(sniffed, "/webm") {
+		if contentType := contentTypeForFilename(filename); contentType != "" {
+			return contentType
+		}
+	}
+	if sniffed != "" {
+		return sniffed
+	}
+
+	if len(head) > 0 {
+		mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+		if mediaType != "" {
+			return mediaType
+		}
+	}
+	return defaultFileContentType
+}
+
+// get file extension for given content type
+func getExtension(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if ext, exists := extensionsForContentTypes[mediaType]; exists {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return strings.TrimPrefix(exts[0], ".")
+	}
+	return defaultFileExtension
+}
+
+// generates mime header
+//
+// If `contentType` is empty, it is detected from `filename` and `bs`.
+func mimeHeaderForBytes(bs []byte, key, filename, contentType string) textproto.MIMEHeader {
+	if contentType == "" {
+		contentType = detectContentType(filename, bs)
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set(kContentDisposition, fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(key), escapeQuotes(filename)))
+	h.Set(kContentType, contentType)
+	return h
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes escapes quotes in given string for a quoted-string of MIME header.
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,42 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectContentType(t *testing.T) {
+	for _, tc := range []struct {
+		filename  string
+		head      []byte
+		expected  string
+		extension string
+	}{
+		{"", []byte("ID3\x04\x00\x00"), "audio/mpeg", "mp3"},
+		{"", []byte{0xFF, 0xFB, 0x90, 0x64}, "audio/mpeg", "mp3"},
+		{"", []byte("RIFF\x24\x08\x00\x00WAVEfmt "), "audio/wav", "wav"},
+		{"", []byte("OggS\x00\x02"), "audio/ogg", "ogg"},
+		{"", []byte("fLaC\x00\x00\x00\x22"), "audio/flac", "flac"},
+		{"", []byte("\x00\x00\x00\x20ftypM4A \x00\x00\x00\x00"), "audio/mp4", "m4a"},
+		{"", []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), "video/mp4", "mp4"},
+		{"recording.m4a", []byte("\x00\x00\x00\x20ftypisom\x00\x00\x02\x00"), "audio/mp4", "m4a"},
+		{"", []byte{0x1A, 0x45, 0xDF, 0xA3, 0x9F}, "audio/webm", "webm"},
+		{"", []byte("\x89PNG\r\n\x1a\n\x00\x00"), "image/png", "png"},
+		{"", []byte{0xFF, 0xD8, 0xFF, 0xE0}, "image/jpeg", "jpg"},
+		{"", []byte("GIF89a\x01\x00"), "image/gif", "gif"},
+		{"", []byte("RIFF\x24\x08\x00\x00WEBPVP8 "), "image/webp", "webp"},
+		{"", []byte("%PDF-1.7\n"), "application/pdf", "pdf"},
+		{"", []byte("{\"prompt\": \"a\"}\n{\"prompt\": \"b\"}\n"), "application/jsonl", "jsonl"},
+		{"", []byte("{\"prompt\": \"a\"}"), "application/json", "json"},
+		{"", []byte(`{"messages": [{"role": "user", "content": "` + strings.Repeat("a", sniffLength) + `"}]}`)[:sniffLength], "application/jsonl", "jsonl"},
+		{"data.jsonl", []byte("{\"prompt\": \"a\"}"), "application/jsonl", "jsonl"},
+		{"", []byte("puts 'hello'\n"), "text/plain", "txt"},
+		{"", []byte{0x00, 0x01, 0x02, 0x03}, "application/octet-stream", "bin"},
+	} {
+		if contentType := detectContentType(tc.filename, tc.head); contentType != tc.expected {
+			t.Errorf("expected content type '%s' for %q, got '%s'", tc.expected, tc.head, contentType)
+		} else if extension := getExtension(contentType); extension != tc.extension {
+			t.Errorf("expected extension '%s' for '%s', got '%s'", tc.extension, contentType, extension)
+		}
+	}
+}
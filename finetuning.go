@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -100,7 +101,16 @@ func (o FineTuningJobOptions) SetSuffix(suffix string) FineTuningJobOptions {
 // CreateFineTuningJob creates a job that fine-tunes a specified model from given data
 //
 // https://platform.openai.com/docs/api-reference/fine-tuning/create
-func (c *Client) CreateFineTuningJob(trainingFileID, model string, options FineTuningJobOptions) (response FineTuningJob, err error) {
+func (c *Client) CreateFineTuningJob(trainingFileID, model string, options FineTuningJobOptions, opts ...RequestOption) (response FineTuningJob, err error) {
+	return c.CreateFineTuningJobWithContext(context.Background(), trainingFileID, model, options, opts...)
+}
+
+// CreateFineTuningJobWithContext creates a job that fine-tunes a specified model from given data with context support.
+//
+// https://platform.openai.com/docs/api-reference/fine-tuning/create
+func (c *Client) CreateFineTuningJobWithContext(ctx context.Context, trainingFileID, model string, options FineTuningJobOptions, opts ...RequestOption) (response FineTuningJob, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = FineTuningJobOptions{}
 	}
@@ -108,7 +118,7 @@ func (c *Client) CreateFineTuningJob(trainingFileID, model string, options FineT
 	options["model"] = model
 
 	var bytes []byte
-	if bytes, err = c.post("v1/fine_tuning/jobs", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/fine_tuning/jobs", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -116,11 +126,6 @@ func (c *Client) CreateFineTuningJob(trainingFileID, model string, options FineT
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return FineTuningJob{}, err
@@ -129,13 +134,22 @@ func (c *Client) CreateFineTuningJob(trainingFileID, model string, options FineT
 // ListFineTuningJobs lists your organization's fine-tuning jobs.
 //
 // https://platform.openai.com/docs/api-reference/fine-tuning/list
-func (c *Client) ListFineTuningJobs(options FineTuningJobsOptions) (response FineTuningJobs, err error) {
+func (c *Client) ListFineTuningJobs(options FineTuningJobsOptions, opts ...RequestOption) (response FineTuningJobs, err error) {
+	return c.ListFineTuningJobsWithContext(context.Background(), options, opts...)
+}
+
+// ListFineTuningJobsWithContext lists your organization's fine-tuning jobs with context support.
+//
+// https://platform.openai.com/docs/api-reference/fine-tuning/list
+func (c *Client) ListFineTuningJobsWithContext(ctx context.Context, options FineTuningJobsOptions, opts ...RequestOption) (response FineTuningJobs, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = FineTuningJobsOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.get("v1/fine_tuning/jobs", options); err == nil {
+	if bytes, err = c.getWithContext(ctx, "v1/fine_tuning/jobs", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -143,11 +157,6 @@ func (c *Client) ListFineTuningJobs(options FineTuningJobsOptions) (response Fin
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return FineTuningJobs{}, err
@@ -156,9 +165,18 @@ func (c *Client) ListFineTuningJobs(options FineTuningJobsOptions) (response Fin
 // RetrieveFineTuningJob retrieves a fine-tuning job.
 //
 // https://platform.openai.com/docs/api-reference/fine-tuning/retrieve
-func (c *Client) RetrieveFineTuningJob(fineTuningJobID string) (response FineTuningJob, err error) {
+func (c *Client) RetrieveFineTuningJob(fineTuningJobID string, opts ...RequestOption) (response FineTuningJob, err error) {
+	return c.RetrieveFineTuningJobWithContext(context.Background(), fineTuningJobID, opts...)
+}
+
+// RetrieveFineTuningJobWithContext retrieves a fine-tuning job with context support.
+//
+// https://platform.openai.com/docs/api-reference/fine-tuning/retrieve
+func (c *Client) RetrieveFineTuningJobWithContext(ctx context.Context, fineTuningJobID string, opts ...RequestOption) (response FineTuningJob, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/fine_tuning/jobs/%s", fineTuningJobID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/fine_tuning/jobs/%s", fineTuningJobID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -166,11 +184,6 @@ func (c *Client) RetrieveFineTuningJob(fineTuningJobID string) (response FineTun
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return FineTuningJob{}, err
@@ -179,9 +192,18 @@ func (c *Client) RetrieveFineTuningJob(fineTuningJobID string) (response FineTun
 // CancelFineTuningJob cancels a fine-tuning job.
 //
 // https://platform.openai.com/docs/api-reference/fine-tuning/cancel
-func (c *Client) CancelFineTuningJob(fineTuningJobID string) (response FineTuningJob, err error) {
+func (c *Client) CancelFineTuningJob(fineTuningJobID string, opts ...RequestOption) (response FineTuningJob, err error) {
+	return c.CancelFineTuningJobWithContext(context.Background(), fineTuningJobID, opts...)
+}
+
+// CancelFineTuningJobWithContext cancels a fine-tuning job with context support.
+//
+// https://platform.openai.com/docs/api-reference/fine-tuning/cancel
+func (c *Client) CancelFineTuningJobWithContext(ctx context.Context, fineTuningJobID string, opts ...RequestOption) (response FineTuningJob, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/fine_tuning/jobs/%s/cancel", fineTuningJobID), nil); err == nil {
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/fine_tuning/jobs/%s/cancel", fineTuningJobID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -189,11 +211,6 @@ func (c *Client) CancelFineTuningJob(fineTuningJobID string) (response FineTunin
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return FineTuningJob{}, err
@@ -242,9 +259,18 @@ func (o FineTuningJobEventsOptions) SetLimit(limit int) FineTuningJobEventsOptio
 // ListFineTuningJobEvents lists status updates for a given fine-tuning job.
 //
 // https://platform.openai.com/docs/api-reference/fine-tuning/list-events
-func (c *Client) ListFineTuningJobEvents(fineTuningJobID string, options FineTuningJobEventsOptions) (response FineTuningJobEvents, err error) {
+func (c *Client) ListFineTuningJobEvents(fineTuningJobID string, options FineTuningJobEventsOptions, opts ...RequestOption) (response FineTuningJobEvents, err error) {
+	return c.ListFineTuningJobEventsWithContext(context.Background(), fineTuningJobID, options, opts...)
+}
+
+// ListFineTuningJobEventsWithContext lists status updates for a given fine-tuning job with context support.
+//
+// https://platform.openai.com/docs/api-reference/fine-tuning/list-events
+func (c *Client) ListFineTuningJobEventsWithContext(ctx context.Context, fineTuningJobID string, options FineTuningJobEventsOptions, opts ...RequestOption) (response FineTuningJobEvents, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/fine_tuning/jobs/%s/events", fineTuningJobID), options); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/fine_tuning/jobs/%s/events", fineTuningJobID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -252,11 +278,6 @@ func (c *Client) ListFineTuningJobEvents(fineTuningJobID string, options FineTun
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return FineTuningJobEvents{}, err
@@ -3,19 +3,13 @@ package openai
 // types and functions for HTTP requests
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
-	"mime/multipart"
 	"net/http"
-	"net/http/httputil"
-	"net/textproto"
-	"os"
-	"strings"
+	"time"
 )
 
 const (
@@ -27,6 +21,8 @@ const (
 	kAuthorization      = "Authorization"
 	kOrganization       = "OpenAI-Organization"
 	kBeta               = "OpenAI-Beta"
+	kProject            = "OpenAI-Project"
+	kUserAgent          = "User-Agent"
 )
 
 var (
@@ -59,497 +55,482 @@ type Usage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+
+	PromptTokensDetails     *UsageTokensDetails `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *UsageTokensDetails `json:"completion_tokens_details,omitempty"`
+}
+
+// UsageTokensDetails struct for the details of tokens in Usage
+type UsageTokensDetails struct {
+	CachedTokens    int `json:"cached_tokens,omitempty"`
+	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+	AudioTokens     int `json:"audio_tokens,omitempty"`
 }
 
 type callback func(response ChatCompletion, done bool, err error)
 
 // err converts `Error` to `error`.
 func (e *Error) err() error {
-	es := map[string]any{
-		"type":    e.Type,
-		"message": e.Message,
-	}
-	if e.Code != nil {
-		es["code"] = *e.Code
-	}
-	if e.Param != nil {
-		es["param"] = e.Param
-	}
+	apiErr := &APIError{}
+	apiErr.fill(*e)
 
-	if bytes, err := json.Marshal(es); err == nil {
-		return fmt.Errorf(string(bytes))
-	} else {
-		return fmt.Errorf(fmt.Sprintf("%+v", es))
-	}
+	return apiErr
 }
 
-func streamWithCtx(ctx context.Context, res *http.Response, cb callback) {
-	defer res.Body.Close()
-
-	fn := ToolCall{Type: "function"}
-
-	scanner := bufio.NewScanner(res.Body)
-	toolIndex := 0
-	toolCalls := []ToolCall{}
-	for scanner.Scan() {
-		// Check for context cancellation
-		select {
-		case <-ctx.Done():
-			cb(ChatCompletion{}, true, ctx.Err())
-			return
-		default:
-		}
-
-		var entry ChatCompletion
-		b := scanner.Bytes()
-		switch {
-		case len(b) == 0:
-			continue
-		case bytes.HasPrefix(b, StreamData):
-			if bytes.HasSuffix(b, StreamDone) {
-				if len(entry.Choices) <= 0 {
-					entry.Choices = []ChatCompletionChoice{
-						{Message: ChatMessage{ToolCalls: []ToolCall{}}},
-					}
-				}
-
-				cb(entry, true, nil)
-				return
-			}
-			if err := json.Unmarshal(b[len(StreamData):], &entry); err != nil {
-				cb(entry, true, err)
-				return
+// newChatCompletionHandler returns a new handler which decodes chat completion chunks (with merged tool calls),
+// and emits the whole chat completion rebuilt from them as the last item.
+func newChatCompletionHandler() eventHandler[ChatCompletion] {
+	acc := NewChatCompletionAccumulator()
+	finishReasonReceived := false
+
+	return eventHandler[ChatCompletion]{
+		event: func(event ServerSentEvent, emit func(ChatCompletion, bool, error)) bool {
+			var entry ChatCompletion
+			if err := event.Decode(&entry); err != nil {
+				emit(entry, true, err)
+				return false
 			}
 			if entry.Type != nil {
 				entryType := *entry.Type
 				if entryType == "ping" {
-					continue
+					return true
 				}
 			}
+			if entry.Error != nil {
+				emit(entry, true, entry.Error.err())
+				return false
+			}
 
-			// Safe access to entry.Choices and tool calls
-			if len(entry.Choices) > 0 && len(entry.Choices[0].Delta.ToolCalls) > 0 {
-				toolCall := entry.Choices[0].Delta.ToolCalls[0]
-				// if there are multiple tools in the response, detect a change in index
-				if toolCall.Index != nil && *toolCall.Index != toolIndex {
-					toolCalls = append(toolCalls, fn)
-					toolIndex++
-					fn = ToolCall{Type: "function", Index: &toolIndex}
-				}
+			acc.Add(entry)
 
-				if toolCall.ID != "" {
-					fn.ID = toolCall.ID
-				}
+			for i, choice := range entry.Choices {
+				if choice.FinishReason != "" {
+					finishReasonReceived = true
 
-				if toolCall.Function.Name != "" {
-					fn.Function.Name = toolCall.Function.Name
-				} else if toolCall.Function.Arguments != "" {
-					fn.Function.Arguments = fn.Function.Arguments + toolCall.Function.Arguments
+					// put the merged tool calls in the message of the finishing chunk
+					if toolCalls := acc.ToolCalls(choice.Index); len(toolCalls) > 0 {
+						entry.Choices[i].Message.ToolCalls = toolCalls
+					}
 				}
 			}
-			// Safe access to finish reason
-			if len(entry.Choices) > 0 && (entry.Choices[0].FinishReason == "tool_calls" ||
-				(entry.Choices[0].FinishReason == "stop" && fn.ID != "")) {
-				// append last function call
-				toolCalls = append(toolCalls, fn)
-				entry.Choices[0].Message.ToolCalls = toolCalls
+			emit(entry, false, nil)
 
-				cb(entry, false, nil)
-				cb(entry, true, nil)
-
-				return
+			return true
+		},
+		end: func(done bool, err error, emit func(ChatCompletion, bool, error)) {
+			switch {
+			case err != nil:
+				emit(ChatCompletion{}, true, err)
+			case done || finishReasonReceived:
+				// the last item is the whole chat completion, rebuilt from the chunks
+				completion := acc.ChatCompletion()
+				if len(completion.Choices) == 0 {
+					completion.Choices = []ChatCompletionChoice{
+						{Message: ChatMessage{ToolCalls: []ToolCall{}}},
+					}
+				}
+				emit(completion, true, nil)
+			default:
+				// stream ended without finishing
+				emit(ChatCompletion{}, true, io.ErrUnexpectedEOF)
 			}
-			cb(entry, false, nil)
-		}
-	}
-	// Check for scanner error
-	if err := scanner.Err(); err != nil {
-		cb(ChatCompletion{}, true, err)
+		},
 	}
 }
 
-// postCBResponses sends HTTP POST request with streaming callback for responses API
-func (c *Client) postCBResponses(endpoint string, params map[string]any, cb responseCallback) (response []byte, err error) {
-	return c.postCBResponsesWithContext(context.Background(), endpoint, params, cb)
-}
-
-// postCBResponsesWithContext sends HTTP POST request with streaming callback and context for responses API
-func (c *Client) postCBResponsesWithContext(ctx context.Context, endpoint string, params map[string]any, cb responseCallback) (response []byte, err error) {
-	if params == nil {
-		params = map[string]any{}
-	}
-	url := baseURL
-	if c.baseURL != nil {
-		url = *c.baseURL
-	}
-	apiURL := fmt.Sprintf("%s/%s", url, endpoint)
-
-	var req *http.Request
-	// application/json
-	var serialized []byte
-	if serialized, err = json.Marshal(params); err == nil {
-		if req, err = http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewBuffer(serialized)); err != nil {
-			return nil, fmt.Errorf("failed to create application/json request: %s", err)
-		}
-
-		// set content-type header
-		req.Header.Set(kContentType, defaultContentType)
-	}
-
-	// set authentication headers
-	req.Header.Set(kAuthorization, fmt.Sprintf("Bearer %s", c.APIKey))
-	req.Header.Set(kOrganization, c.OrganizationID)
-
-	if c.Verbose {
-		if dumped, err := httputil.DumpRequest(req, true); err == nil {
-			log.Printf("dump request:\n\n%s", string(dumped))
-		}
-	}
-
-	// send request and return response bytes
-	var resp *http.Response
-	resp, err = c.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	if !isSuccessStatus(resp.StatusCode) {
-		defer resp.Body.Close()
-		errbody := struct {
-			Error Error `json:"error"`
-		}{}
-		if err := json.NewDecoder(resp.Body).Decode(&errbody); err != nil {
-			return nil, fmt.Errorf("failed to decode error body: %v", err)
-		}
-		return nil, errbody.Error.err()
-	}
-
-	go streamResponsesWithCtx(ctx, resp, cb)
-
-	return nil, nil
-}
-
-// streamResponsesWithCtx handles streaming responses for the responses API
-func streamResponsesWithCtx(ctx context.Context, res *http.Response, cb responseCallback) {
-	defer res.Body.Close()
-
-	scanner := bufio.NewScanner(res.Body)
-	for scanner.Scan() {
-		// Check for context cancellation
-		select {
-		case <-ctx.Done():
-			cb(ResponseStreamEvent{}, true, ctx.Err())
-			return
-		default:
-		}
-
-		b := scanner.Bytes()
-		if len(b) == 0 {
-			continue
-		}
-
-		// Skip event: lines
-		if bytes.HasPrefix(b, []byte("event:")) {
-			continue
-		}
-
-		// Process data: lines
-		if bytes.HasPrefix(b, []byte("data: ")) {
-			dataBytes := bytes.TrimPrefix(b, []byte("data: "))
-
-			// Check for [DONE] marker
-			if bytes.Equal(dataBytes, []byte("[DONE]")) {
-				cb(ResponseStreamEvent{}, true, nil)
-				return
-			}
-
+// newResponseStreamHandler returns a new handler which decodes streaming events of the responses API.
+func newResponseStreamHandler() eventHandler[ResponseStreamEvent] {
+	return eventHandler[ResponseStreamEvent]{
+		event: func(e ServerSentEvent, emit func(ResponseStreamEvent, bool, error)) bool {
 			// Parse JSON event
 			var event ResponseStreamEvent
-			if err := json.Unmarshal(dataBytes, &event); err != nil {
-				cb(ResponseStreamEvent{}, true, err)
-				return
+			if err := e.Decode(&event); err != nil {
+				emit(ResponseStreamEvent{}, true, err)
+				return false
+			}
+			if event.Type == "" {
+				event.Type = e.Event
 			}
 
-			// Check if this is a completion event
-			done := event.Type == "response.completed" || event.Type == "response.failed" || event.Type == "response.cancelled"
-			cb(event, done, nil)
+			// Check if this is an error event
+			if event.Type == "error" {
+				errbody := Error{}
+				if err := e.Decode(&errbody); err != nil {
+					emit(event, true, err)
+				} else {
+					emit(event, true, errbody.err())
+				}
+				return false
+			}
 
-			if done {
-				return
+			// Check if this is a completion event
+			done := event.Type == "response.completed" ||
+				event.Type == "response.failed" ||
+				event.Type == "response.incomplete" ||
+				event.Type == "response.cancelled"
+			if event.Type == "response.failed" && event.Response != nil && event.Response.Error != nil {
+				emit(event, done, event.Response.Error.err())
+			} else {
+				emit(event, done, nil)
 			}
-		}
-	}
 
-	// Check for scanner error
-	if err := scanner.Err(); err != nil {
-		cb(ResponseStreamEvent{}, true, err)
+			return !done
+		},
+		end: func(_ bool, err error, emit func(ResponseStreamEvent, bool, error)) {
+			emit(ResponseStreamEvent{}, true, err)
+		},
 	}
 }
 
-// FileParam struct for multipart requests
-type FileParam struct {
-	bs []byte
-}
-
-// NewFileParamFromBytes returns a new FileParam with given bytes
-func NewFileParamFromBytes(bs []byte) FileParam {
-	return FileParam{
-		bs: bs,
-	}
-}
+// newCompletionHandler returns a new handler which decodes legacy completion chunks.
+func newCompletionHandler() eventHandler[Completion] {
+	return eventHandler[Completion]{
+		event: func(event ServerSentEvent, emit func(Completion, bool, error)) bool {
+			var entry Completion
+			if err := event.Decode(&entry); err != nil {
+				emit(entry, true, err)
+				return false
+			}
+			if entry.Error != nil {
+				emit(entry, true, entry.Error.err())
+				return false
+			}
 
-// NewFileParamFromFilepath returns a new FileParam with bytes read from given filepath
-func NewFileParamFromFilepath(path string) (f FileParam, err error) {
-	var bs []byte
-	if bs, err = os.ReadFile(path); err == nil {
-		return FileParam{
-			bs: bs,
-		}, nil
+			emit(entry, false, nil)
+			return true
+		},
+		end: func(_ bool, err error, emit func(Completion, bool, error)) {
+			emit(Completion{}, true, err)
+		},
 	}
-	return FileParam{}, err
 }
 
-// sends HTTP request with context
-func (c *Client) doWithContext(ctx context.Context, method, endpoint string, params map[string]any) (response []byte, err error) {
+// newRequest builds a HTTP request for given method, endpoint, and params
+// (with the credential and base URL selected in `state`)
+func (c *Client) newRequest(ctx context.Context, method, endpoint string, params map[string]any, state *requestState) (req *http.Request, err error) {
 	if params == nil {
 		params = map[string]any{}
 	}
-	url := baseURL
-	if c.baseURL != nil {
-		url = *c.baseURL
+
+	options := requestOptionsFromContext(ctx)
+	params = options.params(params)
+
+	credential := Credential{
+		APIKey:         c.APIKey,
+		OrganizationID: c.OrganizationID,
+		ProjectID:      c.ProjectID,
+	}
+	if selected := c.credentials.credential(&state.pool); selected != nil {
+		credential = *selected
 	}
-	apiURL := fmt.Sprintf("%s/%s", url, endpoint)
+	credential = options.credential(credential)
+
+	var apiURL string
+	if c.azure != nil {
+		if apiURL, params, err = c.azure.url(endpoint, params); err != nil {
+			return nil, err
+		}
+	} else {
+		url := baseURL
+		if c.baseURL != nil {
+			url = *c.baseURL
+		}
+		if selected := c.credentials.baseURL(&state.pool); selected != "" {
+			url = selected
+		}
+		apiURL = fmt.Sprintf("%s/%s", url, endpoint)
+	}
+
+	if !hasRequestBody(method) {
+		if req, err = http.NewRequestWithContext(ctx, method, apiURL, nil); err != nil {
+			return nil, err
+		}
 
-	var req *http.Request
-	if req, err = http.NewRequestWithContext(ctx, method, apiURL, nil); err == nil {
 		// parameters
 		queries := req.URL.Query()
 		for k, v := range params {
 			queries.Add(k, fmt.Sprintf("%+v", v))
 		}
 		req.URL.RawQuery = queries.Encode()
+	} else if hasFileInParams(params) {
+		// multipart/form-data (streamed)
+		var body *multipartBody
+		if body, err = newMultipartBody(params); err != nil {
+			return nil, err
+		}
 
-		// headers
-		req.Header.Set(kAuthorization, fmt.Sprintf("Bearer %s", c.APIKey))
-		req.Header.Set(kOrganization, c.OrganizationID)
-		if c.beta != nil {
-			req.Header.Set(kBeta, *c.beta)
+		if req, err = http.NewRequestWithContext(ctx, method, apiURL, body); err != nil {
+			body.Close()
+			return nil, fmt.Errorf("failed to create multipart request: %s", err)
 		}
+		req.ContentLength = body.length // -1 if unknown
 
-		if c.Verbose {
-			if dumped, err := httputil.DumpRequest(req, true); err == nil {
-				log.Printf("dump request:\n\n%s", string(dumped))
-			}
+		// set content-type header
+		req.Header.Set(kContentType, body.contentType())
+	} else {
+		// application/json
+		var serialized []byte
+		if serialized, err = json.Marshal(params); err != nil {
+			return nil, err
+		}
+		if req, err = http.NewRequestWithContext(ctx, method, apiURL, bytes.NewBuffer(serialized)); err != nil {
+			return nil, fmt.Errorf("failed to create application/json request: %s", err)
 		}
 
-		req.Close = true
+		// set content-type header
+		req.Header.Set(kContentType, defaultContentType)
+	}
 
-		// send request and return response bytes
-		var resp *http.Response
-		resp, err = c.httpClient.Do(req)
-		if resp != nil {
-			defer resp.Body.Close()
+	// extra query parameters
+	if len(options.extraQuery) > 0 {
+		queries := req.URL.Query()
+		for k, v := range options.extraQuery {
+			queries.Set(k, v)
 		}
-		if err == nil {
-			if response, err = io.ReadAll(resp.Body); err == nil {
-				if c.Verbose {
-					log.Printf("API response for %s: '%s'", endpoint, string(response))
-				}
+		req.URL.RawQuery = queries.Encode()
+	}
 
-				if !isSuccessStatus(resp.StatusCode) {
-					err = fmt.Errorf("http status %d", resp.StatusCode)
-				}
+	// set default headers
+	for k, vs := range c.headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
 
-				return response, err
-			}
+	// set headers of the request (replacing the default ones)
+	for k, vs := range options.headers {
+		req.Header.Del(k)
+		for _, v := range vs {
+			req.Header.Add(k, v)
 		}
 	}
+	if c.userAgent != "" {
+		req.Header.Set(kUserAgent, c.userAgent)
+	}
 
-	return nil, err
-}
+	// set authentication headers
+	if c.azure != nil {
+		if err = c.azure.setAuthHeaders(ctx, req, credential.APIKey); err != nil {
+			return nil, err
+		}
+	} else {
+		req.Header.Set(kAuthorization, fmt.Sprintf("Bearer %s", credential.APIKey))
+		req.Header.Set(kOrganization, credential.OrganizationID)
+		if credential.ProjectID != "" {
+			req.Header.Set(kProject, credential.ProjectID)
+		}
+	}
+	if options.beta != nil {
+		req.Header.Set(kBeta, *options.beta)
+	} else if c.beta != nil {
+		req.Header.Set(kBeta, *c.beta)
+	}
+	if key := idempotencyKeyFromContext(ctx); key != "" {
+		req.Header.Set(kIdempotencyKey, key)
+	}
 
-// sends HTTP GET request with context
-func (c *Client) getWithContext(ctx context.Context, endpoint string, params map[string]any) (response []byte, err error) {
-	return c.doWithContext(ctx, http.MethodGet, endpoint, params)
+	return req, nil
 }
 
-// sends HTTP GET request
-func (c *Client) get(endpoint string, params map[string]any) (response []byte, err error) {
-	return c.getWithContext(context.Background(), endpoint, params)
+// requestState struct for the state of a request, kept across its attempts
+type requestState struct {
+	method      string
+	stream      bool
+	started     time.Time
+	attempts    int
+	reservation *rateLimitReservation
+	pool        poolSelection
+	budget      *budgetReservation
 }
 
-// sends HTTP DELETE request with context
-func (c *Client) deleteWithContext(ctx context.Context, endpoint string, params map[string]any) (response []byte, err error) {
-	return c.doWithContext(ctx, http.MethodDelete, endpoint, params)
-}
+// send builds and sends a HTTP request, retrying it with the client's retry policy on transient failures
+//
+// `state` is updated on each try, so that streaming requests can continue counting attempts.
+func (c *Client) send(ctx context.Context, method, endpoint string, params map[string]any, state *requestState) (resp *http.Response, err error) {
+	retryable := c.retryPolicy != nil && isRetryableRequest(ctx, method) && isReplayableParams(params)
 
-// sends HTTP DELETE request
-func (c *Client) delete(endpoint string, params map[string]any) (response []byte, err error) {
-	return c.deleteWithContext(context.Background(), endpoint, params)
-}
+	for {
+		state.attempts++
 
-// sends HTTP POST request with context
-func (c *Client) postWithContext(ctx context.Context, endpoint string, params map[string]any) (response []byte, err error) {
-	if params == nil {
-		params = map[string]any{}
-	}
+		if c.rateLimiter != nil {
+			if state.reservation, err = c.rateLimiter.wait(ctx, endpoint, params); err != nil {
+				return nil, err
+			}
+		}
 
-	url := baseURL
-	if c.baseURL != nil {
-		url = *c.baseURL
-	}
-	apiURL := fmt.Sprintf("%s/%s", url, endpoint)
+		if c.credentials != nil {
+			c.credentials.selectFor(&state.pool)
+		}
 
-	var req *http.Request
+		var req *http.Request
+		if req, err = c.newRequest(ctx, method, endpoint, params, state); err != nil {
+			return nil, err
+		}
 
-	if hasFileInParams(params) {
-		// multipart/form-data
-		body := &bytes.Buffer{}
-		writer := multipart.NewWriter(body)
+		apiReq := &APIRequest{
+			HTTPRequest: req,
+			Endpoint:    endpoint,
+			Params:      params,
+			Stream:      state.stream,
+		}
+		c.logRequest(ctx, apiReq, state.attempts)
 
-		for k, v := range params {
-			switch val := v.(type) {
-			case FileParam:
-				bs := val.bs
-				filename := fmt.Sprintf("%s.%s", k, getExtension(bs))
-
-				var part io.Writer
-				if part, err = writer.CreatePart(mimeHeaderForBytes(bs, k, filename)); err == nil {
-					if _, err = io.Copy(part, bytes.NewReader(bs)); err != nil {
-						return nil, fmt.Errorf("could not write bytes to multipart for param '%s': %s", k, err)
-					}
-				} else {
-					return nil, fmt.Errorf("could not create part for param '%s': %s", k, err)
-				}
-			default:
-				if err := writer.WriteField(k, fmt.Sprintf("%v", v)); err != nil {
-					return nil, fmt.Errorf("could not write field with key: %s, value: %v", k, v)
+		resp, err = c.doer().Do(apiReq)
+		if c.rateLimiter != nil {
+			if resp != nil {
+				c.rateLimiter.observe(state.reservation, resp.Header)
+			}
+			if err != nil || !isSuccessStatus(resp.StatusCode) {
+				c.rateLimiter.reconcile(state.reservation, 0)
+			}
+		}
+
+		// fail over to another credential or base URL immediately
+		// (a request which failed on a base URL might have been processed already,
+		// so it is sent to another base URL only when it is safe to send again)
+		if c.credentials != nil {
+			if kind := c.credentials.report(ctx, &state.pool, resp, err); kind != failoverNone &&
+				(kind == failoverCredential || isRetryableRequest(ctx, method)) &&
+				isReplayableParams(params) &&
+				c.credentials.canFailover(&state.pool, kind) {
+				if resp != nil {
+					_, _ = io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
 				}
+
+				c.logFailover(ctx, endpoint, state.attempts, resp, err)
+
+				continue
 			}
 		}
 
-		if err = writer.Close(); err != nil {
-			return nil, fmt.Errorf("error while closing multipart form data writer: %s", err)
+		if !retryable || state.attempts >= c.retryPolicy.maxAttempts() {
+			return resp, err
+		}
+		if err != nil {
+			if !c.retryPolicy.isRetryableError(ctx, err) {
+				return nil, err
+			}
+		} else if !c.retryPolicy.isRetryableStatus(resp.StatusCode) {
+			return resp, nil
 		}
 
-		if req, err = http.NewRequestWithContext(ctx, http.MethodPost, apiURL, body); err != nil {
-			return nil, fmt.Errorf("failed to create multipart request: %s", err)
+		delay := c.retryPolicy.delay(state.attempts, resp)
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
 		}
 
-		// set content-type header
-		req.Header.Set("Content-Type", writer.FormDataContentType())
-	} else {
-		// application/json
-		var serialized []byte
-		if serialized, err = json.Marshal(params); err == nil {
-			if req, err = http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewBuffer(serialized)); err != nil {
-				return nil, fmt.Errorf("failed to create application/json request: %s", err)
-			}
+		c.logRetry(ctx, endpoint, state.attempts, delay, resp, err)
 
-			// set content-type header
-			req.Header.Set(kContentType, defaultContentType)
+		if err := sleepWithContext(ctx, delay); err != nil {
+			return nil, err
 		}
 	}
+}
 
-	// set authentication headers
-	req.Header.Set(kAuthorization, fmt.Sprintf("Bearer %s", c.APIKey))
-	req.Header.Set(kOrganization, c.OrganizationID)
-	if c.beta != nil {
-		req.Header.Set(kBeta, *c.beta)
+// sends HTTP request with context
+func (c *Client) doWithContext(ctx context.Context, method, endpoint string, params map[string]any) (response []byte, err error) {
+	ctx = c.withIdempotencyKey(ctx, method)
+
+	ctx, cancel := requestOptionsFromContext(ctx).withTimeout(ctx)
+	defer cancel()
+
+	if _, exists := ctx.Deadline(); !exists && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
 	}
 
-	if c.Verbose {
-		if dumped, err := httputil.DumpRequest(req, true); err == nil {
-			log.Printf("dump request:\n\n%s", string(dumped))
-		}
+	state := &requestState{method: method, started: time.Now()}
+
+	if state.budget, err = c.reserveBudgets(ctx, endpoint, params); err != nil {
+		c.logResponse(ctx, endpoint, params, state, nil, nil, err)
+		return nil, err
 	}
-	req.Close = true
 
-	// send request and return response bytes
 	var resp *http.Response
-	resp, err = c.httpClient.Do(req)
+	resp, err = c.send(ctx, method, endpoint, params, state)
 	if resp != nil {
 		defer resp.Body.Close()
 	}
 	if err == nil {
 		if response, err = io.ReadAll(resp.Body); err == nil {
-			if c.Verbose {
-				log.Printf("API response for %s: '%s'", endpoint, string(response))
-			}
-
 			if !isSuccessStatus(resp.StatusCode) {
-				err = fmt.Errorf("http status %d", resp.StatusCode)
+				err = newAPIError(resp, response)
+			} else {
+				if c.rateLimiter != nil {
+					c.rateLimiter.reconcile(state.reservation, usageTokens(response))
+				}
+				c.trackUsage(ctx, endpoint, params, response)
+				state.budget.settle(response)
 			}
-
-			return response, err
+		} else {
+			response = nil
 		}
 	}
+	state.budget.release() // no-op if settled
 
-	return nil, err
-}
+	fillResponseMeta(ctx, resp, state)
+	c.logResponse(ctx, endpoint, params, state, resp, response, err)
 
-// sends HTTP POST request
-func (c *Client) post(endpoint string, params map[string]any) (response []byte, err error) {
-	return c.postWithContext(context.Background(), endpoint, params)
+	return response, err
 }
 
-// sends HTTP POST request with streaming callback
-func (c *Client) postCB(endpoint string, params map[string]any, cb callback) (response []byte, err error) {
-	return c.postCBWithContext(context.Background(), endpoint, params, cb)
+// sends HTTP GET request with context
+func (c *Client) getWithContext(ctx context.Context, endpoint string, params map[string]any) (response []byte, err error) {
+	return c.doWithContext(ctx, http.MethodGet, endpoint, params)
 }
 
-// sends HTTP POST request with streaming callback and context
-func (c *Client) postCBWithContext(ctx context.Context, endpoint string, params map[string]any, cb callback) (response []byte, err error) {
-	if params == nil {
-		params = map[string]any{}
-	}
-	url := baseURL
-	if c.baseURL != nil {
-		url = *c.baseURL
-	}
-	apiURL := fmt.Sprintf("%s/%s", url, endpoint)
-
-	var req *http.Request
-	// application/json
-	var serialized []byte
-	if serialized, err = json.Marshal(params); err == nil {
-		if req, err = http.NewRequestWithContext(ctx, http.MethodPost, apiURL, bytes.NewBuffer(serialized)); err != nil {
-			return nil, fmt.Errorf("failed to create application/json request: %s", err)
-		}
-
-		// set content-type header
-		req.Header.Set(kContentType, defaultContentType)
-	}
-
-	// set authentication headers
-	req.Header.Set(kAuthorization, fmt.Sprintf("Bearer %s", c.APIKey))
-	req.Header.Set(kOrganization, c.OrganizationID)
+// sends HTTP DELETE request with context
+func (c *Client) deleteWithContext(ctx context.Context, endpoint string, params map[string]any) (response []byte, err error) {
+	return c.doWithContext(ctx, http.MethodDelete, endpoint, params)
+}
 
-	if c.Verbose {
-		if dumped, err := httputil.DumpRequest(req, true); err == nil {
-			log.Printf("dump request:\n\n%s", string(dumped))
-		}
-	}
+// sends HTTP POST request with context
+func (c *Client) postWithContext(ctx context.Context, endpoint string, params map[string]any) (response []byte, err error) {
+	return c.doWithContext(ctx, http.MethodPost, endpoint, params)
+}
 
-	// send request and return response bytes
-	var resp *http.Response
-	resp, err = c.httpClient.Do(req)
-	if err != nil {
+// sends HTTP request for streaming (with `state.method`) and returns the response with success status
+func (c *Client) openStreamWithContext(ctx context.Context, endpoint string, params map[string]any, state *requestState) (resp *http.Response, err error) {
+	if resp, err = c.send(ctx, state.method, endpoint, params, state); err != nil {
+		c.logResponse(ctx, endpoint, params, state, nil, nil, err)
 		return nil, err
 	}
+	fillResponseMeta(ctx, resp, state)
 	if !isSuccessStatus(resp.StatusCode) {
 		defer resp.Body.Close()
-		errbody := struct {
-			Error Error `json:"error"`
-		}{}
-		if err := json.NewDecoder(resp.Body).Decode(&errbody); err != nil {
-			return nil, fmt.Errorf("failed to decode error body: %v", err)
-		}
-		return nil, errbody.Error.err()
+
+		body, _ := io.ReadAll(resp.Body)
+		err = newAPIError(resp, body)
+		c.logResponse(ctx, endpoint, params, state, resp, body, err)
+		return nil, err
 	}
 
-	go streamWithCtx(ctx, resp, cb)
+	c.logStreamStarted(ctx, endpoint, params, state, resp)
 
-	return nil, nil
+	return resp, nil
+}
+
+// sends HTTP POST request with streaming callback and context
+func (c *Client) postCBWithContext(ctx context.Context, endpoint string, params map[string]any, cb callback) (response []byte, err error) {
+	return nil, startStream(ctx, c, http.MethodPost, endpoint, params, newChatCompletionHandler, cb)
+}
+
+// postCBResponsesWithContext sends HTTP POST request with streaming callback and context for responses API
+func (c *Client) postCBResponsesWithContext(ctx context.Context, endpoint string, params map[string]any, cb responseCallback) (response []byte, err error) {
+	return nil, startStream(ctx, c, http.MethodPost, endpoint, params, newResponseStreamHandler, cb)
+}
+
+// checks if requests with given method send their params in the body (not in the query string)
+func hasRequestBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
 }
 
 // checks if given params include any file param
@@ -561,32 +542,3 @@ func hasFileInParams(params map[string]any) bool {
 	}
 	return false
 }
-
-// get file extension from given bytes array
-//
-// https://www.w3.org/Protocols/rfc1341/4_Content-Type.html
-func getExtension(bytes []byte) string {
-	types := strings.Split(http.DetectContentType(bytes), "/") // ex: "image/jpeg"
-	if len(types) >= 2 {
-		splitted := strings.Split(types[1], ";") // for removing subtype parameter
-		if len(splitted) >= 1 {
-			if splitted[0] == "wave" {
-				return "wav"
-			}
-			if splitted[0] == "octet-stream" {
-				return "mp3"
-			}
-
-			return splitted[0] // return subtype only
-		}
-	}
-	return ""
-}
-
-// generates mime header
-func mimeHeaderForBytes(bs []byte, key, filename string) textproto.MIMEHeader {
-	h := make(textproto.MIMEHeader)
-	h.Set(kContentDisposition, fmt.Sprintf(`form-data; name="%s"; filename="%s"`, key, filename))
-	h.Set(kContentType, http.DetectContentType(bs))
-	return h
-}
@@ -1,8 +1,8 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 )
 
 // https://platform.openai.com/docs/api-reference/images
@@ -117,14 +117,23 @@ func (o ImageOptions) SetUser(user string) ImageOptions {
 // CreateImage creates an image with given prompt.
 //
 // https://platform.openai.com/docs/api-reference/images/create
-func (c *Client) CreateImage(prompt string, options ImageOptions) (response GeneratedImages, err error) {
+func (c *Client) CreateImage(prompt string, options ImageOptions, opts ...RequestOption) (response GeneratedImages, err error) {
+	return c.CreateImageWithContext(context.Background(), prompt, options, opts...)
+}
+
+// CreateImageWithContext creates an image with given prompt with context support.
+//
+// https://platform.openai.com/docs/api-reference/images/create
+func (c *Client) CreateImageWithContext(ctx context.Context, prompt string, options ImageOptions, opts ...RequestOption) (response GeneratedImages, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ImageOptions{}
 	}
 	options["prompt"] = prompt
 
 	var bytes []byte
-	if bytes, err = c.post("v1/images/generations", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/images/generations", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -132,11 +141,6 @@ func (c *Client) CreateImage(prompt string, options ImageOptions) (response Gene
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return GeneratedImages{}, err
@@ -198,7 +202,16 @@ func (o ImageEditOptions) SetUser(user string) ImageEditOptions {
 // CreateImageEdit creates an edited or extended image with given file and prompt.
 //
 // https://platform.openai.com/docs/api-reference/images/create-edit
-func (c *Client) CreateImageEdit(image FileParam, prompt string, options ImageEditOptions) (response GeneratedImages, err error) {
+func (c *Client) CreateImageEdit(image FileParam, prompt string, options ImageEditOptions, opts ...RequestOption) (response GeneratedImages, err error) {
+	return c.CreateImageEditWithContext(context.Background(), image, prompt, options, opts...)
+}
+
+// CreateImageEditWithContext creates an edited or extended image with given file and prompt with context support.
+//
+// https://platform.openai.com/docs/api-reference/images/create-edit
+func (c *Client) CreateImageEditWithContext(ctx context.Context, image FileParam, prompt string, options ImageEditOptions, opts ...RequestOption) (response GeneratedImages, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ImageEditOptions{}
 	}
@@ -206,7 +219,7 @@ func (c *Client) CreateImageEdit(image FileParam, prompt string, options ImageEd
 	options["prompt"] = prompt
 
 	var bytes []byte
-	if bytes, err = c.post("v1/images/edits", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/images/edits", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -214,11 +227,6 @@ func (c *Client) CreateImageEdit(image FileParam, prompt string, options ImageEd
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return GeneratedImages{}, err
@@ -272,14 +280,23 @@ func (o ImageVariationOptions) SetUser(user string) ImageVariationOptions {
 // CreateImageVariation creates a variation of a given image.
 //
 // https://platform.openai.com/docs/api-reference/images/create-variation
-func (c *Client) CreateImageVariation(image FileParam, options ImageVariationOptions) (response GeneratedImages, err error) {
+func (c *Client) CreateImageVariation(image FileParam, options ImageVariationOptions, opts ...RequestOption) (response GeneratedImages, err error) {
+	return c.CreateImageVariationWithContext(context.Background(), image, options, opts...)
+}
+
+// CreateImageVariationWithContext creates a variation of a given image with context support.
+//
+// https://platform.openai.com/docs/api-reference/images/create-variation
+func (c *Client) CreateImageVariationWithContext(ctx context.Context, image FileParam, options ImageVariationOptions, opts ...RequestOption) (response GeneratedImages, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ImageVariationOptions{}
 	}
 	options["image"] = image
 
 	var bytes []byte
-	if bytes, err = c.post("v1/images/variations", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/images/variations", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -287,11 +304,6 @@ func (c *Client) CreateImageVariation(image FileParam, options ImageVariationOpt
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return GeneratedImages{}, err
@@ -0,0 +1,348 @@
+package openai
+
+// types and functions for structured logging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// max length of string values in logged request/response bodies
+	maxLoggedStringLength = 256
+
+	// max length of logged response bodies
+	maxLoggedBodyLength = 4096
+
+	redacted = "[REDACTED]"
+)
+
+// logger for deprecated `Client.Verbose`
+var verboseLogger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+// headers which hold secrets
+var secretHeaders = map[string]bool{
+	http.CanonicalHeaderKey(kAuthorization): true,
+	http.CanonicalHeaderKey(kAzureAPIKey):   true,
+	http.CanonicalHeaderKey("Cookie"):       true,
+}
+
+// SetLogger sets the structured logger of the client.
+//
+// Requests are logged with levels:
+//
+//   - Debug: request/response details (secrets are redacted and large values are truncated)
+//   - Info: finished requests and streams
+//   - Warn: API errors and retries
+//   - Error: failed requests
+func (c *Client) SetLogger(logger *slog.Logger) *Client {
+	c.logger = logger
+
+	return c
+}
+
+// WithLogger sets the structured logger of the client.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.SetLogger(logger)
+	}
+}
+
+// activeLogger returns the logger of the client, or nil if logging is disabled.
+func (c *Client) activeLogger() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	if c.Verbose {
+		return verboseLogger
+	}
+	return nil
+}
+
+// modelOf returns the model name in given params.
+func modelOf(params map[string]any) string {
+	if model, ok := params["model"].(string); ok {
+		return model
+	}
+	return ""
+}
+
+// logRequest logs given request before it is sent.
+func (c *Client) logRequest(ctx context.Context, req *APIRequest, attempt int) {
+	logger := c.activeLogger()
+	if logger == nil || !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+
+	logger.LogAttrs(ctx, slog.LevelDebug, "sending request",
+		slog.String("endpoint", req.Endpoint),
+		slog.String("model", modelOf(req.Params)),
+		slog.String("method", req.HTTPRequest.Method),
+		slog.String("url", req.HTTPRequest.URL.String()),
+		slog.Int("attempt", attempt),
+		slog.Bool("stream", req.Stream),
+		slog.Any("headers", redactHeaders(req.HTTPRequest.Header)),
+		slog.Any("params", redactParams(req.Params)),
+	)
+}
+
+// logRetry logs a retry of a request.
+func (c *Client) logRetry(ctx context.Context, endpoint string, attempt int, delay time.Duration, resp *http.Response, err error) {
+	logger := c.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", endpoint),
+		slog.Int("attempt", attempt),
+		slog.Duration("delay", delay),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.String("request_id", resp.Header.Get(kRequestID)),
+		)
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, slog.LevelWarn, "retrying request", attrs...)
+}
+
+// logFailover logs a failover of a request to another credential or base URL.
+func (c *Client) logFailover(ctx context.Context, endpoint string, attempt int, resp *http.Response, err error) {
+	logger := c.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", endpoint),
+		slog.Int("attempt", attempt),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.String("request_id", resp.Header.Get(kRequestID)),
+		)
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, slog.LevelWarn, "failing over request", attrs...)
+}
+
+// logCacheHit logs a request which is served from the cache.
+func (c *Client) logCacheHit(ctx context.Context, endpoint string, params map[string]any) {
+	logger := c.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	logger.LogAttrs(ctx, slog.LevelDebug, "cache hit",
+		slog.String("endpoint", endpoint),
+		slog.String("model", modelOf(params)),
+	)
+}
+
+// logCacheError logs a failure of the cache, which is treated as a cache miss.
+func (c *Client) logCacheError(ctx context.Context, endpoint string, err error) {
+	logger := c.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	logger.LogAttrs(ctx, slog.LevelWarn, "cache failed",
+		slog.String("endpoint", endpoint),
+		slog.String("error", err.Error()),
+	)
+}
+
+// logResponse logs a finished non-streaming request.
+func (c *Client) logResponse(ctx context.Context, endpoint string, params map[string]any, state *requestState, resp *http.Response, body []byte, err error) {
+	logger := c.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", endpoint),
+		slog.String("model", modelOf(params)),
+		slog.Duration("latency", time.Since(state.started)),
+		slog.Int("attempts", state.attempts),
+	}
+	if resp != nil {
+		attrs = append(attrs,
+			slog.Int("status", resp.StatusCode),
+			slog.String("request_id", resp.Header.Get(kRequestID)),
+		)
+	}
+	if usage := usageOf(body); usage != nil {
+		attrs = append(attrs, slog.Any("usage", usage))
+	}
+
+	switch {
+	case err == nil:
+		logger.LogAttrs(ctx, slog.LevelInfo, "request finished", attrs...)
+	case AsAPIError(err) != nil:
+		logger.LogAttrs(ctx, slog.LevelWarn, "request failed with API error", append(attrs, slog.String("error", err.Error()))...)
+	default:
+		logger.LogAttrs(ctx, slog.LevelError, "request failed", append(attrs, slog.String("error", err.Error()))...)
+	}
+
+	if len(body) > 0 && logger.Enabled(ctx, slog.LevelDebug) {
+		logger.LogAttrs(ctx, slog.LevelDebug, "response body",
+			slog.String("endpoint", endpoint),
+			slog.String("body", redactBody(resp, body)),
+		)
+	}
+}
+
+// logStreamStarted logs a stream which is started.
+func (c *Client) logStreamStarted(ctx context.Context, endpoint string, params map[string]any, state *requestState, resp *http.Response) {
+	logger := c.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	logger.LogAttrs(ctx, slog.LevelDebug, "stream started",
+		slog.String("endpoint", endpoint),
+		slog.String("model", modelOf(params)),
+		slog.Int("status", resp.StatusCode),
+		slog.String("request_id", resp.Header.Get(kRequestID)),
+		slog.Duration("header_latency", time.Since(state.started)),
+	)
+}
+
+// logStreamFinished logs a stream which is finished with given reason.
+func (c *Client) logStreamFinished(ctx context.Context, endpoint string, params map[string]any, state *requestState, firstByte time.Duration, events int, err error) {
+	logger := c.activeLogger()
+	if logger == nil {
+		return
+	}
+
+	reason := "done"
+	if err != nil {
+		if ctx.Err() != nil {
+			reason = "canceled"
+		} else {
+			reason = "error"
+		}
+	}
+
+	attrs := []slog.Attr{
+		slog.String("endpoint", endpoint),
+		slog.String("model", modelOf(params)),
+		slog.Duration("first_event_latency", firstByte),
+		slog.Duration("latency", time.Since(state.started)),
+		slog.Int("events", events),
+		slog.Int("attempts", state.attempts),
+		slog.String("reason", reason),
+	}
+	if err != nil {
+		logger.LogAttrs(ctx, slog.LevelWarn, "stream finished", append(attrs, slog.String("error", err.Error()))...)
+	} else {
+		logger.LogAttrs(ctx, slog.LevelInfo, "stream finished", attrs...)
+	}
+}
+
+// redactHeaders returns a copy of given headers with secrets redacted.
+func redactHeaders(header http.Header) map[string]string {
+	redactedHeaders := map[string]string{}
+	for k, vs := range header {
+		if secretHeaders[http.CanonicalHeaderKey(k)] {
+			redactedHeaders[k] = redacted
+		} else {
+			redactedHeaders[k] = strings.Join(vs, ", ")
+		}
+	}
+	return redactedHeaders
+}
+
+// redactParams returns a loggable copy of given params, with files and large values truncated.
+func redactParams(params map[string]any) any {
+	copied := map[string]any{}
+	for k, v := range params {
+		switch val := v.(type) {
+		case FileParam:
+			if val.size >= 0 {
+				copied[k] = fmt.Sprintf("<file: %d bytes>", val.size)
+			} else {
+				copied[k] = "<file: unknown size>"
+			}
+		default:
+			copied[k] = v
+		}
+	}
+
+	bytes, err := json.Marshal(copied)
+	if err != nil {
+		return fmt.Sprintf("<failed to marshal params: %s>", err)
+	}
+	var decoded any
+	if err := json.Unmarshal(bytes, &decoded); err != nil {
+		return fmt.Sprintf("<failed to unmarshal params: %s>", err)
+	}
+	return truncateValues(decoded)
+}
+
+// redactBody returns a loggable string of given response body.
+func redactBody(resp *http.Response, body []byte) string {
+	if resp != nil && !strings.Contains(resp.Header.Get(kContentType), "json") {
+		return fmt.Sprintf("<%s: %d bytes>", resp.Header.Get(kContentType), len(body))
+	}
+
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err == nil {
+		if bytes, err := json.Marshal(truncateValues(decoded)); err == nil {
+			body = bytes
+		}
+	}
+	return truncateString(string(body), maxLoggedBodyLength)
+}
+
+// truncateValues truncates large string values (eg. base64-encoded images) in given decoded JSON value.
+func truncateValues(v any) any {
+	switch val := v.(type) {
+	case string:
+		return truncateString(val, maxLoggedStringLength)
+	case []any:
+		for i := range val {
+			val[i] = truncateValues(val[i])
+		}
+		return val
+	case map[string]any:
+		for k := range val {
+			val[k] = truncateValues(val[k])
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// truncateString truncates given string if it is longer than `max`.
+func truncateString(s string, max int) string {
+	if len(s) > max {
+		return fmt.Sprintf("%s...(%d bytes truncated)", s[:max], len(s)-max)
+	}
+	return s
+}
+
+// usageOf returns the `usage` in given response bytes.
+func usageOf(body []byte) map[string]any {
+	var res struct {
+		Usage map[string]any `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &res); err == nil {
+		return res.Usage
+	}
+	return nil
+}
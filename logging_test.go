@@ -0,0 +1,97 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// bytes buffer which is safe for concurrent writes
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLoggerMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-request-id", "req_logged")
+
+		var requestBody map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err == nil && requestBody["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"chatcmpl-test","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":2,"total_tokens":12}}`))
+	}))
+	defer server.Close()
+
+	buf := &syncBuffer{}
+	client := NewClientWithOptions("sk-secret-api-key",
+		WithBaseURL(server.URL),
+		WithLogger(slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))),
+	)
+
+	image := bytes.Repeat([]byte{0x89, 'P', 'N', 'G'}, 1024)
+	if _, err := client.CreateChatCompletion("test-model", []ChatMessage{
+		NewChatUserMessage([]ChatMessageContent{NewChatMessageContentWithBytes(image)}),
+	}, nil); err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	}
+
+	done := make(chan struct{})
+	if err := client.CreateChatCompletionStreamWithContext(context.Background(), "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil, func(_ ChatCompletion, d bool, _ error) {
+		if d {
+			close(done)
+		}
+	}); err != nil {
+		t.Fatalf("failed to create chat completion stream: %s", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("stream did not finish in time")
+	}
+
+	logged := buf.String()
+	if strings.Contains(logged, "sk-secret-api-key") {
+		t.Errorf("api key was not redacted in logs")
+	}
+	if !strings.Contains(logged, "bytes truncated") {
+		t.Errorf("large base64 value was not truncated in logs")
+	}
+	for _, expected := range []string{
+		`"msg":"request finished"`,
+		`"request_id":"req_logged"`,
+		`"total_tokens":12`,
+		`"msg":"stream finished"`,
+		`"reason":"done"`,
+	} {
+		if !strings.Contains(logged, expected) {
+			t.Errorf("expected '%s' in logs", expected)
+		}
+	}
+}
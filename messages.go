@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -106,7 +107,16 @@ func (o CreateMessageOptions) SetMetadata(metadata map[string]string) CreateMess
 // CreateMessage creates a message with given `threadID`, `role`, `content`, and `options`.
 //
 // https://platform.openai.com/docs/api-reference/messages/createMessage
-func (c *Client) CreateMessage(threadID, role, content string, options CreateMessageOptions) (response Message, err error) {
+func (c *Client) CreateMessage(threadID, role, content string, options CreateMessageOptions, opts ...RequestOption) (response Message, err error) {
+	return c.CreateMessageWithContext(context.Background(), threadID, role, content, options, opts...)
+}
+
+// CreateMessageWithContext creates a message with given `threadID`, `role`, `content`, and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/messages/createMessage
+func (c *Client) CreateMessageWithContext(ctx context.Context, threadID, role, content string, options CreateMessageOptions, opts ...RequestOption) (response Message, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = CreateMessageOptions{}
 	}
@@ -114,7 +124,7 @@ func (c *Client) CreateMessage(threadID, role, content string, options CreateMes
 	options["content"] = content
 
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/threads/%s/messages", threadID), options); err == nil {
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/threads/%s/messages", threadID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -122,11 +132,6 @@ func (c *Client) CreateMessage(threadID, role, content string, options CreateMes
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Message{}, err
@@ -135,9 +140,18 @@ func (c *Client) CreateMessage(threadID, role, content string, options CreateMes
 // RetrieveMessage retrieves a message with given `threadID` and `messageID`.
 //
 // https://platform.openai.com/docs/api-reference/messages/getMessage
-func (c *Client) RetrieveMessage(threadID, messageID string) (response Message, err error) {
+func (c *Client) RetrieveMessage(threadID, messageID string, opts ...RequestOption) (response Message, err error) {
+	return c.RetrieveMessageWithContext(context.Background(), threadID, messageID, opts...)
+}
+
+// RetrieveMessageWithContext retrieves a message with given `threadID` and `messageID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/messages/getMessage
+func (c *Client) RetrieveMessageWithContext(ctx context.Context, threadID, messageID string, opts ...RequestOption) (response Message, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s/messages/%s", threadID, messageID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s/messages/%s", threadID, messageID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -145,11 +159,6 @@ func (c *Client) RetrieveMessage(threadID, messageID string) (response Message,
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Message{}, err
@@ -169,13 +178,22 @@ func (o ModifyMessageOptions) SetMetadata(metadata map[string]string) ModifyMess
 // ModifyMessage modifies a message with given `threadID`, `messageID`, and `options`.
 //
 // https://platform.openai.com/docs/api-reference/messages/modifyMessage
-func (c *Client) ModifyMessage(threadID, messageID string, options ModifyMessageOptions) (response Message, err error) {
+func (c *Client) ModifyMessage(threadID, messageID string, options ModifyMessageOptions, opts ...RequestOption) (response Message, err error) {
+	return c.ModifyMessageWithContext(context.Background(), threadID, messageID, options, opts...)
+}
+
+// ModifyMessageWithContext modifies a message with given `threadID`, `messageID`, and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/messages/modifyMessage
+func (c *Client) ModifyMessageWithContext(ctx context.Context, threadID, messageID string, options ModifyMessageOptions, opts ...RequestOption) (response Message, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ModifyMessageOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/threads/%s/messages/%s", threadID, messageID), options); err == nil {
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/threads/%s/messages/%s", threadID, messageID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -183,11 +201,6 @@ func (c *Client) ModifyMessage(threadID, messageID string, options ModifyMessage
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Message{}, err
@@ -243,13 +256,22 @@ func (o ListMessagesOptions) SetBefore(before string) ListMessagesOptions {
 // ListMessages fetches messages with given `threadID`, and `options`.
 //
 // https://platform.openai.com/docs/api-reference/messages/listMessages
-func (c *Client) ListMessages(threadID string, options ListMessagesOptions) (response Messages, err error) {
+func (c *Client) ListMessages(threadID string, options ListMessagesOptions, opts ...RequestOption) (response Messages, err error) {
+	return c.ListMessagesWithContext(context.Background(), threadID, options, opts...)
+}
+
+// ListMessagesWithContext fetches messages with given `threadID`, and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/messages/listMessages
+func (c *Client) ListMessagesWithContext(ctx context.Context, threadID string, options ListMessagesOptions, opts ...RequestOption) (response Messages, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ListMessagesOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s/messages", threadID), options); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s/messages", threadID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -257,11 +279,6 @@ func (c *Client) ListMessages(threadID string, options ListMessagesOptions) (res
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Messages{}, err
@@ -279,9 +296,18 @@ type MessageFile struct {
 // RetrieveMessageFile retrieves a message file with given `threadID`, `messageID`, and `fileID`.
 //
 // https://platform.openai.com/docs/api-reference/messages/getMessageFile
-func (c *Client) RetrieveMessageFile(threadID, messageID, fileID string) (response MessageFile, err error) {
+func (c *Client) RetrieveMessageFile(threadID, messageID, fileID string, opts ...RequestOption) (response MessageFile, err error) {
+	return c.RetrieveMessageFileWithContext(context.Background(), threadID, messageID, fileID, opts...)
+}
+
+// RetrieveMessageFileWithContext retrieves a message file with given `threadID`, `messageID`, and `fileID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/messages/getMessageFile
+func (c *Client) RetrieveMessageFileWithContext(ctx context.Context, threadID, messageID, fileID string, opts ...RequestOption) (response MessageFile, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s/messages/%s/files/%s", threadID, messageID, fileID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s/messages/%s/files/%s", threadID, messageID, fileID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -289,11 +315,6 @@ func (c *Client) RetrieveMessageFile(threadID, messageID, fileID string) (respon
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return MessageFile{}, err
@@ -349,13 +370,22 @@ func (o ListMessageFilesOptions) SetBefore(before string) ListMessageFilesOption
 // ListMessageFiles fetches message files with given `threadID`, `mesageID`, and `options`.
 //
 // https://platform.openai.com/docs/api-reference/messages/listMessageFiles
-func (c *Client) ListMessageFiles(threadID, messageID string, options ListMessageFilesOptions) (response MessageFiles, err error) {
+func (c *Client) ListMessageFiles(threadID, messageID string, options ListMessageFilesOptions, opts ...RequestOption) (response MessageFiles, err error) {
+	return c.ListMessageFilesWithContext(context.Background(), threadID, messageID, options, opts...)
+}
+
+// ListMessageFilesWithContext fetches message files with given `threadID`, `mesageID`, and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/messages/listMessageFiles
+func (c *Client) ListMessageFilesWithContext(ctx context.Context, threadID, messageID string, options ListMessageFilesOptions, opts ...RequestOption) (response MessageFiles, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ListMessageFilesOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s/messages/%s/files", threadID, messageID), options); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s/messages/%s/files", threadID, messageID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -363,11 +393,6 @@ func (c *Client) ListMessageFiles(threadID, messageID string, options ListMessag
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return MessageFiles{}, err
@@ -0,0 +1,147 @@
+package openai
+
+// types and functions for response metadata
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	kProcessingMillis = "Openai-Processing-Ms"
+)
+
+// ResponseMeta struct for the metadata of a response
+type ResponseMeta struct {
+	// HTTP status code
+	StatusCode int
+
+	// HTTP headers
+	Header http.Header
+
+	// value of `x-request-id` header, for correlating with OpenAI support
+	RequestID string
+
+	// rate limit state parsed from `x-ratelimit-*` headers
+	RateLimit RateLimitState
+
+	// server-side processing time (from `openai-processing-ms` header, 0 if missing)
+	ProcessingTime time.Duration
+
+	// wall-clock latency of the request, including retries
+	// (for streams, until the headers arrive)
+	Latency time.Duration
+
+	// number of attempts
+	Attempts int
+
+	// name of the credential in CredentialPool which served the request (empty if no pool is set)
+	Credential string
+
+	// base URL in CredentialPool which served the request (empty if no base URLs are set in the pool)
+	BaseURL string
+
+	// whether the response was served from the client's cache (without sending a request)
+	CacheHit bool
+}
+
+// RateLimitState struct for the rate limit state in response headers
+//
+// Values are -1 when the headers are missing.
+type RateLimitState struct {
+	LimitRequests     int
+	LimitTokens       int
+	RemainingRequests int
+	RemainingTokens   int
+	ResetRequests     time.Duration
+	ResetTokens       time.Duration
+}
+
+type responseMetaContextKey struct{}
+
+// ContextWithResponseMeta returns a copy of `ctx` which makes requests with it fill `meta`
+// with the metadata of their responses.
+//
+// For streaming requests, `meta` is filled when the headers arrive.
+// It is also filled when the request fails with an HTTP response (eg. API errors).
+func ContextWithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaContextKey{}, meta)
+}
+
+// responseMetaFromContext returns the ResponseMeta in `ctx`, or nil if there is none.
+func responseMetaFromContext(ctx context.Context) *ResponseMeta {
+	if meta, ok := ctx.Value(responseMetaContextKey{}).(*ResponseMeta); ok {
+		return meta
+	}
+	return nil
+}
+
+// fillResponseMeta fills the ResponseMeta in `ctx` (if any) with given response and request state.
+func fillResponseMeta(ctx context.Context, resp *http.Response, state *requestState) {
+	meta := responseMetaFromContext(ctx)
+	if meta == nil || resp == nil {
+		return
+	}
+
+	*meta = newResponseMeta(resp, state)
+}
+
+// newResponseMeta returns a new ResponseMeta with given response and request state.
+func newResponseMeta(resp *http.Response, state *requestState) ResponseMeta {
+	meta := ResponseMeta{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		RequestID:  resp.Header.Get(kRequestID),
+		RateLimit: RateLimitState{
+			LimitRequests:     headerInt(resp.Header, kRateLimitLimitReqs),
+			LimitTokens:       headerInt(resp.Header, kRateLimitLimitTokens),
+			RemainingRequests: headerInt(resp.Header, kRateLimitRemainReqs),
+			RemainingTokens:   headerInt(resp.Header, kRateLimitRemainTokens),
+			ResetRequests:     headerDuration(resp.Header, kRateLimitResetReqs),
+			ResetTokens:       headerDuration(resp.Header, kRateLimitResetTokens),
+		},
+		Latency:    time.Since(state.started),
+		Attempts:   state.attempts,
+		Credential: state.pool.credentialName,
+		BaseURL:    state.pool.baseURLString,
+	}
+	if ms, err := strconv.ParseFloat(resp.Header.Get(kProcessingMillis), 64); err == nil {
+		meta.ProcessingTime = time.Duration(ms * float64(time.Millisecond))
+	}
+	return meta
+}
+
+// newCachedResponseMeta returns a new ResponseMeta for a response served from the cache.
+func newCachedResponseMeta(started time.Time) ResponseMeta {
+	return ResponseMeta{
+		StatusCode: http.StatusOK,
+		RateLimit: RateLimitState{
+			LimitRequests:     -1,
+			LimitTokens:       -1,
+			RemainingRequests: -1,
+			RemainingTokens:   -1,
+			ResetRequests:     -1,
+			ResetTokens:       -1,
+		},
+		Latency:  time.Since(started),
+		CacheHit: true,
+	}
+}
+
+// headerInt returns the integer value of given header, or -1 if it is missing or malformed.
+func headerInt(header http.Header, key string) int {
+	if v, err := strconv.Atoi(header.Get(key)); err == nil {
+		return v
+	}
+	return -1
+}
+
+// headerDuration returns the duration value (eg. "1s", "6m0s") of given header, or -1 if it is missing or malformed.
+func headerDuration(header http.Header, key string) time.Duration {
+	if d, err := time.ParseDuration(header.Get(key)); err == nil {
+		return d
+	}
+	return -1
+}
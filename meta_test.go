@@ -0,0 +1,97 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseMetaMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-test")
+		w.Header().Set("Openai-Processing-Ms", "123")
+		w.Header().Set("X-Ratelimit-Limit-Requests", "500")
+		w.Header().Set("X-Ratelimit-Remaining-Requests", "499")
+		w.Header().Set("X-Ratelimit-Reset-Requests", "120ms")
+		w.Header().Set("X-Ratelimit-Limit-Tokens", "30000")
+		w.Header().Set("X-Ratelimit-Remaining-Tokens", "29000")
+		w.Header().Set("X-Ratelimit-Reset-Tokens", "2s")
+
+		if r.URL.Path == "/v1/responses" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"invalid input","type":"invalid_request_error"}}`))
+			return
+		}
+
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil && body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"chatcmpl-test","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+
+	var meta ResponseMeta
+	ctx := ContextWithResponseMeta(context.Background(), &meta)
+	if _, err := client.CreateChatCompletionWithContext(ctx, "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil); err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	}
+	if meta.StatusCode != http.StatusOK ||
+		meta.RequestID != "req-test" ||
+		meta.ProcessingTime != 123*time.Millisecond ||
+		meta.Latency <= 0 ||
+		meta.Attempts != 1 {
+		t.Errorf("unexpected response meta: %+v", meta)
+	}
+	if rl := meta.RateLimit; rl.LimitRequests != 500 ||
+		rl.RemainingRequests != 499 ||
+		rl.ResetRequests != 120*time.Millisecond ||
+		rl.LimitTokens != 30000 ||
+		rl.RemainingTokens != 29000 ||
+		rl.ResetTokens != 2*time.Second {
+		t.Errorf("unexpected rate limit state: %+v", rl)
+	}
+
+	// metadata is filled for API errors too
+	meta = ResponseMeta{}
+	if _, err := client.CreateResponseWithContext(ctx, "test-model", "test", nil); err == nil {
+		t.Errorf("should fail with API error")
+	} else if meta.StatusCode != http.StatusBadRequest || meta.RequestID != "req-test" {
+		t.Errorf("unexpected response meta: %+v", meta)
+	}
+
+	// metadata is filled when headers of a stream arrive
+	meta = ResponseMeta{}
+	done := make(chan struct{})
+	if err := client.CreateChatCompletionStreamWithContext(ctx, "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil, func(_ ChatCompletion, d bool, _ error) {
+		if d {
+			close(done)
+		}
+	}); err != nil {
+		t.Fatalf("failed to create chat completion stream: %s", err)
+	}
+	if meta.StatusCode != http.StatusOK || meta.RequestID != "req-test" {
+		t.Errorf("unexpected response meta: %+v", meta)
+	}
+	<-done
+}
+
+func TestRateLimitStateMissing(t *testing.T) {
+	meta := newResponseMeta(&http.Response{StatusCode: http.StatusOK, Header: http.Header{}}, &requestState{started: time.Now()})
+	if rl := meta.RateLimit; rl.LimitRequests != -1 || rl.RemainingTokens != -1 || rl.ResetRequests != -1 {
+		t.Errorf("missing values should be -1: %+v", rl)
+	}
+}
@@ -0,0 +1,67 @@
+package openai
+
+// types and functions for HTTP middlewares
+
+import (
+	"net/http"
+)
+
+// APIRequest struct for a request passed through middlewares
+type APIRequest struct {
+	// HTTP request to be sent
+	HTTPRequest *http.Request
+
+	// endpoint of the API (eg. "v1/chat/completions")
+	Endpoint string
+
+	// parameters of the request
+	Params map[string]any
+
+	// whether the response will be streamed
+	Stream bool
+}
+
+// Doer interface for sending API requests
+type Doer interface {
+	Do(req *APIRequest) (*http.Response, error)
+}
+
+// DoerFunc type for using ordinary functions as Doer
+type DoerFunc func(req *APIRequest) (*http.Response, error)
+
+// Do calls `f(req)`.
+func (f DoerFunc) Do(req *APIRequest) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware type for wrapping a Doer with cross-cutting logic
+//
+// For streaming requests, the returned response's body is the event stream.
+type Middleware func(next Doer) Doer
+
+// Use appends given middlewares to the client.
+//
+// Middlewares are applied in the given order, so the first one is the outermost.
+func (c *Client) Use(middlewares ...Middleware) *Client {
+	c.middlewares = append(c.middlewares, middlewares...)
+
+	return c
+}
+
+// WithMiddlewares appends given middlewares to the client.
+func WithMiddlewares(middlewares ...Middleware) ClientOption {
+	return func(c *Client) {
+		c.Use(middlewares...)
+	}
+}
+
+// doer returns a Doer which sends requests with the HTTP client through the middlewares.
+func (c *Client) doer() Doer {
+	var d Doer = DoerFunc(func(req *APIRequest) (*http.Response, error) {
+		return c.httpClient.Do(req.HTTPRequest)
+	})
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		d = c.middlewares[i](d)
+	}
+	return d
+}
@@ -0,0 +1,104 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMiddlewaresMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Injected") != "injected" {
+			t.Errorf("header was not injected by middleware")
+		}
+
+		if r.URL.Path == "/v1/chat/completions" {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":"modr-test","model":"test-model","results":[]}`))
+	}))
+	defer server.Close()
+
+	type record struct {
+		endpoint string
+		model    any
+		stream   bool
+		status   int
+	}
+	var mu sync.Mutex
+	records := []record{}
+	order := []string{}
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+	client.Use(
+		func(next Doer) Doer {
+			return DoerFunc(func(req *APIRequest) (*http.Response, error) {
+				order = append(order, "outer")
+
+				resp, err := next.Do(req)
+				if err == nil {
+					mu.Lock()
+					records = append(records, record{
+						endpoint: req.Endpoint,
+						model:    req.Params["model"],
+						stream:   req.Stream,
+						status:   resp.StatusCode,
+					})
+					mu.Unlock()
+				}
+				return resp, err
+			})
+		},
+		func(next Doer) Doer {
+			return DoerFunc(func(req *APIRequest) (*http.Response, error) {
+				order = append(order, "inner")
+
+				req.HTTPRequest.Header.Set("X-Injected", "injected")
+				return next.Do(req)
+			})
+		},
+	)
+
+	if _, err := client.CreateModeration("test", ModerationOptions{}.SetModel("test-model")); err != nil {
+		t.Fatalf("failed to create moderation: %s", err)
+	}
+	if len(order) != 2 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("middlewares were applied in wrong order: %v", order)
+	}
+
+	done := make(chan struct{})
+	if err := client.CreateChatCompletionStreamWithContext(context.Background(), "chat-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil, func(_ ChatCompletion, d bool, _ error) {
+		if d {
+			close(done)
+		}
+	}); err != nil {
+		t.Fatalf("failed to create chat completion stream: %s", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("stream did not finish in time")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+	if r := records[0]; r.endpoint != "v1/moderations" || r.model != "test-model" || r.stream || r.status != http.StatusOK {
+		t.Errorf("unexpected record: %+v", r)
+	}
+	if r := records[1]; r.endpoint != "v1/chat/completions" || r.model != "chat-model" || !r.stream || r.status != http.StatusOK {
+		t.Errorf("unexpected record: %+v", r)
+	}
+}
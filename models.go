@@ -3,6 +3,7 @@ package openai
 // https://platform.openai.com/docs/api-reference/models
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -53,9 +54,18 @@ type ModelDeletionStatus struct {
 // ListModels lists currently available models.
 //
 // https://platform.openai.com/docs/api-reference/models/list
-func (c *Client) ListModels() (response ModelsList, err error) {
+func (c *Client) ListModels(opts ...RequestOption) (response ModelsList, err error) {
+	return c.ListModelsWithContext(context.Background(), opts...)
+}
+
+// ListModelsWithContext lists currently available models with context support.
+//
+// https://platform.openai.com/docs/api-reference/models/list
+func (c *Client) ListModelsWithContext(ctx context.Context, opts ...RequestOption) (response ModelsList, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get("v1/models", nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, "v1/models", nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -63,11 +73,6 @@ func (c *Client) ListModels() (response ModelsList, err error) {
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return ModelsList{}, err
@@ -76,9 +81,18 @@ func (c *Client) ListModels() (response ModelsList, err error) {
 // RetrieveModel retrieves a model instance.
 //
 // https://platform.openai.com/docs/api-reference/models/retrieve
-func (c *Client) RetrieveModel(id string) (response Model, err error) {
+func (c *Client) RetrieveModel(id string, opts ...RequestOption) (response Model, err error) {
+	return c.RetrieveModelWithContext(context.Background(), id, opts...)
+}
+
+// RetrieveModelWithContext retrieves a model instance with context support.
+//
+// https://platform.openai.com/docs/api-reference/models/retrieve
+func (c *Client) RetrieveModelWithContext(ctx context.Context, id string, opts ...RequestOption) (response Model, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/models/%s", id), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/models/%s", id), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -86,11 +100,6 @@ func (c *Client) RetrieveModel(id string) (response Model, err error) {
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Model{}, err
@@ -99,9 +108,18 @@ func (c *Client) RetrieveModel(id string) (response Model, err error) {
 // DeleteFineTuneModel deletes a fine-tuned model.
 //
 // https://platform.openai.com/docs/api-reference/models/delete
-func (c *Client) DeleteFineTuneModel(model string) (response ModelDeletionStatus, err error) {
+func (c *Client) DeleteFineTuneModel(model string, opts ...RequestOption) (response ModelDeletionStatus, err error) {
+	return c.DeleteFineTuneModelWithContext(context.Background(), model, opts...)
+}
+
+// DeleteFineTuneModelWithContext deletes a fine-tuned model with context support.
+//
+// https://platform.openai.com/docs/api-reference/models/delete
+func (c *Client) DeleteFineTuneModelWithContext(ctx context.Context, model string, opts ...RequestOption) (response ModelDeletionStatus, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.delete(fmt.Sprintf("v1/models/%s", model), nil); err == nil {
+	if bytes, err = c.deleteWithContext(ctx, fmt.Sprintf("v1/models/%s", model), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -109,11 +127,6 @@ func (c *Client) DeleteFineTuneModel(model string) (response ModelDeletionStatus
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return ModelDeletionStatus{}, err
@@ -1,8 +1,8 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
-	"fmt"
 )
 
 // https://platform.openai.com/docs/api-reference/moderations
@@ -37,14 +37,23 @@ func (o ModerationOptions) SetModel(model string) ModerationOptions {
 // CreateModeration classifies given text.
 //
 // https://platform.openai.com/docs/api-reference/moderations/create
-func (c *Client) CreateModeration(input any, options ModerationOptions) (response Moderation, err error) {
+func (c *Client) CreateModeration(input any, options ModerationOptions, opts ...RequestOption) (response Moderation, err error) {
+	return c.CreateModerationWithContext(context.Background(), input, options, opts...)
+}
+
+// CreateModerationWithContext classifies given text with context support.
+//
+// https://platform.openai.com/docs/api-reference/moderations/create
+func (c *Client) CreateModerationWithContext(ctx context.Context, input any, options ModerationOptions, opts ...RequestOption) (response Moderation, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ModerationOptions{}
 	}
 	options["input"] = input
 
 	var bytes []byte
-	if bytes, err = c.post("v1/moderations", options); err == nil {
+	if bytes, err = c.cachedPostWithContext(ctx, "v1/moderations", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -52,11 +61,6 @@ func (c *Client) CreateModeration(input any, options ModerationOptions) (respons
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Moderation{}, err
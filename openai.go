@@ -1,8 +1,11 @@
 package openai
 
 import (
+	"crypto/tls"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -20,37 +23,217 @@ const (
 type Client struct {
 	APIKey         string `json:"api_key"`
 	OrganizationID string `json:"organization_id"`
+	ProjectID      string `json:"project_id,omitempty"`
 
 	httpClient *http.Client
 
 	beta    *string
 	baseURL *string
+	azure   *AzureConfig
 
+	headers   http.Header
+	userAgent string
+	timeout   time.Duration
+
+	retryPolicy *RetryPolicy
+	rateLimiter *RateLimiter
+	credentials *CredentialPool
+	cache       Cache
+	cacheTTL    time.Duration
+
+	usageTracker *UsageTracker
+	budgets      []*Budget
+
+	middlewares []Middleware
+	logger      *slog.Logger
+
+	// Deprecated: use `SetLogger` instead.
+	//
+	// If true and there is no logger set, requests are logged to stderr with debug level.
 	Verbose bool
 }
 
+// ClientOption type for configuring a client in `NewClientWithOptions`
+type ClientOption func(c *Client)
+
 // NewClient returns a new API client
 func NewClient(apiKey, organizationID string) *Client {
-	return &Client{
-		APIKey:         apiKey,
-		OrganizationID: organizationID,
+	return NewClientWithOptions(apiKey, WithOrganization(organizationID))
+}
+
+// NewClientWithOptions returns a new API client configured with given options.
+//
+// Options are applied in the given order.
+func NewClientWithOptions(apiKey string, options ...ClientOption) *Client {
+	c := &Client{
+		APIKey: apiKey,
 
 		// for reusing http client
-		httpClient: &http.Client{
-			Transport: &http.Transport{
-				DialContext: (&net.Dialer{
-					Timeout:   DialTimeout,
-					KeepAlive: KeepAlive,
-				}).DialContext,
-				IdleConnTimeout:       IdleConnTimeout,
-				TLSHandshakeTimeout:   TLSHandshakeTimeout,
-				ResponseHeaderTimeout: ResponseHeaderTimeout,
-				ExpectContinueTimeout: ExpectContinueTimeout,
-			},
+		httpClient: newDefaultHTTPClient(),
+	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
+}
+
+// newDefaultHTTPClient returns a new HTTP client with default timeouts.
+func newDefaultHTTPClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   DialTimeout,
+				KeepAlive: KeepAlive,
+			}).DialContext,
+			IdleConnTimeout:       IdleConnTimeout,
+			TLSHandshakeTimeout:   TLSHandshakeTimeout,
+			ResponseHeaderTimeout: ResponseHeaderTimeout,
+			ExpectContinueTimeout: ExpectContinueTimeout,
 		},
 	}
 }
 
+// transport returns a clone of the client's `*http.Transport` which replaces the original one,
+// so that it can be modified safely.
+//
+// If the client's transport is not a `*http.Transport`, nil is returned.
+func (c *Client) transport() *http.Transport {
+	var t *http.Transport
+	switch rt := c.httpClient.Transport.(type) {
+	case nil:
+		t = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		t = rt.Clone()
+	default:
+		return nil
+	}
+	c.httpClient.Transport = t
+
+	return t
+}
+
+// WithOrganization sets the organization ID of the client.
+func WithOrganization(organizationID string) ClientOption {
+	return func(c *Client) {
+		c.OrganizationID = organizationID
+	}
+}
+
+// WithProject sets the project ID of the client, which will be sent as `OpenAI-Project` header.
+func WithProject(projectID string) ClientOption {
+	return func(c *Client) {
+		c.ProjectID = projectID
+	}
+}
+
+// WithBaseURL sets the base URL of the client.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *Client) {
+		c.SetBaseURL(baseURL)
+	}
+}
+
+// WithBetaHeader sets the beta HTTP header of the client.
+func WithBetaHeader(beta string) ClientOption {
+	return func(c *Client) {
+		c.SetBetaHeader(beta)
+	}
+}
+
+// WithHTTPClient sets the HTTP client for sending requests.
+//
+// The given client is copied, so options applied later (eg. `WithProxy`) will not modify it.
+// If it is nil, a new HTTP client with default timeouts is used.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		if httpClient == nil {
+			c.httpClient = newDefaultHTTPClient()
+			return
+		}
+
+		copied := *httpClient
+		c.httpClient = &copied
+	}
+}
+
+// WithTransport sets the `http.RoundTripper` of the client's HTTP client.
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(c *Client) {
+		c.httpClient.Transport = transport
+	}
+}
+
+// WithProxy sets the proxy function of the client's transport.
+//
+// NOTE: applied only when the transport is a `*http.Transport`
+func WithProxy(proxy func(*http.Request) (*url.URL, error)) ClientOption {
+	return func(c *Client) {
+		if t := c.transport(); t != nil {
+			t.Proxy = proxy
+		}
+	}
+}
+
+// WithProxyURL sets the proxy URL of the client's transport.
+//
+// NOTE: applied only when the transport is a `*http.Transport`
+func WithProxyURL(proxyURL *url.URL) ClientOption {
+	return WithProxy(http.ProxyURL(proxyURL))
+}
+
+// WithTLSConfig sets the TLS config of the client's transport.
+//
+// NOTE: applied only when the transport is a `*http.Transport`
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(c *Client) {
+		if t := c.transport(); t != nil {
+			t.TLSClientConfig = config
+		}
+	}
+}
+
+// WithHeader adds a default HTTP header which will be sent with every request.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = http.Header{}
+		}
+		c.headers.Add(key, value)
+	}
+}
+
+// WithUserAgent sets the `User-Agent` header of requests.
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTimeout sets the default timeout of non-streaming requests.
+//
+// It is applied only when the context of a request has no deadline.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the client.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.SetRetryPolicy(policy)
+	}
+}
+
+// WithRateLimiter sets the client-side rate limiter of the client.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(c *Client) {
+		c.SetRateLimiter(limiter)
+	}
+}
+
 // SetBetaHeader sets the beta HTTP header for beta features.
 func (c *Client) SetBetaHeader(beta string) *Client {
 	c.beta = &beta
@@ -0,0 +1,85 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewClientWithOptionsMock(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case headers <- r.Header.Clone():
+		default:
+		}
+
+		if r.URL.Path == "/v1/models/slow-model" {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer server.Close()
+
+	proxied := false
+	httpClient := &http.Client{}
+	client := NewClientWithOptions("test-key",
+		WithOrganization("test-org"),
+		WithProject("test-project"),
+		WithBaseURL(server.URL),
+		WithHTTPClient(httpClient),
+		WithProxy(func(r *http.Request) (*url.URL, error) {
+			proxied = true
+			return nil, nil // no proxy
+		}),
+		WithHeader("X-Custom", "custom-value"),
+		WithUserAgent("test-agent/1.0"),
+		WithTimeout(50*time.Millisecond),
+	)
+
+	if httpClient.Transport != nil {
+		t.Errorf("given http client should not be modified")
+	}
+
+	if _, err := client.ListModels(); err != nil {
+		t.Fatalf("failed to list models: %s", err)
+	}
+
+	if !proxied {
+		t.Errorf("proxy function was not called")
+	}
+
+	h := <-headers
+	for k, v := range map[string]string{
+		"Authorization":       "Bearer test-key",
+		"Openai-Organization": "test-org",
+		"Openai-Project":      "test-project",
+		"X-Custom":            "custom-value",
+		"User-Agent":          "test-agent/1.0",
+	} {
+		if h.Get(k) != v {
+			t.Errorf("expected header %s: %s, got: %s", k, v, h.Get(k))
+		}
+	}
+
+	// default timeout
+	if _, err := client.RetrieveModel("slow-model"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline exceeded, got: %v", err)
+	}
+
+	// nil http client falls back to the default one
+	client = NewClientWithOptions("test-key", WithBaseURL(server.URL), WithHTTPClient(nil))
+	if client.httpClient == nil {
+		t.Fatalf("http client should not be nil")
+	}
+	if _, err := client.ListModels(); err != nil {
+		t.Errorf("failed to list models with the default http client: %s", err)
+	}
+}
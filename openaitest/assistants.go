@@ -0,0 +1,554 @@
+package openaitest
+
+// handlers for assistants, threads, messages, and runs
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// run struct for a run of a thread
+type run struct {
+	object map[string]any
+	reply  Reply
+}
+
+// run statuses which are final
+var runFinalStatuses = map[any]bool{
+	"completed": true,
+	"cancelled": true,
+	"failed":    true,
+	"expired":   true,
+}
+
+// handleAssistants handles `/v1/assistants`.
+func (s *Server) handleAssistants(w http.ResponseWriter, r *http.Request, segments []string, params map[string]any) {
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		assistant := copyObject(params)
+		assistant["id"] = s.newID("asst")
+		assistant["object"] = "assistant"
+		assistant["created_at"] = now()
+		setDefaults(assistant, map[string]any{
+			"tools":    []any{},
+			"file_ids": []any{},
+			"metadata": map[string]any{},
+		})
+
+		s.mu.Lock()
+		s.assistants = append(s.assistants, assistant)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, assistant)
+		return
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		s.mu.Lock()
+		data := []map[string]any{}
+		for _, assistant := range s.assistants {
+			data = append(data, copyObject(assistant))
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, listObject(ordered(r, data)))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := indexOf(s.assistants, segments[0])
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No assistant found with id '%s'.", segments[0]))
+		return
+	}
+	assistant := s.assistants[index]
+	id := assistant["id"]
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, copyObject(assistant))
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		for k, v := range params {
+			assistant[k] = v
+		}
+		writeJSON(w, http.StatusOK, copyObject(assistant))
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		s.assistants = append(s.assistants[:index], s.assistants[index+1:]...)
+		writeJSON(w, http.StatusOK, deletedObject(segments[0], "assistant.deleted"))
+	case len(segments) >= 2 && segments[1] == "files":
+		fileIDs, _ := assistant["file_ids"].([]any)
+		assistantFile := func(fileID any) map[string]any {
+			return map[string]any{
+				"id":           fileID,
+				"object":       "assistant.file",
+				"created_at":   now(),
+				"assistant_id": id,
+			}
+		}
+
+		switch {
+		case len(segments) == 2 && r.Method == http.MethodPost:
+			fileID := stringParam(params, "file_id")
+			assistant["file_ids"] = append(append([]any{}, fileIDs...), fileID)
+			writeJSON(w, http.StatusOK, assistantFile(fileID))
+		case len(segments) == 2 && r.Method == http.MethodGet:
+			data := []map[string]any{}
+			for _, fileID := range fileIDs {
+				data = append(data, assistantFile(fileID))
+			}
+			writeJSON(w, http.StatusOK, listObject(ordered(r, data)))
+		case len(segments) == 3:
+			for i, fileID := range fileIDs {
+				if fileID != segments[2] {
+					continue
+				}
+
+				switch r.Method {
+				case http.MethodGet:
+					writeJSON(w, http.StatusOK, assistantFile(fileID))
+				case http.MethodDelete:
+					assistant["file_ids"] = append(append([]any{}, fileIDs[:i]...), fileIDs[i+1:]...)
+					writeJSON(w, http.StatusOK, deletedObject(segments[2], "assistant.file.deleted"))
+				default:
+					writeNotFound(w, r)
+				}
+				return
+			}
+			writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No file found with id '%s'.", segments[2]))
+		default:
+			writeNotFound(w, r)
+		}
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// handleThreads handles `/v1/threads`.
+func (s *Server) handleThreads(w http.ResponseWriter, r *http.Request, segments []string, params map[string]any) {
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		writeJSON(w, http.StatusOK, s.createThread(params))
+		return
+	case len(segments) == 1 && segments[0] == "runs" && r.Method == http.MethodPost:
+		thread, _ := params["thread"].(map[string]any)
+		created := s.createThread(thread)
+		s.createRun(w, created["id"].(string), params)
+		return
+	case len(segments) >= 2 && segments[1] == "runs":
+		s.handleRuns(w, r, segments[0], segments[2:], params)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := indexOf(s.threads, segments[0])
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No thread found with id '%s'.", segments[0]))
+		return
+	}
+	thread := s.threads[index]
+	threadID := segments[0]
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, copyObject(thread))
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		if metadata, exists := params["metadata"]; exists {
+			thread["metadata"] = metadata
+		}
+		writeJSON(w, http.StatusOK, copyObject(thread))
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		s.threads = append(s.threads[:index], s.threads[index+1:]...)
+		delete(s.messages, threadID)
+		writeJSON(w, http.StatusOK, deletedObject(threadID, "thread.deleted"))
+	case len(segments) == 2 && segments[1] == "messages" && r.Method == http.MethodPost:
+		writeJSON(w, http.StatusOK, s.addMessage(threadID, stringParam(params, "role"), content(params["content"]), params, nil))
+	case len(segments) == 2 && segments[1] == "messages" && r.Method == http.MethodGet:
+		data := []map[string]any{}
+		for _, message := range s.messages[threadID] {
+			data = append(data, copyObject(message))
+		}
+		writeJSON(w, http.StatusOK, listObject(ordered(r, data)))
+	case len(segments) >= 3 && segments[1] == "messages":
+		messages := s.messages[threadID]
+		i := indexOf(messages, segments[2])
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No message found with id '%s'.", segments[2]))
+			return
+		}
+		message := messages[i]
+
+		switch {
+		case len(segments) == 3 && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, copyObject(message))
+		case len(segments) == 3 && r.Method == http.MethodPost:
+			if metadata, exists := params["metadata"]; exists {
+				message["metadata"] = metadata
+			}
+			writeJSON(w, http.StatusOK, copyObject(message))
+		case len(segments) == 4 && segments[3] == "files" && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, listObject([]map[string]any{}))
+		case len(segments) == 5 && segments[3] == "files" && r.Method == http.MethodGet:
+			writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No file found with id '%s'.", segments[4]))
+		default:
+			writeNotFound(w, r)
+		}
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// createThread creates a new thread with given params.
+func (s *Server) createThread(params map[string]any) map[string]any {
+	thread := map[string]any{
+		"id":         s.newID("thread"),
+		"object":     "thread",
+		"created_at": now(),
+		"metadata":   map[string]any{},
+	}
+	if metadata, exists := params["metadata"]; exists {
+		thread["metadata"] = metadata
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.threads = append(s.threads, thread)
+	threadID := thread["id"].(string)
+	s.messages[threadID] = []map[string]any{}
+	if messages, ok := params["messages"].([]any); ok {
+		for _, m := range messages {
+			if message, ok := m.(map[string]any); ok {
+				s.addMessage(threadID, stringParam(message, "role"), content(message["content"]), message, nil)
+			}
+		}
+	}
+
+	return copyObject(thread)
+}
+
+// addMessage adds a message to given thread (`s.mu` should be locked).
+func (s *Server) addMessage(threadID, role, text string, params map[string]any, run *run) map[string]any {
+	if role == "" {
+		role = "user"
+	}
+
+	message := map[string]any{
+		"id":         s.newIDLocked("msg"),
+		"object":     "thread.message",
+		"created_at": now(),
+		"thread_id":  threadID,
+		"role":       role,
+		"content": []map[string]any{
+			{
+				"type": "text",
+				"text": map[string]any{
+					"value":       text,
+					"annotations": []any{},
+				},
+			},
+		},
+		"file_ids": []any{},
+		"metadata": map[string]any{},
+	}
+	if fileIDs, exists := params["file_ids"]; exists {
+		message["file_ids"] = fileIDs
+	}
+	if metadata, exists := params["metadata"]; exists {
+		message["metadata"] = metadata
+	}
+	if run != nil {
+		message["assistant_id"] = run.object["assistant_id"]
+		message["run_id"] = run.object["id"]
+	}
+
+	s.messages[threadID] = append(s.messages[threadID], message)
+
+	return copyObject(message)
+}
+
+// handleRuns handles `/v1/threads/{thread_id}/runs`.
+//
+// Each retrieval of a run advances its status: queued -> in_progress -> requires_action (if the reply has tool calls) or completed.
+func (s *Server) handleRuns(w http.ResponseWriter, r *http.Request, threadID string, segments []string, params map[string]any) {
+	s.mu.Lock()
+	exists := indexOf(s.threads, threadID) >= 0
+	s.mu.Unlock()
+	if !exists {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No thread found with id '%s'.", threadID))
+		return
+	}
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		s.createRun(w, threadID, params)
+		return
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		s.mu.Lock()
+		data := []map[string]any{}
+		for _, run := range s.runs {
+			if run.object["thread_id"] == threadID {
+				data = append(data, copyObject(run.object))
+			}
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, listObject(ordered(r, data)))
+		return
+	}
+
+	s.mu.Lock()
+	var found *run
+	for _, run := range s.runs {
+		if run.object["id"] == segments[0] && run.object["thread_id"] == threadID {
+			found = run
+		}
+	}
+	s.mu.Unlock()
+	if found == nil {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No run found with id '%s'.", segments[0]))
+		return
+	}
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		s.mu.Lock()
+		s.advanceRun(found)
+		object := copyObject(found.object)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, object)
+	case len(segments) == 1 && r.Method == http.MethodPost:
+		s.mu.Lock()
+		if metadata, exists := params["metadata"]; exists {
+			found.object["metadata"] = metadata
+		}
+		object := copyObject(found.object)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, object)
+	case len(segments) == 2 && segments[1] == "submit_tool_outputs" && r.Method == http.MethodPost:
+		s.mu.Lock()
+		status := found.object["status"]
+		s.mu.Unlock()
+		if status != "requires_action" {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("Runs in status \"%s\" do not accept tool outputs.", status))
+			return
+		}
+
+		reply := s.nextReply()
+
+		s.mu.Lock()
+		found.reply = reply
+		found.object["status"] = "queued"
+		delete(found.object, "required_action")
+		s.mu.Unlock()
+
+		s.respondRun(w, found, params)
+	case len(segments) == 2 && segments[1] == "cancel" && r.Method == http.MethodPost:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if runFinalStatuses[found.object["status"]] {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("Cannot cancel run with status '%s'.", found.object["status"]))
+			return
+		}
+		found.object["status"] = "cancelled"
+		found.object["cancelled_at"] = now()
+		delete(found.object, "required_action")
+		writeJSON(w, http.StatusOK, copyObject(found.object))
+	case len(segments) == 2 && segments[1] == "steps" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, listObject([]map[string]any{}))
+	case len(segments) == 3 && segments[1] == "steps" && r.Method == http.MethodGet:
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No run step found with id '%s'.", segments[2]))
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// createRun creates a new run of given thread, and responds with it.
+func (s *Server) createRun(w http.ResponseWriter, threadID string, params map[string]any) {
+	reply := s.nextReply()
+
+	created := &run{
+		object: map[string]any{
+			"id":           s.newID("run"),
+			"object":       "thread.run",
+			"created_at":   now(),
+			"thread_id":    threadID,
+			"assistant_id": stringParam(params, "assistant_id"),
+			"status":       "queued",
+			"expires_at":   now() + 600,
+			"model":        stringParam(params, "model"),
+			"instructions": stringParam(params, "instructions"),
+			"tools":        []any{},
+			"file_ids":     []any{},
+			"metadata":     map[string]any{},
+		},
+		reply: reply,
+	}
+	for _, k := range []string{"tools", "metadata"} {
+		if v, exists := params[k]; exists && v != nil {
+			created.object[k] = v
+		}
+	}
+
+	s.mu.Lock()
+	if index := indexOf(s.assistants, created.object["assistant_id"].(string)); index >= 0 {
+		assistant := s.assistants[index]
+		if created.object["model"] == "" {
+			created.object["model"] = assistant["model"]
+		}
+		if created.object["instructions"] == "" && assistant["instructions"] != nil {
+			created.object["instructions"] = assistant["instructions"]
+		}
+	}
+	s.runs = append(s.runs, created)
+	s.mu.Unlock()
+
+	s.respondRun(w, created, params)
+}
+
+// respondRun responds with given run, or streams its events until it requires action or completes.
+func (s *Server) respondRun(w http.ResponseWriter, run *run, params map[string]any) {
+	if stream, _ := params["stream"].(bool); !stream {
+		s.mu.Lock()
+		object := copyObject(run.object)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, object)
+		return
+	}
+
+	events := s.newEventWriter(w)
+	snapshot := func() map[string]any {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		return copyObject(run.object)
+	}
+
+	object := snapshot()
+	events.write("thread.run.created", object)
+	events.write("thread.run.queued", snapshot())
+
+	s.mu.Lock()
+	s.advanceRun(run)
+	s.mu.Unlock()
+	events.write("thread.run.in_progress", snapshot())
+
+	if len(run.reply.ToolCalls) > 0 {
+		s.mu.Lock()
+		s.advanceRun(run)
+		s.mu.Unlock()
+		events.write("thread.run.requires_action", snapshot())
+	} else {
+		messageID := fmt.Sprintf("msg_%s", strings.TrimPrefix(object["id"].(string), "run_"))
+		events.write("thread.message.created", map[string]any{
+			"id":        messageID,
+			"object":    "thread.message",
+			"thread_id": object["thread_id"],
+			"role":      "assistant",
+			"status":    "in_progress",
+			"content":   []any{},
+		})
+		for _, c := range run.reply.Chunks {
+			events.write("thread.message.delta", map[string]any{
+				"id":     messageID,
+				"object": "thread.message.delta",
+				"delta": map[string]any{
+					"content": []map[string]any{
+						{
+							"index": 0,
+							"type":  "text",
+							"text":  map[string]any{"value": c},
+						},
+					},
+				},
+			})
+		}
+
+		s.mu.Lock()
+		message := s.advanceRun(run)
+		s.mu.Unlock()
+		events.write("thread.message.completed", message)
+		events.write("thread.run.completed", snapshot())
+	}
+
+	events.write("done", "[DONE]")
+}
+
+// advanceRun advances the status of given run, and returns the message of the reply when it is completed (`s.mu` should be locked).
+func (s *Server) advanceRun(run *run) map[string]any {
+	switch run.object["status"] {
+	case "queued":
+		run.object["status"] = "in_progress"
+		run.object["started_at"] = now()
+	case "in_progress":
+		if len(run.reply.ToolCalls) > 0 {
+			calls := []map[string]any{}
+			for _, call := range run.reply.ToolCalls {
+				calls = append(calls, map[string]any{
+					"id":   call.ID,
+					"type": "function",
+					"function": map[string]any{
+						"name":      call.Name,
+						"arguments": call.Arguments,
+					},
+				})
+			}
+			run.object["status"] = "requires_action"
+			run.object["required_action"] = map[string]any{
+				"type": "submit_tool_outputs",
+				"submit_tool_outputs": map[string]any{
+					"tool_calls": calls,
+				},
+			}
+			return nil
+		}
+
+		run.object["status"] = "completed"
+		run.object["completed_at"] = now()
+		run.object["usage"] = chatUsage(run.reply)
+		return s.addMessage(run.object["thread_id"].(string), "assistant", run.reply.Content, nil, run)
+	}
+	return nil
+}
+
+// indexOf returns the index of an object with given id, or -1 if there is none.
+func indexOf(objects []map[string]any, id string) int {
+	for i, object := range objects {
+		if object["id"] == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// setDefaults sets the default values of given object for missing keys.
+func setDefaults(object map[string]any, defaults map[string]any) {
+	for k, v := range defaults {
+		if object[k] == nil {
+			object[k] = v
+		}
+	}
+}
+
+// content returns the text of given message content (string or array of content parts).
+func content(c any) string {
+	switch v := c.(type) {
+	case string:
+		return v
+	case []any:
+		texts := []string{}
+		for _, part := range v {
+			if p, ok := part.(map[string]any); ok {
+				texts = append(texts, stringParam(p, "text"))
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,274 @@
+package openaitest
+
+// handlers for chat completions, completions, and responses
+
+import (
+	"net/http"
+	"strings"
+)
+
+// handleChatCompletions handles `POST /v1/chat/completions`.
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request, params map[string]any) {
+	if r.Method != http.MethodPost {
+		writeNotFound(w, r)
+		return
+	}
+
+	reply := s.nextReply()
+	id := s.newID("chatcmpl")
+	model := stringParam(params, "model")
+	usage := chatUsage(reply)
+
+	if stream, _ := params["stream"].(bool); !stream {
+		message := map[string]any{
+			"role":    "assistant",
+			"content": reply.Content,
+		}
+		if len(reply.ToolCalls) > 0 {
+			calls := []map[string]any{}
+			for _, call := range reply.ToolCalls {
+				calls = append(calls, map[string]any{
+					"id":   call.ID,
+					"type": "function",
+					"function": map[string]any{
+						"name":      call.Name,
+						"arguments": call.Arguments,
+					},
+				})
+			}
+			message["tool_calls"] = calls
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"id":      id,
+			"object":  "chat.completion",
+			"created": now(),
+			"model":   model,
+			"choices": []map[string]any{
+				{
+					"index":         0,
+					"message":       message,
+					"finish_reason": reply.FinishReason,
+				},
+			},
+			"usage": usage,
+		})
+		return
+	}
+
+	events := s.newEventWriter(w)
+	chunk := func(delta map[string]any, finishReason any) map[string]any {
+		return map[string]any{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": now(),
+			"model":   model,
+			"choices": []map[string]any{
+				{
+					"index":         0,
+					"delta":         delta,
+					"finish_reason": finishReason,
+				},
+			},
+		}
+	}
+
+	events.write("", chunk(map[string]any{"role": "assistant", "content": ""}, nil))
+	for _, c := range reply.Chunks {
+		events.write("", chunk(map[string]any{"content": c}, nil))
+	}
+	for i, call := range reply.ToolCalls {
+		events.write("", chunk(map[string]any{
+			"tool_calls": []map[string]any{
+				{
+					"index": i,
+					"id":    call.ID,
+					"type":  "function",
+					"function": map[string]any{
+						"name":      call.Name,
+						"arguments": "",
+					},
+				},
+			},
+		}, nil))
+		events.write("", chunk(map[string]any{
+			"tool_calls": []map[string]any{
+				{
+					"index": i,
+					"function": map[string]any{
+						"arguments": call.Arguments,
+					},
+				},
+			},
+		}, nil))
+	}
+	events.write("", chunk(map[string]any{}, reply.FinishReason))
+
+	if options, _ := params["stream_options"].(map[string]any); options != nil {
+		if include, _ := options["include_usage"].(bool); include {
+			events.write("", map[string]any{
+				"id":      id,
+				"object":  "chat.completion.chunk",
+				"created": now(),
+				"model":   model,
+				"choices": []any{},
+				"usage":   usage,
+			})
+		}
+	}
+	events.write("", "[DONE]")
+}
+
+// handleCompletions handles `POST /v1/completions`.
+func (s *Server) handleCompletions(w http.ResponseWriter, r *http.Request, params map[string]any) {
+	if r.Method != http.MethodPost {
+		writeNotFound(w, r)
+		return
+	}
+
+	reply := s.nextReply()
+	id := s.newID("cmpl")
+	model := stringParam(params, "model")
+
+	completion := func(text string, finishReason any) map[string]any {
+		return map[string]any{
+			"id":      id,
+			"object":  "text_completion",
+			"created": now(),
+			"model":   model,
+			"choices": []map[string]any{
+				{
+					"index":         0,
+					"text":          text,
+					"finish_reason": finishReason,
+				},
+			},
+		}
+	}
+
+	if stream, _ := params["stream"].(bool); !stream {
+		res := completion(reply.Content, reply.FinishReason)
+		res["usage"] = chatUsage(reply)
+		writeJSON(w, http.StatusOK, res)
+		return
+	}
+
+	events := s.newEventWriter(w)
+	for _, c := range reply.Chunks {
+		events.write("", completion(c, nil))
+	}
+	events.write("", completion("", reply.FinishReason))
+	events.write("", "[DONE]")
+}
+
+// handleResponses handles `POST /v1/responses`.
+func (s *Server) handleResponses(w http.ResponseWriter, r *http.Request, params map[string]any) {
+	if r.Method != http.MethodPost {
+		writeNotFound(w, r)
+		return
+	}
+
+	reply := s.nextReply()
+	id := s.newID("resp")
+	messageID := s.newID("msg")
+
+	response := func(status string, output []map[string]any) map[string]any {
+		res := map[string]any{
+			"id":         id,
+			"object":     "response",
+			"created_at": now(),
+			"status":     status,
+			"model":      stringParam(params, "model"),
+			"output":     output,
+		}
+		if status == "completed" {
+			res["usage"] = map[string]any{
+				"input_tokens":  reply.PromptTokens,
+				"output_tokens": reply.CompletionTokens,
+				"total_tokens":  reply.PromptTokens + reply.CompletionTokens,
+			}
+		}
+		return res
+	}
+	message := func(status, text string) map[string]any {
+		content := []map[string]any{}
+		if status == "completed" {
+			content = append(content, map[string]any{
+				"type":        "output_text",
+				"text":        text,
+				"annotations": []any{},
+			})
+		}
+		return map[string]any{
+			"id":      messageID,
+			"type":    "message",
+			"status":  status,
+			"role":    "assistant",
+			"content": content,
+		}
+	}
+	functionCall := func(call ToolCall, status, arguments string) map[string]any {
+		return map[string]any{
+			"id":        "fc_" + strings.TrimPrefix(call.ID, "call_"),
+			"type":      "function_call",
+			"status":    status,
+			"call_id":   call.ID,
+			"name":      call.Name,
+			"arguments": arguments,
+		}
+	}
+
+	output := []map[string]any{}
+	if reply.Content != "" || len(reply.ToolCalls) == 0 {
+		output = append(output, message("completed", reply.Content))
+	}
+	for _, call := range reply.ToolCalls {
+		output = append(output, functionCall(call, "completed", call.Arguments))
+	}
+
+	if stream, _ := params["stream"].(bool); !stream {
+		writeJSON(w, http.StatusOK, response("completed", output))
+		return
+	}
+
+	events := s.newEventWriter(w)
+	write := func(typ string, data map[string]any) {
+		data["type"] = typ
+		events.write(typ, data)
+	}
+
+	write("response.created", map[string]any{"response": response("in_progress", []map[string]any{})})
+	write("response.in_progress", map[string]any{"response": response("in_progress", []map[string]any{})})
+
+	index := 0
+	if reply.Content != "" || len(reply.ToolCalls) == 0 {
+		write("response.output_item.added", map[string]any{"output_index": index, "item": message("in_progress", "")})
+		write("response.content_part.added", map[string]any{"output_index": index, "item_id": messageID, "content_index": 0, "part": map[string]any{"type": "output_text", "text": ""}})
+		for _, c := range reply.Chunks {
+			write("response.output_text.delta", map[string]any{"output_index": index, "item_id": messageID, "content_index": 0, "delta": c})
+		}
+		write("response.output_text.done", map[string]any{"output_index": index, "item_id": messageID, "content_index": 0, "text": reply.Content})
+		write("response.content_part.done", map[string]any{"output_index": index, "item_id": messageID, "content_index": 0, "part": map[string]any{"type": "output_text", "text": reply.Content}})
+		write("response.output_item.done", map[string]any{"output_index": index, "item": message("completed", reply.Content)})
+		index++
+	}
+	for _, call := range reply.ToolCalls {
+		item := functionCall(call, "in_progress", "")
+		write("response.output_item.added", map[string]any{"output_index": index, "item": item})
+		write("response.function_call_arguments.delta", map[string]any{"output_index": index, "item_id": item["id"], "delta": call.Arguments})
+		write("response.function_call_arguments.done", map[string]any{"output_index": index, "item_id": item["id"], "arguments": call.Arguments})
+		write("response.output_item.done", map[string]any{"output_index": index, "item": functionCall(call, "completed", call.Arguments)})
+		index++
+	}
+
+	write("response.completed", map[string]any{"response": response("completed", output)})
+}
+
+// chatUsage returns the usage of given reply for chat completions and completions.
+func chatUsage(reply Reply) map[string]any {
+	return map[string]any{
+		"prompt_tokens":     reply.PromptTokens,
+		"completion_tokens": reply.CompletionTokens,
+		"total_tokens":      reply.PromptTokens + reply.CompletionTokens,
+	}
+}
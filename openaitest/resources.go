@@ -0,0 +1,481 @@
+package openaitest
+
+// handlers for embeddings, moderations, models, images, audio, files, and fine-tuning jobs
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// file struct for an uploaded file
+type file struct {
+	object  map[string]any
+	content []byte
+}
+
+// fineTuningJob struct for a fine-tuning job
+type fineTuningJob struct {
+	object map[string]any
+	events []map[string]any
+}
+
+// fine-tuning job statuses, in order of transition
+var fineTuningJobStatuses = []string{"validating_files", "queued", "running", "succeeded"}
+
+// handleEmbeddings handles `POST /v1/embeddings`.
+func (s *Server) handleEmbeddings(w http.ResponseWriter, r *http.Request, params map[string]any) {
+	if r.Method != http.MethodPost {
+		writeNotFound(w, r)
+		return
+	}
+
+	dimensions := 1536
+	if d, ok := params["dimensions"].(float64); ok && d > 0 {
+		dimensions = int(d)
+	}
+
+	data := []map[string]any{}
+	tokens := 0
+	for i, input := range inputs(params["input"]) {
+		data = append(data, map[string]any{
+			"object":    "embedding",
+			"index":     i,
+			"embedding": embedding(input, dimensions),
+		})
+		tokens += len(strings.Fields(input))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"object": "list",
+		"data":   data,
+		"model":  stringParam(params, "model"),
+		"usage": map[string]any{
+			"prompt_tokens": tokens,
+			"total_tokens":  tokens,
+		},
+	})
+}
+
+// handleModerations handles `POST /v1/moderations`.
+func (s *Server) handleModerations(w http.ResponseWriter, r *http.Request, params map[string]any) {
+	if r.Method != http.MethodPost {
+		writeNotFound(w, r)
+		return
+	}
+
+	results := []map[string]any{}
+	for range inputs(params["input"]) {
+		results = append(results, map[string]any{
+			"flagged": false,
+			"categories": map[string]bool{
+				"harassment": false,
+				"hate":       false,
+				"self-harm":  false,
+				"sexual":     false,
+				"violence":   false,
+			},
+			"category_scores": map[string]float64{
+				"harassment": 0,
+				"hate":       0,
+				"self-harm":  0,
+				"sexual":     0,
+				"violence":   0,
+			},
+		})
+	}
+
+	model := stringParam(params, "model")
+	if model == "" {
+		model = "omni-moderation-latest"
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":      s.newID("modr"),
+		"model":   model,
+		"results": results,
+	})
+}
+
+// handleModels handles `/v1/models`.
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request, segments []string) {
+	model := func(id string) map[string]any {
+		return map[string]any{
+			"id":       id,
+			"object":   "model",
+			"created":  0,
+			"owned_by": "openaitest",
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		data := []map[string]any{}
+		for _, id := range s.models {
+			data = append(data, model(id))
+		}
+		writeJSON(w, http.StatusOK, listObject(data))
+		return
+	case len(segments) == 1:
+		for i, id := range s.models {
+			if id != segments[0] {
+				continue
+			}
+
+			switch r.Method {
+			case http.MethodGet:
+				writeJSON(w, http.StatusOK, model(id))
+			case http.MethodDelete:
+				s.models = append(s.models[:i], s.models[i+1:]...)
+				writeJSON(w, http.StatusOK, deletedObject(id, "model"))
+			default:
+				writeNotFound(w, r)
+			}
+			return
+		}
+		writeError(w, http.StatusNotFound, "invalid_request_error", "model_not_found", fmt.Sprintf("The model '%s' does not exist", segments[0]))
+		return
+	}
+
+	writeNotFound(w, r)
+}
+
+// handleImages handles `POST /v1/images/*`.
+func (s *Server) handleImages(w http.ResponseWriter, r *http.Request, params map[string]any) {
+	if r.Method != http.MethodPost {
+		writeNotFound(w, r)
+		return
+	}
+
+	n, _ := strconv.Atoi(formValue(r, params, "n"))
+	if n <= 0 {
+		n = 1
+	}
+
+	data := []map[string]any{}
+	for i := 0; i < n; i++ {
+		if formValue(r, params, "response_format") == "b64_json" {
+			data = append(data, map[string]any{"b64_json": "iVBORw0KGgo="})
+		} else {
+			data = append(data, map[string]any{"url": fmt.Sprintf("%s/images/%s.png", s.URL, s.newID("img"))})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"created": now(),
+		"data":    data,
+	})
+}
+
+// handleAudio handles `POST /v1/audio/*`.
+func (s *Server) handleAudio(w http.ResponseWriter, r *http.Request, segments []string, params map[string]any) {
+	if r.Method != http.MethodPost || len(segments) != 1 {
+		writeNotFound(w, r)
+		return
+	}
+
+	switch segments[0] {
+	case "speech":
+		if stringParam(params, "input") == "" {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", "'input' is required")
+			return
+		}
+		w.Header().Set("Content-Type", "audio/mpeg")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ID3\x04\x00\x00\x00\x00\x00\x00\xff\xfb\x90\x00"))
+	case "transcriptions", "translations":
+		if _, _, err := r.FormFile("file"); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("'file' is required: %s", err))
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"text": "This is a test transcription.",
+		})
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// handleFiles handles `/v1/files`.
+func (s *Server) handleFiles(w http.ResponseWriter, r *http.Request, segments []string) {
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("'file' is required: %s", err))
+			return
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+
+		uploaded := &file{
+			object: map[string]any{
+				"id":         s.newID("file"),
+				"object":     "file",
+				"bytes":      len(content),
+				"created_at": now(),
+				"filename":   header.Filename,
+				"purpose":    r.FormValue("purpose"),
+			},
+			content: content,
+		}
+
+		s.mu.Lock()
+		s.files = append(s.files, uploaded)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, uploaded.object)
+		return
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		s.mu.Lock()
+		data := []map[string]any{}
+		for _, f := range s.files {
+			data = append(data, f.object)
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, listObject(data))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	index := -1
+	for i, f := range s.files {
+		if f.object["id"] == segments[0] {
+			index = i
+		}
+	}
+	if index < 0 {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("No such File object: %s", segments[0]))
+		return
+	}
+	f := s.files[index]
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, f.object)
+	case len(segments) == 1 && r.Method == http.MethodDelete:
+		s.files = append(s.files[:index], s.files[index+1:]...)
+		writeJSON(w, http.StatusOK, deletedObject(segments[0], "file"))
+	case len(segments) == 2 && segments[1] == "content" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(f.content)
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// handleFineTuningJobs handles `/v1/fine_tuning/jobs`.
+//
+// Each retrieval of a job advances its status: validating_files -> queued -> running -> succeeded.
+func (s *Server) handleFineTuningJobs(w http.ResponseWriter, r *http.Request, segments []string, params map[string]any) {
+	if len(segments) == 0 || segments[0] != "jobs" {
+		writeNotFound(w, r)
+		return
+	}
+	segments = segments[1:]
+
+	switch {
+	case len(segments) == 0 && r.Method == http.MethodPost:
+		id := s.newID("ftjob")
+		job := &fineTuningJob{
+			object: map[string]any{
+				"id":               id,
+				"object":           "fine_tuning.job",
+				"created_at":       now(),
+				"model":            stringParam(params, "model"),
+				"organization_id":  "org-openaitest",
+				"status":           fineTuningJobStatuses[0],
+				"hyperparameters":  map[string]any{"n_epochs": "auto"},
+				"training_file":    stringParam(params, "training_file"),
+				"validation_file":  params["validation_file"],
+				"result_files":     []string{},
+				"trained_tokens":   nil,
+				"fine_tuned_model": nil,
+			},
+		}
+
+		s.mu.Lock()
+		s.addFineTuningJobEvent(job, "Created fine-tuning job")
+		s.fineTuningJobs = append(s.fineTuningJobs, job)
+		object := copyObject(job.object)
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, object)
+		return
+	case len(segments) == 0 && r.Method == http.MethodGet:
+		s.mu.Lock()
+		data := []map[string]any{}
+		for _, job := range s.fineTuningJobs {
+			data = append(data, copyObject(job.object))
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, http.StatusOK, listObject(ordered(r, data)))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var job *fineTuningJob
+	for _, j := range s.fineTuningJobs {
+		if j.object["id"] == segments[0] {
+			job = j
+		}
+	}
+	if job == nil {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("Could not find fine-tune: %s", segments[0]))
+		return
+	}
+
+	switch {
+	case len(segments) == 1 && r.Method == http.MethodGet:
+		s.advanceFineTuningJob(job)
+		writeJSON(w, http.StatusOK, copyObject(job.object))
+	case len(segments) == 2 && segments[1] == "cancel" && r.Method == http.MethodPost:
+		if status := job.object["status"]; status == "succeeded" || status == "failed" || status == "cancelled" {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("Job has already %s", status))
+			return
+		}
+		job.object["status"] = "cancelled"
+		s.addFineTuningJobEvent(job, "Fine-tuning job cancelled")
+		writeJSON(w, http.StatusOK, copyObject(job.object))
+	case len(segments) == 2 && segments[1] == "events" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, listObject(ordered(r, append([]map[string]any{}, job.events...))))
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// advanceFineTuningJob advances the status of given job (`s.mu` should be locked).
+func (s *Server) advanceFineTuningJob(job *fineTuningJob) {
+	for i, status := range fineTuningJobStatuses[:len(fineTuningJobStatuses)-1] {
+		if job.object["status"] != status {
+			continue
+		}
+
+		next := fineTuningJobStatuses[i+1]
+		job.object["status"] = next
+		if next == "succeeded" {
+			job.object["finished_at"] = now()
+			job.object["trained_tokens"] = 1000
+			job.object["fine_tuned_model"] = fmt.Sprintf("ft:%s:openaitest::%s", job.object["model"], job.object["id"])
+			s.models = append(s.models, job.object["fine_tuned_model"].(string))
+		}
+		s.addFineTuningJobEvent(job, fmt.Sprintf("Fine-tuning job status changed to %s", next))
+		return
+	}
+}
+
+// addFineTuningJobEvent adds an event to given job (`s.mu` should be locked).
+func (s *Server) addFineTuningJobEvent(job *fineTuningJob, message string) {
+	job.events = append(job.events, map[string]any{
+		"id":         s.newIDLocked("ftevent"),
+		"object":     "fine_tuning.job.event",
+		"created_at": now(),
+		"level":      "info",
+		"message":    message,
+		"type":       "message",
+	})
+}
+
+// inputs returns the inputs (string or array of strings) in given param.
+func inputs(input any) []string {
+	switch v := input.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		strs := []string{}
+		for _, e := range v {
+			strs = append(strs, fmt.Sprint(e))
+		}
+		return strs
+	}
+	return []string{}
+}
+
+// embedding returns a deterministic embedding of given input.
+func embedding(input string, dimensions int) []float64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(input))
+	seed := hash.Sum64()
+
+	vector := make([]float64, dimensions)
+	for i := range vector {
+		// xorshift
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		vector[i] = float64(seed%2000)/1000 - 1
+	}
+	return vector
+}
+
+// formValue returns the value of given key from a multipart form or JSON params.
+func formValue(r *http.Request, params map[string]any, key string) string {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		return r.FormValue(key)
+	}
+	if v, exists := params[key]; exists && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// listObject returns a list object with given data.
+func listObject(data []map[string]any) map[string]any {
+	list := map[string]any{
+		"object":   "list",
+		"data":     data,
+		"has_more": false,
+	}
+	if len(data) > 0 {
+		list["first_id"] = data[0]["id"]
+		list["last_id"] = data[len(data)-1]["id"]
+	}
+	return list
+}
+
+// ordered returns given data (in creation order) ordered and limited with the query parameters of given request.
+//
+// Data are ordered in descending order by default.
+func ordered(r *http.Request, data []map[string]any) []map[string]any {
+	query := r.URL.Query()
+
+	if query.Get("order") != "asc" {
+		for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+	if limit, err := strconv.Atoi(query.Get("limit")); err == nil && limit > 0 && limit < len(data) {
+		data = data[:limit]
+	}
+	return data
+}
+
+// deletedObject returns a deletion status object.
+func deletedObject(id, object string) map[string]any {
+	return map[string]any{
+		"id":      id,
+		"object":  object,
+		"deleted": true,
+	}
+}
+
+// copyObject returns a shallow copy of given object.
+func copyObject(object map[string]any) map[string]any {
+	copied := make(map[string]any, len(object))
+	for k, v := range object {
+		copied[k] = v
+	}
+	return copied
+}
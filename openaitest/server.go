@@ -0,0 +1,449 @@
+// Package openaitest provides an in-process fake OpenAI API server for testing.
+//
+// It implements the endpoints covered by `github.com/meinside/openai-go` with scriptable behaviors:
+// canned replies (including tool calls), streaming chunks with delays, injected errors,
+// and in-memory states of files, assistants, threads, runs, and fine-tuning jobs.
+//
+//	server := openaitest.NewServer()
+//	defer server.Close()
+//
+//	server.Reply(openaitest.Reply{Content: "Hello!"})
+//
+//	client := openai.NewClient("test-key", "test-org")
+//	client.SetBaseURL(server.URL)
+package openaitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultReply is the reply which is used when no reply is queued.
+var DefaultReply = Reply{
+	Content: "This is a test reply.",
+}
+
+// Reply struct for a scripted reply of chat completions, responses, completions, and runs
+type Reply struct {
+	// content of the reply
+	Content string
+
+	// tool calls of the reply (a reply with tool calls makes a run require action)
+	ToolCalls []ToolCall
+
+	// finish reason (defaults to "tool_calls" if there are tool calls, "stop" otherwise)
+	FinishReason string
+
+	// chunks of `Content` for streaming (defaults to `Content` split into words)
+	Chunks []string
+
+	// token usage (estimated from the number of words if zero)
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// ToolCall struct for a tool call in a scripted reply
+type ToolCall struct {
+	// id of the tool call (generated if empty)
+	ID string
+
+	// name of the function
+	Name string
+
+	// arguments of the function (JSON)
+	Arguments string
+}
+
+// Error struct for an injected error
+type Error struct {
+	// path prefix of requests to fail (eg. "chat/completions", empty for all requests)
+	Path string
+
+	// number of requests to fail (1 if zero, -1 for all requests)
+	Count int
+
+	// HTTP status code (eg. 429, 500)
+	StatusCode int
+
+	// type, code, and message of the error
+	Type    string
+	Code    string
+	Message string
+
+	// value of `Retry-After-Ms` header (not set if zero)
+	RetryAfter time.Duration
+}
+
+// Request struct for a request received by the server
+type Request struct {
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the body of the request as JSON into `v`.
+func (r Request) JSON(v any) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Server is a fake OpenAI API server.
+type Server struct {
+	*httptest.Server
+
+	mu sync.Mutex
+
+	replies    []Reply
+	errors     []Error
+	chunkDelay time.Duration
+	requests   []Request
+	ids        map[string]int
+
+	models         []string
+	files          []*file
+	assistants     []map[string]any
+	threads        []map[string]any
+	messages       map[string][]map[string]any // by thread id
+	runs           []*run
+	fineTuningJobs []*fineTuningJob
+}
+
+// NewServer starts and returns a new fake server.
+//
+// It should be closed with `Close` after use.
+func NewServer() *Server {
+	s := &Server{
+		ids:      map[string]int{},
+		models:   []string{"gpt-4o", "gpt-4o-mini", "text-embedding-3-small", "whisper-1", "dall-e-3"},
+		messages: map[string][]map[string]any{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+
+	return s
+}
+
+// Reply queues given replies, which are used in order by chat completions, responses, completions, and runs.
+func (s *Server) Reply(replies ...Reply) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.replies = append(s.replies, replies...)
+
+	return s
+}
+
+// InjectError queues given error, which fails matching requests before they are handled.
+func (s *Server) InjectError(e Error) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.Count == 0 {
+		e.Count = 1
+	}
+	if e.StatusCode == 0 {
+		e.StatusCode = http.StatusInternalServerError
+	}
+	s.errors = append(s.errors, e)
+
+	return s
+}
+
+// SetChunkDelay sets the delay between chunks of streams.
+func (s *Server) SetChunkDelay(delay time.Duration) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chunkDelay = delay
+
+	return s
+}
+
+// Requests returns the requests received by the server.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request{}, s.requests...)
+}
+
+// serveHTTP records given request, fails it with an injected error, or routes it to its handler.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1"), "/")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	injected := s.popError(path)
+	s.mu.Unlock()
+
+	if injected != nil {
+		if injected.RetryAfter > 0 {
+			w.Header().Set("Retry-After-Ms", strconv.FormatInt(injected.RetryAfter.Milliseconds(), 10))
+		}
+		writeError(w, injected.StatusCode, injected.Type, injected.Code, injected.Message)
+		return
+	}
+
+	w.Header().Set("X-Request-Id", fmt.Sprintf("req_%d", len(s.Requests())))
+
+	s.route(w, r, path, body)
+}
+
+// popError returns an injected error for given path, or nil if there is none.
+func (s *Server) popError(path string) *Error {
+	for i, e := range s.errors {
+		if !strings.HasPrefix(path, e.Path) {
+			continue
+		}
+
+		injected := e
+		if e.Count > 0 {
+			if e.Count--; e.Count == 0 {
+				s.errors = append(s.errors[:i], s.errors[i+1:]...)
+			} else {
+				s.errors[i] = e
+			}
+		}
+		if injected.Type == "" {
+			injected.Type = errorTypeForStatus(injected.StatusCode)
+		}
+		if injected.Message == "" {
+			injected.Message = http.StatusText(injected.StatusCode)
+		}
+		return &injected
+	}
+	return nil
+}
+
+// route routes given request to its handler.
+func (s *Server) route(w http.ResponseWriter, r *http.Request, path string, body []byte) {
+	segments := strings.Split(path, "/")
+
+	var params map[string]any
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") && len(body) > 0 {
+		if err := json.Unmarshal(body, &params); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request_error", "", fmt.Sprintf("failed to parse request body: %s", err))
+			return
+		}
+	}
+	if params == nil {
+		params = map[string]any{}
+	}
+
+	switch segments[0] {
+	case "chat":
+		s.handleChatCompletions(w, r, params)
+	case "completions":
+		s.handleCompletions(w, r, params)
+	case "responses":
+		s.handleResponses(w, r, params)
+	case "embeddings":
+		s.handleEmbeddings(w, r, params)
+	case "moderations":
+		s.handleModerations(w, r, params)
+	case "models":
+		s.handleModels(w, r, segments[1:])
+	case "images":
+		s.handleImages(w, r, params)
+	case "audio":
+		s.handleAudio(w, r, segments[1:], params)
+	case "files":
+		s.handleFiles(w, r, segments[1:])
+	case "assistants":
+		s.handleAssistants(w, r, segments[1:], params)
+	case "threads":
+		s.handleThreads(w, r, segments[1:], params)
+	case "fine_tuning":
+		s.handleFineTuningJobs(w, r, segments[1:], params)
+	default:
+		writeNotFound(w, r)
+	}
+}
+
+// nextReply pops and returns the next queued reply, or `DefaultReply` if there is none.
+func (s *Server) nextReply() Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply := DefaultReply
+	if len(s.replies) > 0 {
+		reply = s.replies[0]
+		s.replies = s.replies[1:]
+	}
+
+	reply.ToolCalls = append([]ToolCall{}, reply.ToolCalls...)
+	for i, call := range reply.ToolCalls {
+		if call.ID == "" {
+			reply.ToolCalls[i].ID = s.newIDLocked("call")
+		}
+		if call.Arguments == "" {
+			reply.ToolCalls[i].Arguments = "{}"
+		}
+	}
+	if reply.FinishReason == "" {
+		if len(reply.ToolCalls) > 0 {
+			reply.FinishReason = "tool_calls"
+		} else {
+			reply.FinishReason = "stop"
+		}
+	}
+	if reply.Chunks == nil {
+		reply.Chunks = splitWords(reply.Content)
+	}
+	if reply.PromptTokens == 0 {
+		reply.PromptTokens = 10
+	}
+	if reply.CompletionTokens == 0 {
+		reply.CompletionTokens = len(strings.Fields(reply.Content)) + len(reply.ToolCalls)
+	}
+
+	return reply
+}
+
+// newID returns a new id with given prefix.
+func (s *Server) newID(prefix string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.newIDLocked(prefix)
+}
+
+// newIDLocked returns a new id with given prefix (`s.mu` should be locked).
+func (s *Server) newIDLocked(prefix string) string {
+	s.ids[prefix]++
+	return fmt.Sprintf("%s_test%d", prefix, s.ids[prefix])
+}
+
+// sleepChunkDelay sleeps for the chunk delay.
+func (s *Server) sleepChunkDelay() {
+	s.mu.Lock()
+	delay := s.chunkDelay
+	s.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// splitWords splits given text into chunks of words (with their trailing spaces).
+func splitWords(text string) []string {
+	chunks := []string{}
+	for len(text) > 0 {
+		i := strings.Index(text, " ")
+		if i < 0 {
+			chunks = append(chunks, text)
+			break
+		}
+		chunks = append(chunks, text[:i+1])
+		text = text[i+1:]
+	}
+	return chunks
+}
+
+// errorTypeForStatus returns the error type for given status code.
+func errorTypeForStatus(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_exceeded"
+	case status == http.StatusUnauthorized:
+		return "invalid_api_key"
+	case status >= 500:
+		return "server_error"
+	default:
+		return "invalid_request_error"
+	}
+}
+
+// writeJSON writes given value as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error response.
+func writeError(w http.ResponseWriter, status int, typ, code, message string) {
+	e := map[string]any{
+		"message": message,
+		"type":    typ,
+	}
+	if code != "" {
+		e["code"] = code
+	}
+	writeJSON(w, status, map[string]any{"error": e})
+}
+
+// writeNotFound writes a not found error response for given request.
+func writeNotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("Invalid URL (%s %s)", r.Method, r.URL.Path))
+}
+
+// eventWriter struct for writing server-sent events
+type eventWriter struct {
+	s *Server
+	w http.ResponseWriter
+
+	started bool
+}
+
+// newEventWriter returns a new eventWriter and writes the headers of a stream.
+func (s *Server) newEventWriter(w http.ResponseWriter) *eventWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	return &eventWriter{s: s, w: w}
+}
+
+// write writes an event with given name (omitted if empty) and data (encoded as JSON unless it is a string).
+func (e *eventWriter) write(event string, data any) {
+	if e.started {
+		e.s.sleepChunkDelay()
+	}
+	e.started = true
+
+	var encoded string
+	if str, ok := data.(string); ok {
+		encoded = str
+	} else {
+		bs, _ := json.Marshal(data)
+		encoded = string(bs)
+	}
+
+	if event != "" {
+		fmt.Fprintf(e.w, "event: %s\n", event)
+	}
+	fmt.Fprintf(e.w, "data: %s\n\n", encoded)
+
+	if flusher, ok := e.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// stringParam returns the string value of given key in params.
+func stringParam(params map[string]any, key string) string {
+	if v, ok := params[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// now returns the current unix time.
+func now() int64 {
+	return time.Now().Unix()
+}
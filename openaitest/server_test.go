@@ -0,0 +1,355 @@
+package openaitest
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/meinside/openai-go"
+)
+
+func newClient(server *Server, options ...openai.ClientOption) *openai.Client {
+	client := openai.NewClientWithOptions("test-key", options...)
+	client.SetBaseURL(server.URL)
+	return client
+}
+
+func TestChatCompletion(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := newClient(server)
+
+	// default reply
+	if completion, err := client.CreateChatCompletion("gpt-4o", []openai.ChatMessage{openai.NewChatUserMessage("Hello!")}, nil); err != nil {
+		t.Errorf("failed to create chat completion: %s", err)
+	} else if content, _ := completion.Choices[0].Message.ContentString(); content != DefaultReply.Content {
+		t.Errorf("unexpected content: %s", content)
+	}
+
+	// tool calls, and then a reply
+	server.Reply(Reply{
+		ToolCalls: []ToolCall{{Name: "get_weather", Arguments: `{"city":"Seoul"}`}},
+	}, Reply{
+		Content: "It is sunny in Seoul.",
+	})
+
+	completion, err := client.CreateChatCompletion("gpt-4o", []openai.ChatMessage{openai.NewChatUserMessage("How is the weather in Seoul?")}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	}
+	calls := completion.Choices[0].Message.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "get_weather" || calls[0].ID == "" || completion.Choices[0].FinishReason != "tool_calls" {
+		t.Fatalf("unexpected tool calls: %+v", completion.Choices[0])
+	}
+
+	done := make(chan struct{})
+	var streamed strings.Builder
+	if err := client.CreateChatCompletionStreamWithContext(context.Background(), "gpt-4o", []openai.ChatMessage{
+		openai.NewChatUserMessage("How is the weather in Seoul?"),
+		openai.NewChatToolMessage(calls[0].ID, "sunny"),
+	}, nil, func(c openai.ChatCompletion, d bool, err error) {
+		if err != nil {
+			t.Errorf("failed to stream chat completion: %s", err)
+		}
+		if !d && len(c.Choices) > 0 {
+			content, _ := c.Choices[0].Delta.ContentString()
+			streamed.WriteString(content)
+		}
+		if d {
+			close(done)
+		}
+	}); err != nil {
+		t.Fatalf("failed to create chat completion stream: %s", err)
+	}
+	<-done
+	if streamed.String() != "It is sunny in Seoul." {
+		t.Errorf("unexpected streamed content: %s", streamed.String())
+	}
+
+	if requests := server.Requests(); len(requests) != 3 || requests[2].Path != "/v1/chat/completions" {
+		t.Errorf("unexpected requests: %+v", requests)
+	}
+}
+
+func TestChatCompletionStreamToolCalls(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.Reply(Reply{
+		ToolCalls: []ToolCall{
+			{Name: "first", Arguments: `{"a":1}`},
+			{Name: "second", Arguments: `{"b":2}`},
+		},
+	})
+	server.SetChunkDelay(time.Millisecond)
+
+	client := newClient(server)
+
+	done := make(chan openai.ChatCompletion, 1)
+	if err := client.CreateChatCompletionStreamWithContext(context.Background(), "gpt-4o", []openai.ChatMessage{openai.NewChatUserMessage("Call tools.")}, nil, func(c openai.ChatCompletion, d bool, err error) {
+		if err != nil {
+			t.Errorf("failed to stream chat completion: %s", err)
+		}
+		if d {
+			done <- c
+		}
+	}); err != nil {
+		t.Fatalf("failed to create chat completion stream: %s", err)
+	}
+
+	last := <-done
+	if len(last.Choices) == 0 || len(last.Choices[0].Message.ToolCalls) != 2 {
+		t.Fatalf("unexpected last chunk: %+v", last)
+	}
+	if calls := last.Choices[0].Message.ToolCalls; calls[0].Function.Name != "first" || calls[0].Function.Arguments != `{"a":1}` ||
+		calls[1].Function.Name != "second" || calls[1].Function.Arguments != `{"b":2}` {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+}
+
+func TestResponses(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.Reply(Reply{Content: "Hello there."}, Reply{Content: "Streamed reply."})
+
+	client := newClient(server)
+
+	if response, err := client.CreateResponse("gpt-4o", "Hello!", nil); err != nil {
+		t.Errorf("failed to create response: %s", err)
+	} else if len(response.Output) != 1 || response.Output[0].Content[0].Text != "Hello there." || response.Usage == nil {
+		t.Errorf("unexpected response: %+v", response)
+	}
+
+	done := make(chan struct{})
+	var streamed strings.Builder
+	var completed *openai.Response
+	if err := client.CreateResponseStreamWithContext(context.Background(), "gpt-4o", "Hello!", nil, func(e openai.ResponseStreamEvent, d bool, err error) {
+		if err != nil {
+			t.Errorf("failed to stream response: %s", err)
+		}
+		if e.Type == "response.output_text.delta" && e.Delta != nil {
+			streamed.WriteString(*e.Delta)
+		}
+		if d {
+			completed = e.Response
+			close(done)
+		}
+	}); err != nil {
+		t.Fatalf("failed to create response stream: %s", err)
+	}
+	<-done
+	if streamed.String() != "Streamed reply." || completed == nil || completed.Status != "completed" {
+		t.Errorf("unexpected streamed response: %s, %+v", streamed.String(), completed)
+	}
+}
+
+func TestInjectedErrors(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.InjectError(Error{Path: "chat/completions", StatusCode: http.StatusTooManyRequests, Count: 2, RetryAfter: time.Millisecond})
+
+	// retried
+	client := newClient(server, openai.WithRetryPolicy(openai.RetryPolicy{
+		MaxAttempts:          3,
+		BaseDelay:            time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests},
+		AutoIdempotencyKey:   true,
+	}))
+	if _, err := client.CreateChatCompletion("gpt-4o", []openai.ChatMessage{openai.NewChatUserMessage("Hello!")}, nil); err != nil {
+		t.Errorf("should succeed after retries: %s", err)
+	}
+	if requests := server.Requests(); len(requests) != 3 {
+		t.Errorf("unexpected number of requests: %d", len(requests))
+	}
+
+	// not retried
+	server.InjectError(Error{StatusCode: http.StatusInternalServerError, Message: "boom"})
+	client = newClient(server)
+	if _, err := client.ListModels(); err == nil {
+		t.Errorf("should fail with injected error")
+	} else if apiErr := openai.AsAPIError(err); apiErr == nil || apiErr.StatusCode != http.StatusInternalServerError || apiErr.Message != "boom" {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if _, err := client.ListModels(); err != nil {
+		t.Errorf("injected error should be consumed: %s", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := newClient(server)
+
+	uploaded, err := client.UploadFile(openai.NewFileParamFromBytesWithFilename([]byte(`{"prompt":"a","completion":"b"}`+"\n"), "train.jsonl", ""), "fine-tune")
+	if err != nil {
+		t.Fatalf("failed to upload file: %s", err)
+	}
+	if uploaded.Filename != "train.jsonl" || uploaded.Purpose != "fine-tune" {
+		t.Errorf("unexpected uploaded file: %+v", uploaded)
+	}
+
+	if files, err := client.ListFiles(); err != nil || len(files.Data) != 1 {
+		t.Errorf("unexpected files: %+v, %v", files, err)
+	}
+	if content, err := client.RetrieveFileContent(uploaded.ID); err != nil || !strings.Contains(string(content), `"prompt":"a"`) {
+		t.Errorf("unexpected file content: %s, %v", content, err)
+	}
+
+	// fine-tuning job advances on each retrieval
+	job, err := client.CreateFineTuningJob(uploaded.ID, "gpt-4o-mini", nil)
+	if err != nil {
+		t.Fatalf("failed to create fine-tuning job: %s", err)
+	}
+	for i := 0; i < 3; i++ {
+		if job, err = client.RetrieveFineTuningJob(job.ID); err != nil {
+			t.Fatalf("failed to retrieve fine-tuning job: %s", err)
+		}
+	}
+	if job.Status != openai.FineTuningJobStatusSucceeded || job.FineTunedModel == nil {
+		t.Errorf("unexpected fine-tuning job: %+v", job)
+	}
+	if events, err := client.ListFineTuningJobEvents(job.ID, nil); err != nil || len(events.Data) != 4 {
+		t.Errorf("unexpected fine-tuning job events: %+v, %v", events, err)
+	}
+
+	if deleted, err := client.DeleteFile(uploaded.ID); err != nil || !deleted.Deleted {
+		t.Errorf("failed to delete file: %+v, %v", deleted, err)
+	}
+	if _, err := client.RetrieveFileContent(uploaded.ID); err == nil {
+		t.Errorf("deleted file should not be found")
+	}
+}
+
+func TestRuns(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	server.Reply(Reply{
+		ToolCalls: []ToolCall{{Name: "lookup", Arguments: `{"q":"go"}`}},
+	}, Reply{
+		Content: "Go is a programming language.",
+	})
+
+	client := newClient(server)
+
+	assistant, err := client.CreateAssistant("gpt-4o", nil)
+	if err != nil {
+		t.Fatalf("failed to create assistant: %s", err)
+	}
+	thread, err := client.CreateThread(nil)
+	if err != nil {
+		t.Fatalf("failed to create thread: %s", err)
+	}
+	if _, err := client.CreateMessage(thread.ID, "user", "What is Go?", nil); err != nil {
+		t.Fatalf("failed to create message: %s", err)
+	}
+
+	run, err := client.CreateRun(thread.ID, assistant.ID, nil)
+	if err != nil {
+		t.Fatalf("failed to create run: %s", err)
+	}
+	if run.Status != openai.RunStatusQueued || run.Model != "gpt-4o" {
+		t.Errorf("unexpected run: %+v", run)
+	}
+
+	// queued -> in_progress -> requires_action
+	for run.Status != openai.RunStatusRequiresAction {
+		if run, err = client.RetrieveRun(thread.ID, run.ID); err != nil {
+			t.Fatalf("failed to retrieve run: %s", err)
+		}
+	}
+	calls := run.RequiredAction.SubmitToolOutputs.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "lookup" {
+		t.Fatalf("unexpected required action: %+v", run.RequiredAction)
+	}
+
+	output := "Go is made by Google."
+	if run, err = client.SubmitToolOutputs(thread.ID, run.ID, []openai.ToolOutput{{ToolCallID: &calls[0].ID, Output: &output}}); err != nil {
+		t.Fatalf("failed to submit tool outputs: %s", err)
+	}
+
+	// queued -> in_progress -> completed
+	for run.Status != openai.RunStatusCompleted {
+		if run, err = client.RetrieveRun(thread.ID, run.ID); err != nil {
+			t.Fatalf("failed to retrieve run: %s", err)
+		}
+	}
+
+	messages, err := client.ListMessages(thread.ID, nil)
+	if err != nil {
+		t.Fatalf("failed to list messages: %s", err)
+	}
+	if len(messages.Data) != 2 || messages.Data[0].Role != "assistant" || messages.Data[0].Content[0].Text.Value != "Go is a programming language." {
+		t.Errorf("unexpected messages: %+v", messages.Data)
+	}
+
+	// stream
+	server.Reply(Reply{Content: "Streamed run."})
+	done := make(chan struct{})
+	var streamed strings.Builder
+	var lastEvent string
+	if err := client.CreateRunStream(thread.ID, assistant.ID, nil, func(e openai.ServerSentEvent, d bool, err error) {
+		if err != nil {
+			t.Errorf("failed to stream run: %s", err)
+		}
+		if e.Event == "thread.message.delta" {
+			var delta struct {
+				Delta struct {
+					Content []struct {
+						Text struct {
+							Value string `json:"value"`
+						} `json:"text"`
+					} `json:"content"`
+				} `json:"delta"`
+			}
+			if err := e.Decode(&delta); err == nil && len(delta.Delta.Content) > 0 {
+				streamed.WriteString(delta.Delta.Content[0].Text.Value)
+			}
+		}
+		if e.Event != "" {
+			lastEvent = e.Event
+		}
+		if d {
+			close(done)
+		}
+	}); err != nil {
+		t.Fatalf("failed to create run stream: %s", err)
+	}
+	<-done
+	if streamed.String() != "Streamed run." || lastEvent != "thread.run.completed" {
+		t.Errorf("unexpected run stream: %s (last event: %s)", streamed.String(), lastEvent)
+	}
+}
+
+func TestEmbeddingsAndModels(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	client := newClient(server)
+
+	embeddings, err := client.CreateEmbedding("text-embedding-3-small", []string{"hello", "world"}, openai.EmbeddingOptions{"dimensions": 8})
+	if err != nil {
+		t.Fatalf("failed to create embeddings: %s", err)
+	}
+	if len(embeddings.Data) != 2 || len(embeddings.Data[0].Embedding) != 8 {
+		t.Errorf("unexpected embeddings: %+v", embeddings)
+	}
+
+	again, _ := client.CreateEmbedding("text-embedding-3-small", "hello", openai.EmbeddingOptions{"dimensions": 8})
+	for i, v := range again.Data[0].Embedding {
+		if v != embeddings.Data[0].Embedding[i] {
+			t.Errorf("embeddings should be deterministic")
+			break
+		}
+	}
+
+	if models, err := client.ListModels(); err != nil || len(models.Data) == 0 {
+		t.Errorf("unexpected models: %+v, %v", models, err)
+	}
+}
@@ -0,0 +1,198 @@
+package openai
+
+// types and functions for per-request options
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// RequestOption type for overriding the client's configuration for a request
+//
+// Request options can be passed to API functions, or attached to a context with `ContextWithRequestOptions`.
+// They do not mutate the client, so they are safe to use with a client shared across goroutines.
+type RequestOption func(o *requestOptions)
+
+// requestOptions struct for the options of a request
+type requestOptions struct {
+	headers        http.Header
+	beta           *string
+	idempotencyKey string
+	timeout        time.Duration
+
+	apiKey         *string
+	organizationID *string
+	projectID      *string
+
+	extraBody  map[string]any
+	extraQuery map[string]string
+}
+
+// WithRequestHeader adds a HTTP header to the request.
+func WithRequestHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = http.Header{}
+		}
+		o.headers.Add(key, value)
+	}
+}
+
+// WithRequestBetaHeader sets the beta HTTP header (`OpenAI-Beta`) of the request.
+func WithRequestBetaHeader(beta string) RequestOption {
+	return func(o *requestOptions) {
+		o.beta = &beta
+	}
+}
+
+// WithRequestIdempotencyKey sets the idempotency key of the request (see `ContextWithIdempotencyKey`).
+func WithRequestIdempotencyKey(key string) RequestOption {
+	return func(o *requestOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithRequestTimeout sets the timeout of the request, including its retries.
+//
+// For streaming requests, it also limits the time for receiving the whole stream.
+func WithRequestTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithRequestAPIKey sets the API key of the request.
+//
+// It takes precedence over the credentials in the client's CredentialPool.
+func WithRequestAPIKey(apiKey string) RequestOption {
+	return func(o *requestOptions) {
+		o.apiKey = &apiKey
+	}
+}
+
+// WithRequestOrganization sets the organization id of the request.
+func WithRequestOrganization(organizationID string) RequestOption {
+	return func(o *requestOptions) {
+		o.organizationID = &organizationID
+	}
+}
+
+// WithRequestProject sets the project id of the request.
+func WithRequestProject(projectID string) RequestOption {
+	return func(o *requestOptions) {
+		o.projectID = &projectID
+	}
+}
+
+// WithExtraBody adds a field to the body of the request (or to the query string of GET/DELETE requests),
+// for parameters which are not supported by this library yet.
+//
+// It overwrites the param with the same key.
+func WithExtraBody(key string, value any) RequestOption {
+	return func(o *requestOptions) {
+		if o.extraBody == nil {
+			o.extraBody = map[string]any{}
+		}
+		o.extraBody[key] = value
+	}
+}
+
+// WithExtraQuery adds a query parameter to the URL of the request.
+func WithExtraQuery(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.extraQuery == nil {
+			o.extraQuery = map[string]string{}
+		}
+		o.extraQuery[key] = value
+	}
+}
+
+type requestOptionsContextKey struct{}
+
+// ContextWithRequestOptions returns a copy of `ctx` which makes requests with it apply given options,
+// in addition to the options already attached to `ctx`.
+func ContextWithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	return withRequestOptions(ctx, opts)
+}
+
+// withRequestOptions attaches given options to `ctx` (merged with the ones in `ctx`).
+func withRequestOptions(ctx context.Context, opts []RequestOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+
+	options := requestOptionsFromContext(ctx).clone()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.idempotencyKey != "" {
+		ctx = ContextWithIdempotencyKey(ctx, options.idempotencyKey)
+	}
+	return context.WithValue(ctx, requestOptionsContextKey{}, options)
+}
+
+// requestOptionsFromContext returns the request options in `ctx`.
+func requestOptionsFromContext(ctx context.Context) requestOptions {
+	if options, ok := ctx.Value(requestOptionsContextKey{}).(requestOptions); ok {
+		return options
+	}
+	return requestOptions{}
+}
+
+// clone returns a copy of the options, which can be modified without affecting the original.
+func (o requestOptions) clone() requestOptions {
+	cloned := o
+	cloned.headers = o.headers.Clone()
+	if o.extraBody != nil {
+		cloned.extraBody = make(map[string]any, len(o.extraBody))
+		for k, v := range o.extraBody {
+			cloned.extraBody[k] = v
+		}
+	}
+	if o.extraQuery != nil {
+		cloned.extraQuery = make(map[string]string, len(o.extraQuery))
+		for k, v := range o.extraQuery {
+			cloned.extraQuery[k] = v
+		}
+	}
+	return cloned
+}
+
+// params returns given params with the extra body fields (a copy if there are any).
+func (o requestOptions) params(params map[string]any) map[string]any {
+	if len(o.extraBody) == 0 {
+		return params
+	}
+
+	merged := make(map[string]any, len(params)+len(o.extraBody))
+	for k, v := range params {
+		merged[k] = v
+	}
+	for k, v := range o.extraBody {
+		merged[k] = v
+	}
+	return merged
+}
+
+// credential returns given credential overridden with the options.
+func (o requestOptions) credential(credential Credential) Credential {
+	if o.apiKey != nil {
+		credential.APIKey = *o.apiKey
+	}
+	if o.organizationID != nil {
+		credential.OrganizationID = *o.organizationID
+	}
+	if o.projectID != nil {
+		credential.ProjectID = *o.projectID
+	}
+	return credential
+}
+
+// withTimeout returns a copy of `ctx` with the timeout of the options (if any).
+func (o requestOptions) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, o.timeout)
+}
@@ -0,0 +1,135 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestOptionsMock(t *testing.T) {
+	var mu sync.Mutex
+	var lastHeader http.Header
+	var lastQuery map[string][]string
+	var lastBody map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		mu.Lock()
+		lastHeader, lastQuery, lastBody = r.Header.Clone(), r.URL.Query(), body
+		mu.Unlock()
+
+		if r.URL.Query().Get("slow") == "true" {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/v1/models" {
+			w.Write([]byte(`{"object":"list","data":[]}`))
+			return
+		}
+		w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]}],"usage":{"prompt_tokens":1,"total_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	last := func() (http.Header, map[string][]string, map[string]any) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return lastHeader, lastQuery, lastBody
+	}
+
+	client := NewClientWithOptions("client-key",
+		WithOrganization("client-org"),
+		WithBaseURL(server.URL),
+		WithHeader("X-Client", "client"),
+		WithBetaHeader("assistants=v1"),
+	)
+
+	// overrides
+	if _, err := client.CreateEmbedding("text-embedding-3-small", "hello", nil,
+		WithRequestHeader("X-Client", "request"),
+		WithRequestHeader("X-Request", "1"),
+		WithRequestBetaHeader("assistants=v2"),
+		WithRequestIdempotencyKey("idempotency-1"),
+		WithRequestAPIKey("request-key"),
+		WithRequestOrganization("request-org"),
+		WithRequestProject("request-project"),
+		WithExtraBody("dimensions", 8),
+		WithExtraBody("model", "text-embedding-3-large"),
+		WithExtraQuery("api-version", "2024-01-01"),
+	); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	}
+	header, query, body := last()
+	for key, expected := range map[string]string{
+		"Authorization":       "Bearer request-key",
+		"Openai-Organization": "request-org",
+		"Openai-Project":      "request-project",
+		"Openai-Beta":         "assistants=v2",
+		"Idempotency-Key":     "idempotency-1",
+		"X-Client":            "request",
+		"X-Request":           "1",
+	} {
+		if got := header.Get(key); got != expected {
+			t.Errorf("expected header %s = %s, got %s", key, expected, got)
+		}
+	}
+	if got := query["api-version"]; len(got) != 1 || got[0] != "2024-01-01" {
+		t.Errorf("unexpected query: %v", query)
+	}
+	if body["dimensions"] != float64(8) || body["model"] != "text-embedding-3-large" || body["input"] != "hello" {
+		t.Errorf("unexpected body: %v", body)
+	}
+
+	// the client is not mutated
+	if _, err := client.ListModels(); err != nil {
+		t.Fatalf("failed to list models: %s", err)
+	}
+	header, query, _ = last()
+	if header.Get("Authorization") != "Bearer client-key" ||
+		header.Get("Openai-Organization") != "client-org" ||
+		header.Get("Openai-Beta") != "assistants=v1" ||
+		header.Get("X-Client") != "client" ||
+		header.Get("X-Request") != "" ||
+		header.Get("Idempotency-Key") != "" ||
+		len(query) != 0 {
+		t.Errorf("request options should not affect other requests: %v, %v", header, query)
+	}
+
+	// options in context, merged with the ones of the call
+	ctx := ContextWithRequestOptions(context.Background(), WithRequestAPIKey("context-key"), WithExtraQuery("limit", "1"))
+	if _, err := client.ListModelsWithContext(ctx, WithRequestOrganization("call-org")); err != nil {
+		t.Fatalf("failed to list models: %s", err)
+	}
+	header, query, _ = last()
+	if header.Get("Authorization") != "Bearer context-key" || header.Get("Openai-Organization") != "call-org" || query["limit"][0] != "1" {
+		t.Errorf("unexpected request: %v, %v", header, query)
+	}
+
+	// extra body of GET requests are sent as query parameters
+	if _, err := client.ListModels(WithExtraBody("after", "model-1")); err != nil {
+		t.Fatalf("failed to list models: %s", err)
+	}
+	if _, query, _ = last(); query["after"][0] != "model-1" {
+		t.Errorf("unexpected query: %v", query)
+	}
+
+	// timeout
+	started := time.Now()
+	if _, err := client.ListModels(WithExtraQuery("slow", "true"), WithRequestTimeout(50*time.Millisecond)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("request should time out early: %s", elapsed)
+	}
+}
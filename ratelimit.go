@@ -0,0 +1,335 @@
+package openai
+
+// types and functions for client-side rate limiting
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	kRateLimitLimitReqs   = "X-Ratelimit-Limit-Requests"
+	kRateLimitLimitTokens = "X-Ratelimit-Limit-Tokens"
+)
+
+// RateLimit struct for requests-per-minute and tokens-per-minute budgets
+//
+// Zero values mean no limit.
+type RateLimit struct {
+	RequestsPerMinute int
+	TokensPerMinute   int
+}
+
+// RateLimiter struct for limiting requests and tokens per model (or per endpoint) with token buckets
+//
+// When the budget is exhausted, requests wait until it is refilled (or the context is done).
+type RateLimiter struct {
+	// limit for the keys without their own limits
+	DefaultLimit RateLimit
+
+	// KeyFunc returns the key of budgets for a request (nil for the model name, or the endpoint if there is no model)
+	KeyFunc func(endpoint string, params map[string]any) string
+
+	// EstimateTokens estimates the number of tokens a request will consume (nil for the default estimation)
+	EstimateTokens func(params map[string]any) int
+
+	// if true, limits are not learned from `x-ratelimit-*` response headers
+	DisableLearning bool
+
+	mu      sync.Mutex
+	limits  map[string]RateLimit
+	buckets map[string]*rateLimitBuckets
+}
+
+// NewRateLimiter returns a new RateLimiter with given default limit.
+func NewRateLimiter(defaultLimit RateLimit) *RateLimiter {
+	return &RateLimiter{
+		DefaultLimit: defaultLimit,
+
+		limits:  map[string]RateLimit{},
+		buckets: map[string]*rateLimitBuckets{},
+	}
+}
+
+// SetLimit sets the limit for given key (model name or endpoint).
+//
+// Limits learned from response headers may lower it, but never raise it.
+func (l *RateLimiter) SetLimit(key string, limit RateLimit) *RateLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.limits[key] = limit
+	delete(l.buckets, key)
+
+	return l
+}
+
+// SetRateLimiter sets the client-side rate limiter of the client.
+func (c *Client) SetRateLimiter(limiter *RateLimiter) *Client {
+	c.rateLimiter = limiter
+
+	return c
+}
+
+// rateLimitBuckets struct for the buckets of a key
+type rateLimitBuckets struct {
+	requests *tokenBucket
+	tokens   *tokenBucket
+}
+
+// tokenBucket struct which is refilled to its capacity in a minute
+type tokenBucket struct {
+	capacity  float64
+	available float64
+	last      time.Time
+
+	configured float64 // capacity set with `SetLimit` or `DefaultLimit` (0 if learned)
+}
+
+// newTokenBucket returns a new full bucket with given capacity, or nil if there is no limit.
+func newTokenBucket(capacity int, now time.Time) *tokenBucket {
+	if capacity <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity:  float64(capacity),
+		available: float64(capacity),
+		last:      now,
+	}
+}
+
+// newConfiguredTokenBucket returns a new full bucket with given configured capacity, or nil if there is no limit.
+//
+// Limits learned from response headers do not raise the capacity of it.
+func newConfiguredTokenBucket(capacity int, now time.Time) *tokenBucket {
+	b := newTokenBucket(capacity, now)
+	if b != nil {
+		b.configured = b.capacity
+	}
+	return b
+}
+
+// refill refills the bucket for the time passed.
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed > 0 {
+		b.available = math.Min(b.capacity, b.available+b.capacity*elapsed.Minutes())
+		b.last = now
+	}
+}
+
+// waitFor returns how long it takes until `n` can be taken from the bucket.
+//
+// `n` is capped to the capacity, so that requests larger than the capacity can proceed with a full bucket.
+func (b *tokenBucket) waitFor(n float64) time.Duration {
+	n = math.Min(n, b.capacity)
+	if b.available >= n {
+		return 0
+	}
+	return time.Duration((n - b.available) / b.capacity * float64(time.Minute))
+}
+
+// rateLimitReservation struct for budgets taken for a request
+type rateLimitReservation struct {
+	key    string
+	tokens int
+}
+
+// key returns the key of budgets for a request.
+func (l *RateLimiter) key(endpoint string, params map[string]any) string {
+	if l.KeyFunc != nil {
+		return l.KeyFunc(endpoint, params)
+	}
+	if model, ok := params["model"].(string); ok && model != "" {
+		return model
+	}
+	return endpoint
+}
+
+// bucketsFor returns the buckets for given key, creating them if needed.
+//
+// NOTE: `l.mu` should be locked by the caller
+func (l *RateLimiter) bucketsFor(key string, now time.Time) *rateLimitBuckets {
+	if l.buckets == nil {
+		l.buckets = map[string]*rateLimitBuckets{}
+	}
+
+	b, exists := l.buckets[key]
+	if !exists {
+		limit, exists := l.limits[key]
+		if !exists {
+			limit = l.DefaultLimit
+		}
+		b = &rateLimitBuckets{
+			requests: newConfiguredTokenBucket(limit.RequestsPerMinute, now),
+			tokens:   newConfiguredTokenBucket(limit.TokensPerMinute, now),
+		}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// wait waits until the budgets for given request are available, and takes them.
+func (l *RateLimiter) wait(ctx context.Context, endpoint string, params map[string]any) (*rateLimitReservation, error) {
+	key := l.key(endpoint, params)
+
+	var tokens int
+	if l.EstimateTokens != nil {
+		tokens = l.EstimateTokens(params)
+	} else {
+		tokens = estimateTokens(params)
+	}
+
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		b := l.bucketsFor(key, now)
+
+		var wait time.Duration
+		if b.requests != nil {
+			b.requests.refill(now)
+			wait = b.requests.waitFor(1)
+		}
+		if b.tokens != nil {
+			b.tokens.refill(now)
+			if d := b.tokens.waitFor(float64(tokens)); d > wait {
+				wait = d
+			}
+		}
+
+		if wait <= 0 {
+			if b.requests != nil {
+				b.requests.available--
+			}
+			if b.tokens != nil {
+				b.tokens.available -= float64(tokens)
+			}
+			l.mu.Unlock()
+
+			return &rateLimitReservation{key: key, tokens: tokens}, nil
+		}
+		l.mu.Unlock()
+
+		if err := sleepWithContext(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// reconcile adjusts the tokens budget with the actual number of tokens used.
+//
+// Negative `actual` means the usage is unknown, and the estimation is kept.
+func (l *RateLimiter) reconcile(r *rateLimitReservation, actual int) {
+	if r == nil || actual < 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, exists := l.buckets[r.key]; exists && b.tokens != nil {
+		b.tokens.available = math.Min(b.tokens.capacity, b.tokens.available+float64(r.tokens-actual))
+	}
+	r.tokens = actual
+}
+
+// observe learns limits and remaining budgets from given response headers.
+func (l *RateLimiter) observe(r *rateLimitReservation, header http.Header) {
+	if r == nil || l.DisableLearning {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b := l.bucketsFor(r.key, now)
+	b.requests = learnBucket(b.requests, header.Get(kRateLimitLimitReqs), header.Get(kRateLimitRemainReqs), now)
+	b.tokens = learnBucket(b.tokens, header.Get(kRateLimitLimitTokens), header.Get(kRateLimitRemainTokens), now)
+}
+
+// learnBucket updates (or creates) a bucket with given limit and remaining header values.
+//
+// Learned limits only lower the capacity of a configured bucket, so that limits set lower on purpose are kept.
+func learnBucket(b *tokenBucket, limit, remaining string, now time.Time) *tokenBucket {
+	if l, err := strconv.Atoi(limit); err == nil && l > 0 {
+		if b == nil {
+			b = newTokenBucket(l, now)
+		} else {
+			b.refill(now)
+			b.capacity = float64(l)
+			if b.configured > 0 {
+				b.capacity = math.Min(b.capacity, b.configured)
+			}
+			b.available = math.Min(b.available, b.capacity)
+		}
+	}
+	if r, err := strconv.Atoi(remaining); err == nil && b != nil {
+		b.refill(now)
+		b.available = math.Min(b.available, float64(r))
+	}
+	return b
+}
+
+// estimateTokens roughly estimates the number of tokens a request will consume:
+// about 4 characters per token for the prompt, plus the maximum number of tokens to generate.
+func estimateTokens(params map[string]any) int {
+	chars := 0
+	for _, k := range []string{"messages", "input", "prompt", "instructions"} {
+		if v, exists := params[k]; exists {
+			if bs, err := json.Marshal(v); err == nil {
+				chars += len(bs)
+			}
+		}
+	}
+	tokens := (chars + 3) / 4
+
+	for _, k := range []string{"max_tokens", "max_completion_tokens", "max_output_tokens"} {
+		if v := intParam(params, k); v > 0 {
+			tokens += v
+		}
+	}
+
+	return tokens
+}
+
+// usageTokens returns the total number of tokens in the `usage` of given response bytes, or -1 if there is none.
+func usageTokens(response []byte) int {
+	var res struct {
+		Usage *struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(response, &res); err == nil && res.Usage != nil {
+		return res.Usage.TotalTokens
+	}
+	return -1
+}
+
+// streamUsageTokens returns the total number of tokens in the usage of given stream event, or -1 if there is none.
+func streamUsageTokens(event any) int {
+	switch e := event.(type) {
+	case ChatCompletion:
+		if e.Usage.TotalTokens > 0 {
+			return e.Usage.TotalTokens
+		}
+	case ResponseStreamEvent:
+		if e.Response != nil && e.Response.Usage != nil {
+			return e.Response.Usage.TotalTokens
+		}
+	case Completion:
+		if e.Usage.TotalTokens > 0 {
+			return e.Usage.TotalTokens
+		}
+	case ServerSentEvent:
+		if e.Data != "" {
+			return usageTokens([]byte(e.Data))
+		}
+	}
+	return -1
+}
@@ -0,0 +1,162 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-ratelimit-limit-tokens", "1000")
+		w.Header().Set("x-ratelimit-remaining-tokens", "900")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"object":"list","data":[{"object":"embedding","embedding":[0.1],"index":0}],"model":"test-model","usage":{"prompt_tokens":5,"total_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(RateLimit{}).
+		SetLimit("test-model", RateLimit{RequestsPerMinute: 1})
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+	client.SetRateLimiter(limiter)
+
+	if _, err := client.CreateEmbedding("test-model", "Hello!", nil); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	}
+
+	// limits are learned from response headers, and tokens are reconciled with the usage
+	limiter.mu.Lock()
+	b := limiter.buckets["test-model"]
+	if b.tokens == nil || b.tokens.capacity != 1000 || b.tokens.available > 900 {
+		t.Errorf("tokens limit was not learned: %+v", b.tokens)
+	}
+	limiter.mu.Unlock()
+
+	// the next request should wait for the requests budget
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := client.CreateChatCompletionWithContext(ctx, "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context deadline exceeded, got: %v", err)
+	}
+
+	// other models have their own budgets
+	if _, err := client.CreateEmbedding("other-model", "Hello!", nil); err != nil {
+		t.Errorf("failed to create embedding with other model: %s", err)
+	}
+}
+
+func TestRateLimiterDoMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[]}`))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(RateLimit{}).
+		SetLimit("test-model", RateLimit{TokensPerMinute: 1000})
+	limiter.DisableLearning = true
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+	client.SetRateLimiter(limiter)
+
+	// max tokens in bodies of `Do` are decoded as json.Number
+	if err := client.Do(context.Background(), http.MethodPost, "/v1/chat/completions", map[string]any{
+		"model":      "test-model",
+		"messages":   []ChatMessage{NewChatUserMessage("Hello!")},
+		"max_tokens": 600,
+	}, nil); err != nil {
+		t.Fatalf("failed to send request: %s", err)
+	}
+
+	limiter.mu.Lock()
+	b := limiter.buckets["test-model"]
+	if b.tokens == nil || b.tokens.available > 400 {
+		t.Errorf("max tokens should be taken from the budget: %+v", b.tokens)
+	}
+	limiter.mu.Unlock()
+
+	for _, v := range []any{600, int64(600), float64(600), json.Number("600")} {
+		if tokens := estimateTokens(map[string]any{"max_tokens": v}); tokens != 600 {
+			t.Errorf("expected 600 tokens for %T, got %d", v, tokens)
+		}
+	}
+}
+
+func TestRateLimiterLearningMock(t *testing.T) {
+	advertised := "10000"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("x-ratelimit-limit-tokens", advertised)
+		w.Write([]byte(`{"object":"list","data":[{"object":"embedding","embedding":[0.1],"index":0}],"model":"test-model","usage":{"prompt_tokens":5,"total_tokens":5}}`))
+	}))
+	defer server.Close()
+
+	limiter := NewRateLimiter(RateLimit{}).
+		SetLimit("test-model", RateLimit{TokensPerMinute: 100})
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+	client.SetRateLimiter(limiter)
+
+	capacity := func() float64 {
+		limiter.mu.Lock()
+		defer limiter.mu.Unlock()
+		return limiter.buckets["test-model"].tokens.capacity
+	}
+
+	// higher limits of the server do not raise the configured one
+	if _, err := client.CreateEmbedding("test-model", "Hello!", nil); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	}
+	if c := capacity(); c != 100 {
+		t.Errorf("configured limit should be kept, got %f", c)
+	}
+
+	// lower limits of the server are applied
+	advertised = "50"
+	if _, err := client.CreateEmbedding("test-model", "Hello!", nil); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	}
+	if c := capacity(); c != 50 {
+		t.Errorf("lower limit of the server should be applied, got %f", c)
+	}
+
+	// limits which were not configured are learned as they are
+	if _, err := client.CreateEmbedding("other-model", "Hello!", nil); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	}
+	limiter.mu.Lock()
+	if b := limiter.buckets["other-model"]; b.tokens == nil || b.tokens.capacity != 50 {
+		t.Errorf("limit should be learned: %+v", b.tokens)
+	}
+	limiter.mu.Unlock()
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(60, now)
+
+	b.available -= 60
+	if d := b.waitFor(1); d != time.Second {
+		t.Errorf("expected 1s, got %s", d)
+	}
+
+	b.refill(now.Add(500 * time.Millisecond))
+	if b.available != 0.5 {
+		t.Errorf("expected 0.5 tokens, got %f", b.available)
+	}
+
+	// requests larger than the capacity wait for a full bucket
+	b.refill(now.Add(2 * time.Minute))
+	if d := b.waitFor(100); d != 0 {
+		t.Errorf("expected no wait, got %s", d)
+	}
+}
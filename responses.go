@@ -53,6 +53,7 @@ type ResponseOutput struct {
 type OutputContent struct {
 	Type        string       `json:"type"`
 	Text        string       `json:"text,omitempty"`
+	Refusal     string       `json:"refusal,omitempty"` // when type == 'refusal'
 	Annotations []Annotation `json:"annotations,omitempty"`
 }
 
@@ -97,6 +98,7 @@ type ResponseTool struct {
 	Name        string                 `json:"name,omitempty"`
 	Description string                 `json:"description,omitempty"`
 	Parameters  ToolFunctionParameters `json:"parameters,omitempty"`
+	Strict      *bool                  `json:"strict,omitempty"`
 }
 
 // Tool choice constants
@@ -203,6 +205,14 @@ func (o ResponseOptions) SetToolChoiceFunction(functionName string) ResponseOpti
 	return o
 }
 
+// SetTextFormat sets the format of the text parameter (eg. for structured outputs)
+func (o ResponseOptions) SetTextFormat(format ResponseTextFormat) ResponseOptions {
+	o["text"] = map[string]any{
+		"format": format,
+	}
+	return o
+}
+
 // SetParallelToolCalls sets the parallel_tool_calls parameter
 func (o ResponseOptions) SetParallelToolCalls(parallel bool) ResponseOptions {
 	o["parallel_tool_calls"] = parallel
@@ -238,36 +248,14 @@ type ResponseStreamEvent struct {
 }
 
 // CreateResponse creates a response using the OpenAI Responses API
-func (c *Client) CreateResponse(model string, input any, options ResponseOptions) (response Response, err error) {
-	if options == nil {
-		options = ResponseOptions{}
-	}
-	options["model"] = model
-	options["input"] = input
-
-	if options["stream"] != nil {
-		cb := options["stream"].(responseCallback)
-		options["stream"] = true
-		_, err := c.postCBResponses("v1/responses", options, cb)
-		return Response{}, err
-	}
-
-	var bytes []byte
-	if bytes, err = c.post("v1/responses", options); err == nil {
-		if err = json.Unmarshal(bytes, &response); err == nil {
-			if response.Error == nil {
-				return response, nil
-			} else {
-				err = response.Error.err()
-			}
-		}
-	}
-
-	return Response{}, err
+func (c *Client) CreateResponse(model string, input any, options ResponseOptions, opts ...RequestOption) (response Response, err error) {
+	return c.CreateResponseWithContext(context.Background(), model, input, options, opts...)
 }
 
 // CreateResponseWithContext creates a response with context support
-func (c *Client) CreateResponseWithContext(ctx context.Context, model string, input any, options ResponseOptions) (response Response, err error) {
+func (c *Client) CreateResponseWithContext(ctx context.Context, model string, input any, options ResponseOptions, opts ...RequestOption) (response Response, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ResponseOptions{}
 	}
@@ -282,7 +270,7 @@ func (c *Client) CreateResponseWithContext(ctx context.Context, model string, in
 	}
 
 	var bytes []byte
-	if bytes, err = c.postWithContext(ctx, "v1/responses", options); err == nil {
+	if bytes, err = c.cachedPostWithContext(ctx, "v1/responses", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -296,20 +284,14 @@ func (c *Client) CreateResponseWithContext(ctx context.Context, model string, in
 }
 
 // CreateResponseStream creates a streaming response
-func (c *Client) CreateResponseStream(model string, input any, options ResponseOptions, cb responseCallback) (err error) {
-	if options == nil {
-		options = ResponseOptions{}
-	}
-	options["model"] = model
-	options["input"] = input
-	options["stream"] = true
-
-	_, err = c.postCBResponses("v1/responses", options, cb)
-	return err
+func (c *Client) CreateResponseStream(model string, input any, options ResponseOptions, cb responseCallback, opts ...RequestOption) (err error) {
+	return c.CreateResponseStreamWithContext(context.Background(), model, input, options, cb, opts...)
 }
 
 // CreateResponseStreamWithContext creates a streaming response with context support
-func (c *Client) CreateResponseStreamWithContext(ctx context.Context, model string, input any, options ResponseOptions, cb responseCallback) (err error) {
+func (c *Client) CreateResponseStreamWithContext(ctx context.Context, model string, input any, options ResponseOptions, cb responseCallback, opts ...RequestOption) (err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ResponseOptions{}
 	}
@@ -360,6 +342,12 @@ func NewResponseTool(name, description string, parameters ToolFunctionParameters
 	}
 }
 
+// SetStrict sets whether the model should follow the schema of the parameters exactly (strict function calling)
+func (t ResponseTool) SetStrict(strict bool) ResponseTool {
+	t.Strict = &strict
+	return t
+}
+
 // ArgumentsParsed returns the parsed arguments from a function call ResponseOutput
 func (r ResponseOutput) ArgumentsParsed() (result map[string]any, err error) {
 	if r.Type == "function_call" && r.Arguments != "" {
@@ -621,7 +621,7 @@ func TestResponsesRealWithTools(t *testing.T) {
 			functionCalled = true
 			log.Printf("Search call with status: %s", output.Status)
 		} else if output.Type == "message" {
-			if len(output.Content) > 0 && len(output.Content) > 0 {
+			if len(output.Content) > 0 {
 				log.Printf("Final response text: %s", output.Content[0].Text)
 				log.Printf("Annotations: %v", output.Content[0].Annotations)
 			}
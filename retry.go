@@ -0,0 +1,255 @@
+package openai
+
+// types and functions for retrying requests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	kIdempotencyKey = "Idempotency-Key"
+
+	kRetryAfter            = "Retry-After"
+	kRetryAfterMillis      = "Retry-After-Ms"
+	kRateLimitResetReqs    = "X-Ratelimit-Reset-Requests"
+	kRateLimitResetTokens  = "X-Ratelimit-Reset-Tokens"
+	kRateLimitRemainReqs   = "X-Ratelimit-Remaining-Requests"
+	kRateLimitRemainTokens = "X-Ratelimit-Remaining-Tokens"
+)
+
+// RetryPolicy struct for retrying requests on transient failures
+type RetryPolicy struct {
+	// maximum number of attempts, including the first one
+	MaxAttempts int
+
+	// delay before the first retry, doubled on each following retry
+	BaseDelay time.Duration
+
+	// upper bound of exponential delays (delays from response headers are not capped)
+	MaxDelay time.Duration
+
+	// fraction (0.0 ~ 1.0) of each exponential delay to be randomized
+	Jitter float64
+
+	// HTTP status codes which can be retried
+	RetryableStatusCodes []int
+
+	// IsRetryableError decides if a network error can be retried (nil for the default)
+	IsRetryableError func(err error) bool
+
+	// if true, a random idempotency key is attached to POST/PATCH requests without one, so that they can be retried
+	AutoIdempotencyKey bool
+}
+
+// DefaultRetryPolicy returns a RetryPolicy with default values.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    8 * time.Second,
+		Jitter:      0.25,
+		RetryableStatusCodes: []int{
+			http.StatusRequestTimeout,
+			http.StatusTooManyRequests,
+			http.StatusInternalServerError,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// SetRetryPolicy sets the retry policy of the client.
+//
+// Non-idempotent (POST, PATCH) requests are retried only when an idempotency key is attached to them.
+func (c *Client) SetRetryPolicy(policy RetryPolicy) *Client {
+	c.retryPolicy = &policy
+
+	return c
+}
+
+type idempotencyKeyContextKey struct{}
+
+// ContextWithIdempotencyKey returns a copy of `ctx` with given idempotency key,
+// which will be sent as `Idempotency-Key` header and makes POST requests retryable.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// idempotencyKeyFromContext returns the idempotency key in `ctx`.
+func idempotencyKeyFromContext(ctx context.Context) string {
+	if key, ok := ctx.Value(idempotencyKeyContextKey{}).(string); ok {
+		return key
+	}
+	return ""
+}
+
+// withIdempotencyKey attaches a random idempotency key to `ctx` if needed by the retry policy.
+func (c *Client) withIdempotencyKey(ctx context.Context, method string) context.Context {
+	if c.retryPolicy != nil &&
+		c.retryPolicy.AutoIdempotencyKey &&
+		!isIdempotentMethod(method) &&
+		idempotencyKeyFromContext(ctx) == "" {
+		bs := make([]byte, 16)
+		if _, err := rand.Read(bs); err == nil {
+			return ContextWithIdempotencyKey(ctx, fmt.Sprintf("openai-go-retry-%x", bs))
+		}
+	}
+	return ctx
+}
+
+// isRetryableRequest checks if a request with given method can be retried.
+func isRetryableRequest(ctx context.Context, method string) bool {
+	return isIdempotentMethod(method) || idempotencyKeyFromContext(ctx) != ""
+}
+
+// isIdempotentMethod checks if given HTTP method is idempotent.
+func isIdempotentMethod(method string) bool {
+	return method != http.MethodPost && method != http.MethodPatch
+}
+
+// maxAttempts returns the maximum number of attempts.
+func (p *RetryPolicy) maxAttempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// isRetryableStatus checks if given HTTP status code can be retried.
+func (p *RetryPolicy) isRetryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryableError checks if given error can be retried.
+func (p *RetryPolicy) isRetryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if p.IsRetryableError != nil {
+		return p.IsRetryableError(err)
+	}
+	return isTransientNetworkError(err)
+}
+
+// isTransientNetworkError checks if given error is a transient network error.
+//
+// Unknown hosts and failed verifications of TLS certificates are not transient, as they will fail again.
+func isTransientNetworkError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	var (
+		verificationErr *tls.CertificateVerificationError
+		authorityErr    x509.UnknownAuthorityError
+		invalidErr      x509.CertificateInvalidError
+		hostnameErr     x509.HostnameError
+	)
+	if errors.As(err, &verificationErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &hostnameErr) {
+		return false
+	}
+	if errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr)
+}
+
+// delay returns the delay before the next attempt.
+func (p *RetryPolicy) delay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d := delayFromHeaders(resp); d > 0 {
+			return d
+		}
+	}
+
+	d := float64(p.BaseDelay) * math.Pow(2, float64(attempt-1))
+	if p.MaxDelay > 0 && d > float64(p.MaxDelay) {
+		d = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		d -= d * math.Min(p.Jitter, 1) * mathrand.Float64()
+	}
+	return time.Duration(d)
+}
+
+// delayFromHeaders returns the delay suggested by the headers of given response.
+func delayFromHeaders(resp *http.Response) time.Duration {
+	if ms, err := strconv.ParseFloat(resp.Header.Get(kRetryAfterMillis), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	if ra := resp.Header.Get(kRetryAfter); ra != "" {
+		if secs, err := strconv.ParseFloat(ra, 64); err == nil && secs > 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+		if t, err := http.ParseTime(ra); err == nil {
+			if d := time.Until(t); d > 0 {
+				return d
+			}
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		var d time.Duration
+		for _, pair := range [][2]string{
+			{kRateLimitRemainReqs, kRateLimitResetReqs},
+			{kRateLimitRemainTokens, kRateLimitResetTokens},
+		} {
+			if resp.Header.Get(pair[0]) != "0" {
+				continue
+			}
+			if reset, err := time.ParseDuration(resp.Header.Get(pair[1])); err == nil && reset > d {
+				d = reset
+			}
+		}
+		return d
+	}
+
+	return 0
+}
+
+// sleepWithContext sleeps for given duration, or until `ctx` is done.
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,168 @@
+package openai
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// returns a retry policy with short delays for testing
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.BaseDelay = 10 * time.Millisecond
+	policy.MaxDelay = 20 * time.Millisecond
+	return policy
+}
+
+func TestRetryMock(t *testing.T) {
+	var calls int32
+	keys := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys <- r.Header.Get("Idempotency-Key")
+
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&calls, 1)%3 != 0 {
+			w.Header().Set("Retry-After-Ms", "10")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"message":"overloaded","type":"server_error"}}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"object":"list","data":[{"id":"test-model"}]}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+	client.SetRetryPolicy(testRetryPolicy())
+
+	// GET requests are retried
+	if models, err := client.ListModels(); err != nil {
+		t.Errorf("failed to list models with retries: %s", err)
+	} else if len(models.Data) != 1 || atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("unexpected result: %+v (calls: %d)", models, calls)
+	}
+	for len(keys) > 0 {
+		<-keys
+	}
+
+	// POST requests without idempotency key are not retried
+	atomic.StoreInt32(&calls, 0)
+	if _, err := client.CreateModeration("test", nil); !IsServerError(err) {
+		t.Errorf("expected server error, got: %v", err)
+	} else if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+	<-keys
+
+	// POST requests with idempotency key are retried with the same key
+	atomic.StoreInt32(&calls, 0)
+	ctx := ContextWithIdempotencyKey(context.Background(), "test-key-1")
+	if _, err := client.CreateChatCompletionWithContext(ctx, "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil); err != nil {
+		t.Errorf("failed to create chat completion with retries: %s", err)
+	} else if atomic.LoadInt32(&calls) != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+	for i := 0; i < 3; i++ {
+		if key := <-keys; key != "test-key-1" {
+			t.Errorf("expected idempotency key 'test-key-1', got '%s'", key)
+		}
+	}
+}
+
+func TestRetryStreamMock(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"Hi\"}}]}\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	policy := testRetryPolicy()
+	policy.AutoIdempotencyKey = true
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+	client.SetRetryPolicy(policy)
+
+	done := make(chan error, 1)
+	if err := client.CreateChatCompletionStreamWithContext(context.Background(), "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil, func(_ ChatCompletion, d bool, err error) {
+		if d {
+			done <- err
+		}
+	}); err != nil {
+		t.Fatalf("failed to create chat completion stream with retries: %s", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("stream failed: %s", err)
+		}
+		if atomic.LoadInt32(&calls) != 2 {
+			t.Errorf("expected 2 calls, got %d", calls)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("stream did not finish in time")
+	}
+}
+
+func TestRetryDelayFromHeaders(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("x-ratelimit-remaining-requests", "10")
+	resp.Header.Set("x-ratelimit-reset-requests", "1s")
+	resp.Header.Set("x-ratelimit-remaining-tokens", "0")
+	resp.Header.Set("x-ratelimit-reset-tokens", "6m0s")
+	if d := delayFromHeaders(resp); d != 6*time.Minute {
+		t.Errorf("expected 6m0s, got %s", d)
+	}
+
+	resp.Header.Set("Retry-After", "2")
+	if d := delayFromHeaders(resp); d != 2*time.Second {
+		t.Errorf("expected 2s, got %s", d)
+	}
+
+	policy := RetryPolicy{BaseDelay: time.Second, MaxDelay: 3 * time.Second}
+	if d := policy.delay(3, nil); d != 3*time.Second {
+		t.Errorf("expected delay to be capped to 3s, got %s", d)
+	}
+}
+
+func TestIsTransientNetworkError(t *testing.T) {
+	dial := func(err error) error {
+		return &url.Error{Op: "Post", URL: "https://api.openai.com/v1/chat/completions", Err: &net.OpError{Op: "dial", Net: "tcp", Err: err}}
+	}
+
+	for _, tc := range []struct {
+		err       error
+		transient bool
+	}{
+		{dial(syscall.ECONNREFUSED), true},
+		{dial(&net.DNSError{Err: "server misbehaving", Name: "api.openai.com", IsTemporary: true}), true},
+		{dial(&net.DNSError{Err: "no such host", Name: "api.openai.co", IsNotFound: true}), false},
+		{&url.Error{Op: "Post", URL: "https://api.openai.com", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}, false},
+		{&url.Error{Op: "Post", URL: "https://api.openai.com", Err: x509.HostnameError{Host: "api.openai.com", Certificate: &x509.Certificate{}}}, false},
+		{&url.Error{Op: "Post", URL: "https://api.openai.com", Err: x509.CertificateInvalidError{Reason: x509.Expired, Cert: &x509.Certificate{}}}, false},
+		{io.ErrUnexpectedEOF, true},
+		{context.Canceled, false},
+	} {
+		if transient := isTransientNetworkError(tc.err); transient != tc.transient {
+			t.Errorf("expected transient = %t for '%s'", tc.transient, tc.err)
+		}
+	}
+}
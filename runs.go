@@ -1,8 +1,10 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 )
 
 // NOTE: In Beta
@@ -108,14 +110,23 @@ func (o CreateRunOptions) SetMetadata(metadata map[string]string) CreateRunOptio
 // CreateRun creates a run with given `threadID`, `assistantID`, and `options`.
 //
 // https://platform.openai.com/docs/api-reference/runs/createRun
-func (c *Client) CreateRun(threadID, assistantID string, options CreateRunOptions) (response Run, err error) {
+func (c *Client) CreateRun(threadID, assistantID string, options CreateRunOptions, opts ...RequestOption) (response Run, err error) {
+	return c.CreateRunWithContext(context.Background(), threadID, assistantID, options, opts...)
+}
+
+// CreateRunWithContext creates a run with given `threadID`, `assistantID`, and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/createRun
+func (c *Client) CreateRunWithContext(ctx context.Context, threadID, assistantID string, options CreateRunOptions, opts ...RequestOption) (response Run, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = CreateRunOptions{}
 	}
 	options["assistant_id"] = assistantID
 
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/threads/%s/runs", threadID), options); err == nil {
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/threads/%s/runs", threadID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -123,11 +134,6 @@ func (c *Client) CreateRun(threadID, assistantID string, options CreateRunOption
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Run{}, err
@@ -136,9 +142,18 @@ func (c *Client) CreateRun(threadID, assistantID string, options CreateRunOption
 // RetrieveRun retrieves a run with given `threadID` and `runID`.
 //
 // https://platform.openai.com/docs/api-reference/runs/getRun
-func (c *Client) RetrieveRun(threadID, runID string) (response Run, err error) {
+func (c *Client) RetrieveRun(threadID, runID string, opts ...RequestOption) (response Run, err error) {
+	return c.RetrieveRunWithContext(context.Background(), threadID, runID, opts...)
+}
+
+// RetrieveRunWithContext retrieves a run with given `threadID` and `runID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/getRun
+func (c *Client) RetrieveRunWithContext(ctx context.Context, threadID, runID string, opts ...RequestOption) (response Run, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s/runs/%s", threadID, runID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s/runs/%s", threadID, runID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -146,11 +161,6 @@ func (c *Client) RetrieveRun(threadID, runID string) (response Run, err error) {
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Run{}, err
@@ -170,13 +180,22 @@ func (o ModifyRunOptions) SetMetadata(metadata map[string]string) ModifyRunOptio
 // ModifyRun modifies a run with given `threadID`, `runID`, and `options`.
 //
 // https://platform.openai.com/docs/api-reference/runs/modifyRun
-func (c *Client) ModifyRun(threadID, runID string, options ModifyRunOptions) (response Run, err error) {
+func (c *Client) ModifyRun(threadID, runID string, options ModifyRunOptions, opts ...RequestOption) (response Run, err error) {
+	return c.ModifyRunWithContext(context.Background(), threadID, runID, options, opts...)
+}
+
+// ModifyRunWithContext modifies a run with given `threadID`, `runID`, and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/modifyRun
+func (c *Client) ModifyRunWithContext(ctx context.Context, threadID, runID string, options ModifyRunOptions, opts ...RequestOption) (response Run, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ModifyRunOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/threads/%s/runs/%s", threadID, runID), options); err == nil {
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/threads/%s/runs/%s", threadID, runID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -184,11 +203,6 @@ func (c *Client) ModifyRun(threadID, runID string, options ModifyRunOptions) (re
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Run{}, err
@@ -244,13 +258,22 @@ func (o ListRunsOptions) SetBefore(before string) ListRunsOptions {
 // ListRuns fetches runs with given `threadID` and `options`.
 //
 // https://platform.openai.com/docs/api-reference/runs/listRuns
-func (c *Client) ListRuns(threadID string, options ListRunsOptions) (response Runs, err error) {
+func (c *Client) ListRuns(threadID string, options ListRunsOptions, opts ...RequestOption) (response Runs, err error) {
+	return c.ListRunsWithContext(context.Background(), threadID, options, opts...)
+}
+
+// ListRunsWithContext fetches runs with given `threadID` and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/listRuns
+func (c *Client) ListRunsWithContext(ctx context.Context, threadID string, options ListRunsOptions, opts ...RequestOption) (response Runs, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ListRunsOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s/runs", threadID), options); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s/runs", threadID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -258,11 +281,6 @@ func (c *Client) ListRuns(threadID string, options ListRunsOptions) (response Ru
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Runs{}, err
@@ -281,9 +299,18 @@ type ToolOutput struct {
 //	run.Status == RunStatusRequiresAction && run.RequiredAction.Type == "submit_tool_outputs".
 //
 // https://platform.openai.com/docs/api-reference/runs/submitToolOutputs
-func (c *Client) SubmitToolOutputs(threadID, runID string, toolOutputs []ToolOutput) (response Run, err error) {
+func (c *Client) SubmitToolOutputs(threadID, runID string, toolOutputs []ToolOutput, opts ...RequestOption) (response Run, err error) {
+	return c.SubmitToolOutputsWithContext(context.Background(), threadID, runID, toolOutputs, opts...)
+}
+
+// SubmitToolOutputsWithContext submits tool outputs with given `threadID` and `runID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/submitToolOutputs
+func (c *Client) SubmitToolOutputsWithContext(ctx context.Context, threadID, runID string, toolOutputs []ToolOutput, opts ...RequestOption) (response Run, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/threads/%s/runs/%s/submit_tool_outputs", threadID, runID), map[string]any{
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/threads/%s/runs/%s/submit_tool_outputs", threadID, runID), map[string]any{
 		"tool_outputs": toolOutputs,
 	}); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
@@ -293,11 +320,6 @@ func (c *Client) SubmitToolOutputs(threadID, runID string, toolOutputs []ToolOut
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Run{}, err
@@ -310,9 +332,18 @@ func (c *Client) SubmitToolOutputs(threadID, runID string, toolOutputs []ToolOut
 //	run.Status == RunStatusInProgress.
 //
 // https://platform.openai.com/docs/api-reference/runs/cancelRun
-func (c *Client) CancelRun(threadID, runID string) (response Run, err error) {
+func (c *Client) CancelRun(threadID, runID string, opts ...RequestOption) (response Run, err error) {
+	return c.CancelRunWithContext(context.Background(), threadID, runID, opts...)
+}
+
+// CancelRunWithContext cancels a run with given `threadID` and `runID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/cancelRun
+func (c *Client) CancelRunWithContext(ctx context.Context, threadID, runID string, opts ...RequestOption) (response Run, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/threads/%s/runs/%s/cancel", threadID, runID), nil); err == nil {
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/threads/%s/runs/%s/cancel", threadID, runID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -320,11 +351,6 @@ func (c *Client) CancelRun(threadID, runID string) (response Run, err error) {
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Run{}, err
@@ -390,14 +416,23 @@ type RunnableThreadMessage struct {
 // CreateThreadAndRun creates a thread and runs it with given `assistantID` and `options`.
 //
 // https://platform.openai.com/docs/api-reference/runs/createThreadAndRun
-func (c *Client) CreateThreadAndRun(assistantID string, options CreateThreadAndRunOptions) (response Run, err error) {
+func (c *Client) CreateThreadAndRun(assistantID string, options CreateThreadAndRunOptions, opts ...RequestOption) (response Run, err error) {
+	return c.CreateThreadAndRunWithContext(context.Background(), assistantID, options, opts...)
+}
+
+// CreateThreadAndRunWithContext creates a thread and runs it with given `assistantID` and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/createThreadAndRun
+func (c *Client) CreateThreadAndRunWithContext(ctx context.Context, assistantID string, options CreateThreadAndRunOptions, opts ...RequestOption) (response Run, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = CreateThreadAndRunOptions{}
 	}
 	options["assistant_id"] = assistantID
 
 	var bytes []byte
-	if bytes, err = c.post("v1/threads/runs", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/threads/runs", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -405,16 +440,76 @@ func (c *Client) CreateThreadAndRun(assistantID string, options CreateThreadAndR
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Run{}, err
 }
 
+// CreateRunStream creates a run with given `threadID`, `assistantID`, and `options`, and streams its events to given callback.
+//
+// Events are named like "thread.run.created" or "thread.message.delta", and their data can be decoded with `ServerSentEvent.Decode`.
+//
+// https://platform.openai.com/docs/api-reference/assistants-streaming/events
+func (c *Client) CreateRunStream(threadID, assistantID string, options CreateRunOptions, cb ServerSentEventCallback, opts ...RequestOption) (err error) {
+	return c.CreateRunStreamWithContext(context.Background(), threadID, assistantID, options, cb, opts...)
+}
+
+// CreateRunStreamWithContext creates a run with given `threadID`, `assistantID`, and `options`, and streams its events to given callback with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants-streaming/events
+func (c *Client) CreateRunStreamWithContext(ctx context.Context, threadID, assistantID string, options CreateRunOptions, cb ServerSentEventCallback, opts ...RequestOption) (err error) {
+	ctx = withRequestOptions(ctx, opts)
+
+	if options == nil {
+		options = CreateRunOptions{}
+	}
+	options["assistant_id"] = assistantID
+	options["stream"] = true
+
+	return startStream(ctx, c, http.MethodPost, fmt.Sprintf("v1/threads/%s/runs", threadID), options, newServerSentEventHandler, cb)
+}
+
+// CreateThreadAndRunStream creates a thread and runs it with given `assistantID` and `options`, and streams its events to given callback.
+//
+// https://platform.openai.com/docs/api-reference/assistants-streaming/events
+func (c *Client) CreateThreadAndRunStream(assistantID string, options CreateThreadAndRunOptions, cb ServerSentEventCallback, opts ...RequestOption) (err error) {
+	return c.CreateThreadAndRunStreamWithContext(context.Background(), assistantID, options, cb, opts...)
+}
+
+// CreateThreadAndRunStreamWithContext creates a thread and runs it with given `assistantID` and `options`, and streams its events to given callback with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants-streaming/events
+func (c *Client) CreateThreadAndRunStreamWithContext(ctx context.Context, assistantID string, options CreateThreadAndRunOptions, cb ServerSentEventCallback, opts ...RequestOption) (err error) {
+	ctx = withRequestOptions(ctx, opts)
+
+	if options == nil {
+		options = CreateThreadAndRunOptions{}
+	}
+	options["assistant_id"] = assistantID
+	options["stream"] = true
+
+	return startStream(ctx, c, http.MethodPost, "v1/threads/runs", options, newServerSentEventHandler, cb)
+}
+
+// SubmitToolOutputsStream submits tool outputs with given `threadID` and `runID`, and streams the events of the run to given callback.
+//
+// https://platform.openai.com/docs/api-reference/assistants-streaming/events
+func (c *Client) SubmitToolOutputsStream(threadID, runID string, toolOutputs []ToolOutput, cb ServerSentEventCallback, opts ...RequestOption) (err error) {
+	return c.SubmitToolOutputsStreamWithContext(context.Background(), threadID, runID, toolOutputs, cb, opts...)
+}
+
+// SubmitToolOutputsStreamWithContext submits tool outputs with given `threadID` and `runID`, and streams the events of the run to given callback with context support.
+//
+// https://platform.openai.com/docs/api-reference/assistants-streaming/events
+func (c *Client) SubmitToolOutputsStreamWithContext(ctx context.Context, threadID, runID string, toolOutputs []ToolOutput, cb ServerSentEventCallback, opts ...RequestOption) (err error) {
+	ctx = withRequestOptions(ctx, opts)
+
+	return startStream(ctx, c, http.MethodPost, fmt.Sprintf("v1/threads/%s/runs/%s/submit_tool_outputs", threadID, runID), map[string]any{
+		"tool_outputs": toolOutputs,
+		"stream":       true,
+	}, newServerSentEventHandler, cb)
+}
+
 // RunStepType type for constants
 type RunStepType string
 
@@ -520,9 +615,18 @@ type RunStep struct {
 // RetrieveRunStep retrieves a run step with given `threadID`, `runID` and `stepID`.
 //
 // https://platform.openai.com/docs/api-reference/runs/getRunStep
-func (c *Client) RetrieveRunStep(threadID, runID, stepID string) (response RunStep, err error) {
+func (c *Client) RetrieveRunStep(threadID, runID, stepID string, opts ...RequestOption) (response RunStep, err error) {
+	return c.RetrieveRunStepWithContext(context.Background(), threadID, runID, stepID, opts...)
+}
+
+// RetrieveRunStepWithContext retrieves a run step with given `threadID`, `runID` and `stepID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/getRunStep
+func (c *Client) RetrieveRunStepWithContext(ctx context.Context, threadID, runID, stepID string, opts ...RequestOption) (response RunStep, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s/runs/%s/steps/%s", threadID, runID, stepID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s/runs/%s/steps/%s", threadID, runID, stepID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -530,11 +634,6 @@ func (c *Client) RetrieveRunStep(threadID, runID, stepID string) (response RunSt
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return RunStep{}, err
@@ -590,13 +689,22 @@ type RunSteps struct {
 // ListRunSteps fetches run steps with given `threadID`, `runID` and `options`.
 //
 // https://platform.openai.com/docs/api-reference/runs/listRunSteps
-func (c *Client) ListRunSteps(threadID, runID string, options ListRunStepsOptions) (response RunSteps, err error) {
+func (c *Client) ListRunSteps(threadID, runID string, options ListRunStepsOptions, opts ...RequestOption) (response RunSteps, err error) {
+	return c.ListRunStepsWithContext(context.Background(), threadID, runID, options, opts...)
+}
+
+// ListRunStepsWithContext fetches run steps with given `threadID`, `runID` and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/runs/listRunSteps
+func (c *Client) ListRunStepsWithContext(ctx context.Context, threadID, runID string, options ListRunStepsOptions, opts ...RequestOption) (response RunSteps, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ListRunStepsOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s/runs/%s/steps", threadID, runID), options); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s/runs/%s/steps", threadID, runID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -604,11 +712,6 @@ func (c *Client) ListRunSteps(threadID, runID string, options ListRunStepsOption
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return RunSteps{}, err
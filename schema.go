@@ -0,0 +1,462 @@
+package openai
+
+// types and functions for generating JSON schemas from Go types
+
+import (
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JSONSchema type for JSON schemas (of structured outputs, or parameters of tools)
+//
+// https://platform.openai.com/docs/guides/structured-outputs#supported-schemas
+type JSONSchema map[string]any
+
+// NewJSONSchema generates a JSON schema of an object from the type of `v`, which should be a struct or a pointer to a struct.
+//
+// Struct fields are converted with the following rules:
+//
+//   - property names are taken from `json` tags (fields with `json:"-"` and unexported fields are skipped),
+//   - `description` tags are used as descriptions,
+//   - `enum` tags (comma-separated values, eg. `enum:"celsius,fahrenheit"`) are used as enums,
+//   - `minimum`, `maximum`, `exclusiveMinimum`, `exclusiveMaximum`, `multipleOf`, `minLength`, `maxLength`,
+//     `minItems`, `maxItems`, `pattern`, and `format` tags are used as the constraints with the same names,
+//   - fields of pointer types or with `omitempty` are optional, and others are required,
+//   - nested structs become nested objects, slices and arrays become arrays, and maps with string keys become objects
+//     with `additionalProperties` (which is not allowed in strict mode of the API, so maps are errors then),
+//   - `time.Time` becomes a string with `date-time` format,
+//   - types which implement `JSONSchemaProvider` have their own schemas (eg. unions with `anyOf`),
+//   - and recursive structs are referenced with `$ref` (to `#` for the root, or to `$defs`).
+//
+// If `strict` is true, the schema is generated for strict mode of the API:
+// all properties are required, optional ones are nullable instead, and objects do not allow additional properties.
+func NewJSONSchema(v any, strict bool) (JSONSchema, error) {
+	return newJSONSchemaOf(reflect.TypeOf(v), strict)
+}
+
+// newJSONSchemaOf generates a JSON schema of an object from given type.
+func newJSONSchemaOf(t reflect.Type, strict bool) (JSONSchema, error) {
+	root := t
+	for root != nil && root.Kind() == reflect.Pointer {
+		root = root.Elem()
+	}
+	if root == nil || root.Kind() != reflect.Struct || root == timeType {
+		return nil, fmt.Errorf("type of a JSON schema should be a struct, got: %v", t)
+	}
+
+	return newSchemaGenerator(root, strict).generate()
+}
+
+// JSONSchemaProvider interface for types which provide their own JSON schemas to `NewJSONSchema`
+// (eg. unions of types with `anyOf`)
+type JSONSchemaProvider interface {
+	JSONSchema() JSONSchema
+}
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	rawMessageType     = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	schemaProviderType = reflect.TypeOf((*JSONSchemaProvider)(nil)).Elem()
+
+	errUnsupportedSchemaType = errors.New("unsupported type for JSON schema")
+)
+
+// schemaGenerator struct for generating a JSON schema from a Go type
+type schemaGenerator struct {
+	root   reflect.Type
+	strict bool
+
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+	defs      map[string]any
+	defNames  map[reflect.Type]string
+}
+
+// newSchemaGenerator returns a new schemaGenerator for given root type.
+func newSchemaGenerator(root reflect.Type, strict bool) *schemaGenerator {
+	return &schemaGenerator{
+		root:      root,
+		strict:    strict,
+		visiting:  map[reflect.Type]bool{},
+		recursive: map[reflect.Type]bool{},
+		defs:      map[string]any{},
+		defNames:  map[reflect.Type]string{},
+	}
+}
+
+// generate generates the JSON schema of the root type.
+func (g *schemaGenerator) generate() (JSONSchema, error) {
+	schema, err := g.schemaOf(g.root)
+	if err != nil {
+		return nil, err
+	}
+	if len(g.defs) > 0 {
+		schema["$defs"] = g.defs
+	}
+	return JSONSchema(schema), nil
+}
+
+// schemaOf returns the JSON schema of given type.
+func (g *schemaGenerator) schemaOf(t reflect.Type) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Implements(schemaProviderType) {
+		return providedSchemaOf(reflect.Zero(t).Interface().(JSONSchemaProvider)), nil
+	} else if reflect.PointerTo(t).Implements(schemaProviderType) {
+		return providedSchemaOf(reflect.New(t).Interface().(JSONSchemaProvider)), nil
+	}
+
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	}
+	if t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		// custom JSON encoding, so nothing is known about its values
+		return map[string]any{}, nil
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return map[string]any{"type": "string"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoded as base64 strings
+			return map[string]any{"type": "string"}, nil
+		}
+		items, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if g.strict {
+			return nil, fmt.Errorf("%w: map is not allowed in strict mode (%s)", errUnsupportedSchemaType, t)
+		}
+		if t.Key().Kind() != reflect.String && !t.Key().Implements(textMarshalerType) {
+			return nil, fmt.Errorf("%w: map with non-string keys (%s)", errUnsupportedSchemaType, t)
+		}
+		values, err := g.schemaOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		return g.structSchemaOf(t)
+	}
+
+	return nil, fmt.Errorf("%w: %s", errUnsupportedSchemaType, t)
+}
+
+// structSchemaOf returns the JSON schema of given struct type, or a reference to it if it is recursive.
+func (g *schemaGenerator) structSchemaOf(t reflect.Type) (map[string]any, error) {
+	if g.visiting[t] {
+		if t == g.root {
+			return map[string]any{"$ref": "#"}, nil
+		}
+		g.recursive[t] = true
+		return map[string]any{"$ref": "#/$defs/" + g.defName(t)}, nil
+	}
+
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	properties := map[string]any{}
+	required := []string{}
+	if err := g.addProperties(t, properties, &required); err != nil {
+		return nil, err
+	}
+
+	schema := map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+
+	if g.recursive[t] && t != g.root {
+		name := g.defName(t)
+		g.defs[name] = schema
+		return map[string]any{"$ref": "#/$defs/" + name}, nil
+	}
+	return schema, nil
+}
+
+// defName returns the name of given struct type in `$defs`, which is unique in the schema.
+//
+// Types are named with their type names, or with their package paths too if the names are already taken by other types.
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	if name, exists := g.defNames[t]; exists {
+		return name
+	}
+
+	taken := func(name string) bool {
+		for _, n := range g.defNames {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+	name := sanitizeDefName(t.Name())
+	if taken(name) {
+		name = sanitizeDefName(t.PkgPath() + "." + t.Name())
+	}
+	for i, base := 2, name; taken(name); i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+
+	g.defNames[t] = name
+	return name
+}
+
+// sanitizeDefName replaces characters which are not allowed in names of `$defs` (eg. '/' of package paths) with '_'.
+func sanitizeDefName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// addProperties adds the properties of given struct type (including the ones of embedded structs).
+func (g *schemaGenerator) addProperties(t reflect.Type, properties map[string]any, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// fields of embedded structs are promoted, as in `encoding/json`
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := g.addProperties(ft, properties, required); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property, err := g.schemaOf(field.Type)
+		if err != nil {
+			return fmt.Errorf("field '%s': %w", field.Name, err)
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			if property["enum"], err = enumValues(field.Type, enum); err != nil {
+				return fmt.Errorf("field '%s': %w", field.Name, err)
+			}
+		}
+
+		if err := addConstraints(property, field.Tag); err != nil {
+			return fmt.Errorf("field '%s': %w", field.Name, err)
+		}
+
+		optional := field.Type.Kind() == reflect.Pointer || hasTagOption(opts, "omitempty")
+		if optional && g.strict {
+			property = nullable(property)
+		}
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+
+		if _, exists := properties[name]; !exists && (!optional || g.strict) {
+			*required = append(*required, name)
+		}
+		properties[name] = property
+	}
+	return nil
+}
+
+// hasTagOption checks if given comma-separated tag options have `option`.
+func hasTagOption(opts, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// enumValues converts the comma-separated values of an `enum` tag to values of given type.
+func enumValues(t reflect.Type, tag string) ([]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	values := []any{}
+	for _, value := range strings.Split(tag, ",") {
+		value = strings.TrimSpace(value)
+
+		switch t.Kind() {
+		case reflect.String:
+			values = append(values, value)
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer enum value '%s': %w", value, err)
+			}
+			values = append(values, i)
+		case reflect.Float32, reflect.Float64:
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number enum value '%s': %w", value, err)
+			}
+			values = append(values, f)
+		default:
+			return nil, fmt.Errorf("enums are not supported for type: %s", t)
+		}
+	}
+	return values, nil
+}
+
+// providedSchemaOf returns a copy of the schema provided by given value.
+func providedSchemaOf(provider JSONSchemaProvider) map[string]any {
+	schema := map[string]any{}
+	for k, v := range provider.JSONSchema() {
+		schema[k] = v
+	}
+	return schema
+}
+
+// tags of schema constraints, and whether their values are numbers
+var schemaConstraintTags = []struct {
+	name   string
+	number bool
+}{
+	{"minimum", true},
+	{"maximum", true},
+	{"exclusiveMinimum", true},
+	{"exclusiveMaximum", true},
+	{"multipleOf", true},
+	{"minLength", true},
+	{"maxLength", true},
+	{"minItems", true},
+	{"maxItems", true},
+	{"pattern", false},
+	{"format", false},
+}
+
+// addConstraints adds the constraints in given struct tag to the schema.
+func addConstraints(schema map[string]any, tag reflect.StructTag) error {
+	for _, constraint := range schemaConstraintTags {
+		value, exists := tag.Lookup(constraint.name)
+		if !exists {
+			continue
+		}
+
+		if constraint.number {
+			number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return fmt.Errorf("invalid value of '%s': %w", constraint.name, err)
+			}
+			schema[constraint.name] = number
+		} else {
+			schema[constraint.name] = value
+		}
+	}
+	return nil
+}
+
+// nullable returns given schema which also allows null.
+func nullable(schema map[string]any) map[string]any {
+	if typ3, ok := schema["type"].(string); ok {
+		schema["type"] = []string{typ3, "null"}
+		if enum, ok := schema["enum"].([]any); ok {
+			schema["enum"] = append(enum, nil)
+		}
+		return schema
+	}
+	if len(schema) == 0 {
+		return schema // anything, including null
+	}
+
+	return map[string]any{
+		"anyOf": []any{schema, map[string]any{"type": "null"}},
+	}
+}
+
+// NewToolFunctionParametersFromType generates ToolFunctionParameters from the type of `v` (a struct or a pointer to a struct).
+//
+// See `NewJSONSchema` for the rules of conversion. If `strict` is true, the parameters are generated for strict function calling,
+// which should be enabled with `ChatCompletionTool.SetStrict`, `ResponseTool.SetStrict`, or `ToolFunction.Strict`.
+func NewToolFunctionParametersFromType(v any, strict bool) (ToolFunctionParameters, error) {
+	schema, err := NewJSONSchema(v, strict)
+	if err != nil {
+		return nil, err
+	}
+	return ToolFunctionParameters(schema), nil
+}
+
+// NewToolFunctionParametersFromFunc generates ToolFunctionParameters from the argument struct of given handler function,
+// which should look like `func(args T) ...` or `func(ctx context.Context, args T) ...`.
+//
+// See `NewToolFunctionParametersFromType` for the details.
+func NewToolFunctionParametersFromFunc(fn any, strict bool) (ToolFunctionParameters, error) {
+	t, err := toolArgumentsType(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := newJSONSchemaOf(t, strict)
+	if err != nil {
+		return nil, err
+	}
+	return ToolFunctionParameters(schema), nil
+}
+
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// toolArgumentsType returns the type of the argument struct of given handler function.
+func toolArgumentsType(fn any) (reflect.Type, error) {
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func {
+		return nil, fmt.Errorf("handler should be a function, got: %v", t)
+	}
+
+	switch {
+	case t.NumIn() == 1:
+		return t.In(0), nil
+	case t.NumIn() == 2 && t.In(0) == contextType:
+		return t.In(1), nil
+	}
+	return nil, fmt.Errorf("handler should take an argument struct (optionally after a context.Context), got: %s", t)
+}
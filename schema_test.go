@@ -0,0 +1,342 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type schemaTestAddress struct {
+	City    string `json:"city" description:"name of the city"`
+	ZipCode string `json:"zip_code,omitempty"`
+}
+
+type schemaTestBase struct {
+	ID string `json:"id"`
+}
+
+type schemaTestPerson struct {
+	schemaTestBase
+
+	Name      string            `json:"name" description:"full name"`
+	Age       *int              `json:"age"`
+	Unit      string            `json:"unit" enum:"celsius,fahrenheit"`
+	Level     int               `json:"level,omitempty" enum:"1,2,3"`
+	Score     float64           `json:"score"`
+	Active    bool              `json:"active"`
+	Tags      []string          `json:"tags"`
+	Address   schemaTestAddress `json:"address"`
+	CreatedAt time.Time         `json:"created_at"`
+	Extra     any               `json:"extra,omitempty"`
+	Ignored   string            `json:"-"`
+	private   string
+	NoTag     string
+}
+
+type schemaTestTree struct {
+	Value    string           `json:"value"`
+	Children []schemaTestTree `json:"children"`
+	Next     *schemaTestList  `json:"next,omitempty"`
+}
+
+type schemaTestList struct {
+	Value string          `json:"value"`
+	Next  *schemaTestList `json:"next,omitempty"`
+}
+
+// converts given value to a generic JSON value, for comparing schemas
+func toJSONValue(t *testing.T, v any) any {
+	t.Helper()
+
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to encode: %s", err)
+	}
+	var value any
+	if err := json.Unmarshal(bytes, &value); err != nil {
+		t.Fatalf("failed to decode: %s", err)
+	}
+	return value
+}
+
+func TestJSONSchema(t *testing.T) {
+	// not strict
+	schema, err := NewJSONSchema(&schemaTestPerson{}, false)
+	if err != nil {
+		t.Fatalf("failed to generate schema: %s", err)
+	}
+	expected := `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"name": {"type": "string", "description": "full name"},
+			"age": {"type": "integer"},
+			"unit": {"type": "string", "enum": ["celsius", "fahrenheit"]},
+			"level": {"type": "integer", "enum": [1, 2, 3]},
+			"score": {"type": "number"},
+			"active": {"type": "boolean"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"address": {
+				"type": "object",
+				"properties": {
+					"city": {"type": "string", "description": "name of the city"},
+					"zip_code": {"type": "string"}
+				},
+				"required": ["city"],
+				"additionalProperties": false
+			},
+			"created_at": {"type": "string", "format": "date-time"},
+			"extra": {},
+			"NoTag": {"type": "string"}
+		},
+		"required": ["id", "name", "unit", "score", "active", "tags", "address", "created_at", "NoTag"],
+		"additionalProperties": false
+	}`
+	var expectedValue any
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatalf("failed to decode expected schema: %s", err)
+	}
+	if actual := toJSONValue(t, schema); !reflect.DeepEqual(actual, expectedValue) {
+		t.Errorf("unexpected schema:\n%+v\nexpected:\n%+v", actual, expectedValue)
+	}
+
+	// strict
+	schema, err = NewJSONSchema(schemaTestPerson{}, true)
+	if err != nil {
+		t.Fatalf("failed to generate schema: %s", err)
+	}
+	value := toJSONValue(t, schema).(map[string]any)
+	if required := value["required"].([]any); len(required) != 12 {
+		t.Errorf("expected all 12 properties to be required, got %v", required)
+	}
+	properties := value["properties"].(map[string]any)
+	for name, expected := range map[string]string{
+		"age":   `{"type": ["integer", "null"]}`,
+		"level": `{"type": ["integer", "null"], "enum": [1, 2, 3, null]}`,
+		"extra": `{}`,
+	} {
+		var expectedValue any
+		if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+			t.Fatalf("failed to decode expected schema: %s", err)
+		}
+		if !reflect.DeepEqual(properties[name], expectedValue) {
+			t.Errorf("unexpected schema of '%s': %+v", name, properties[name])
+		}
+	}
+	address := properties["address"].(map[string]any)
+	if required := address["required"].([]any); len(required) != 2 {
+		t.Errorf("expected all properties of address to be required, got %v", required)
+	}
+
+	// recursive
+	schema, err = NewJSONSchema(schemaTestTree{}, true)
+	if err != nil {
+		t.Fatalf("failed to generate schema: %s", err)
+	}
+	expected = `{
+		"type": "object",
+		"properties": {
+			"value": {"type": "string"},
+			"children": {"type": "array", "items": {"$ref": "#"}},
+			"next": {"anyOf": [{"$ref": "#/$defs/schemaTestList"}, {"type": "null"}]}
+		},
+		"required": ["value", "children", "next"],
+		"additionalProperties": false,
+		"$defs": {
+			"schemaTestList": {
+				"type": "object",
+				"properties": {
+					"value": {"type": "string"},
+					"next": {"anyOf": [{"$ref": "#/$defs/schemaTestList"}, {"type": "null"}]}
+				},
+				"required": ["value", "next"],
+				"additionalProperties": false
+			}
+		}
+	}`
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatalf("failed to decode expected schema: %s", err)
+	}
+	if actual := toJSONValue(t, schema); !reflect.DeepEqual(actual, expectedValue) {
+		t.Errorf("unexpected recursive schema:\n%+v\nexpected:\n%+v", actual, expectedValue)
+	}
+
+	// same-named types (eg. of different packages)
+	type schemaTestNode struct {
+		Value string          `json:"value"`
+		Next  *schemaTestNode `json:"next,omitempty"`
+	}
+	first := reflect.TypeOf(schemaTestNode{})
+	{
+		type schemaTestNode struct {
+			Count int             `json:"count"`
+			Next  *schemaTestNode `json:"next,omitempty"`
+		}
+		second := reflect.TypeOf(schemaTestNode{})
+
+		schema, err = newJSONSchemaOf(reflect.StructOf([]reflect.StructField{
+			{Name: "First", Type: first, Tag: `json:"first"`},
+			{Name: "Second", Type: second, Tag: `json:"second"`},
+		}), true)
+		if err != nil {
+			t.Fatalf("failed to generate schema: %s", err)
+		}
+		defs := toJSONValue(t, schema).(map[string]any)["$defs"].(map[string]any)
+		if len(defs) != 2 {
+			t.Errorf("expected 2 definitions for same-named types, got %+v", defs)
+		}
+		for name, def := range defs {
+			next := def.(map[string]any)["properties"].(map[string]any)["next"]
+			if ref := next.(map[string]any)["anyOf"].([]any)[0].(map[string]any)["$ref"]; ref != "#/$defs/"+name {
+				t.Errorf("definition '%s' should reference itself, got %v", name, ref)
+			}
+		}
+	}
+
+	// maps
+	type schemaTestLabels struct {
+		Labels map[string]int `json:"labels"`
+	}
+	schema, err = NewJSONSchema(schemaTestLabels{}, false)
+	if err != nil {
+		t.Fatalf("failed to generate schema: %s", err)
+	}
+	if labels := toJSONValue(t, schema).(map[string]any)["properties"].(map[string]any)["labels"]; !reflect.DeepEqual(labels, map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "integer"},
+	}) {
+		t.Errorf("unexpected schema of map: %+v", labels)
+	}
+	if _, err := NewJSONSchema(schemaTestLabels{}, true); !errors.Is(err, errUnsupportedSchemaType) {
+		t.Errorf("expected an error for map in strict mode, got %v", err)
+	}
+
+	// unsupported types
+	if _, err := NewJSONSchema("not a struct", true); err == nil {
+		t.Errorf("expected an error for non-struct type")
+	}
+	if _, err := NewJSONSchema(struct {
+		Callback func() `json:"callback"`
+	}{}, true); !errors.Is(err, errUnsupportedSchemaType) {
+		t.Errorf("expected an error for unsupported type, got %v", err)
+	}
+	if _, err := NewJSONSchema(struct {
+		Unit bool `json:"unit" enum:"true"`
+	}{}, true); err == nil {
+		t.Errorf("expected an error for unsupported enum")
+	}
+}
+
+// union of a string and a number
+type schemaTestUnion struct {
+	value any
+}
+
+func (u schemaTestUnion) JSONSchema() JSONSchema {
+	return JSONSchema{
+		"anyOf": []any{
+			map[string]any{"type": "string"},
+			map[string]any{"type": "number"},
+		},
+	}
+}
+
+func (u schemaTestUnion) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.value)
+}
+
+type schemaTestArguments struct {
+	Query   string          `json:"query" description:"search query" minLength:"1" maxLength:"100" pattern:"^[a-z ]+$"`
+	Limit   int             `json:"limit" minimum:"1" maximum:"50"`
+	Ratio   float64         `json:"ratio,omitempty" exclusiveMinimum:"0" exclusiveMaximum:"1" multipleOf:"0.1"`
+	Emails  []string        `json:"emails" minItems:"1" maxItems:"3"`
+	Date    string          `json:"date" format:"date"`
+	Value   schemaTestUnion `json:"value"`
+	Options *struct {
+		Exact bool `json:"exact"`
+	} `json:"options"`
+}
+
+func TestToolFunctionParameters(t *testing.T) {
+	expected := `{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string", "description": "search query", "minLength": 1, "maxLength": 100, "pattern": "^[a-z ]+$"},
+			"limit": {"type": "integer", "minimum": 1, "maximum": 50},
+			"ratio": {"type": ["number", "null"], "exclusiveMinimum": 0, "exclusiveMaximum": 1, "multipleOf": 0.1},
+			"emails": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 3},
+			"date": {"type": "string", "format": "date"},
+			"value": {"anyOf": [{"type": "string"}, {"type": "number"}]},
+			"options": {
+				"type": ["object", "null"],
+				"properties": {"exact": {"type": "boolean"}},
+				"required": ["exact"],
+				"additionalProperties": false
+			}
+		},
+		"required": ["query", "limit", "ratio", "emails", "date", "value", "options"],
+		"additionalProperties": false
+	}`
+	var expectedValue any
+	if err := json.Unmarshal([]byte(expected), &expectedValue); err != nil {
+		t.Fatalf("failed to decode expected schema: %s", err)
+	}
+
+	// from a type
+	parameters, err := NewToolFunctionParametersFromType(schemaTestArguments{}, true)
+	if err != nil {
+		t.Fatalf("failed to generate parameters: %s", err)
+	}
+	if actual := toJSONValue(t, parameters); !reflect.DeepEqual(actual, expectedValue) {
+		t.Errorf("unexpected parameters:\n%+v\nexpected:\n%+v", actual, expectedValue)
+	}
+
+	// from functions
+	for _, fn := range []any{
+		func(args schemaTestArguments) (string, error) { return "", nil },
+		func(ctx context.Context, args *schemaTestArguments) (string, error) { return "", nil },
+	} {
+		parameters, err := NewToolFunctionParametersFromFunc(fn, true)
+		if err != nil {
+			t.Fatalf("failed to generate parameters: %s", err)
+		}
+		if actual := toJSONValue(t, parameters); !reflect.DeepEqual(actual, expectedValue) {
+			t.Errorf("unexpected parameters from %T:\n%+v", fn, actual)
+		}
+	}
+	for _, fn := range []any{
+		"not a function",
+		func() {},
+		func(a, b schemaTestArguments) {},
+		func(ctx context.Context, query string) {},
+	} {
+		if _, err := NewToolFunctionParametersFromFunc(fn, true); err == nil {
+			t.Errorf("expected an error for %T", fn)
+		}
+	}
+
+	// invalid constraint
+	if _, err := NewToolFunctionParametersFromType(struct {
+		Limit int `json:"limit" minimum:"one"`
+	}{}, true); err == nil {
+		t.Errorf("expected an error for invalid constraint")
+	}
+
+	// strict tools
+	for _, tool := range []any{
+		NewChatCompletionTool("search", "Search things", parameters).SetStrict(true),
+		NewResponseTool("search", "Search things", parameters).SetStrict(true),
+		NewFunctionTool(ToolFunction{Name: "search", Description: "Search things", Parameters: parameters, Strict: &[]bool{true}[0]}),
+	} {
+		value := toJSONValue(t, tool).(map[string]any)
+		if function, exists := value["function"].(map[string]any); exists {
+			value = function
+		}
+		if value["strict"] != true || !reflect.DeepEqual(value["parameters"], expectedValue) {
+			t.Errorf("unexpected strict tool: %+v", value)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package openai
+
+// types and functions for server-sent events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ServerSentEvent struct for an event of server-sent events
+//
+// https://html.spec.whatwg.org/multipage/server-sent-events.html
+type ServerSentEvent struct {
+	// `id` field (last event ID)
+	ID string
+
+	// `event` field (empty for "message" events)
+	Event string
+
+	// `data` field (multiple data lines are joined with '\n')
+	Data string
+
+	// `retry` field (0 if not set)
+	Retry time.Duration
+}
+
+// Decode decodes the data of the event as JSON into `v`.
+func (e ServerSentEvent) Decode(v any) error {
+	return json.Unmarshal([]byte(e.Data), v)
+}
+
+// ServerSentEventCallback type for receiving server-sent events
+//
+// `done` is true for the last call (with or without an error).
+type ServerSentEventCallback func(event ServerSentEvent, done bool, err error)
+
+var (
+	utf8BOM = []byte("\xef\xbb\xbf")
+)
+
+// sseReader struct for reading server-sent events from a stream
+type sseReader struct {
+	reader *bufio.Reader
+
+	first       bool
+	skipLF      bool // true if the last line ended with "\r", which may be followed by "\n"
+	lastEventID string
+}
+
+// newSSEReader returns a new sseReader for given stream.
+func newSSEReader(r io.Reader) *sseReader {
+	return &sseReader{
+		reader: bufio.NewReader(r),
+		first:  true,
+	}
+}
+
+// readLine reads a line terminated by "\r\n", "\n", or "\r" (without the terminator).
+//
+// Lines are returned as soon as their terminators arrive (without waiting for more bytes),
+// and they are not limited in length.
+// A line without terminator at the end of the stream is also returned.
+func (r *sseReader) readLine() (line []byte, err error) {
+	for {
+		// wait for more bytes
+		if r.reader.Buffered() == 0 {
+			if _, err = r.reader.ReadByte(); err != nil {
+				if err == io.EOF && len(line) > 0 {
+					break
+				}
+				return nil, err
+			}
+			_ = r.reader.UnreadByte()
+		}
+		buffered, _ := r.reader.Peek(r.reader.Buffered())
+
+		// "\n" of "\r\n" which was split across reads
+		if r.skipLF {
+			r.skipLF = false
+			if buffered[0] == '\n' {
+				_, _ = r.reader.Discard(1)
+				continue
+			}
+		}
+
+		i := bytes.IndexAny(buffered, "\r\n")
+		if i < 0 {
+			line = append(line, buffered...)
+			_, _ = r.reader.Discard(len(buffered))
+			continue
+		}
+		line = append(line, buffered[:i]...)
+		r.skipLF = buffered[i] == '\r'
+		_, _ = r.reader.Discard(i + 1)
+		break
+	}
+
+	if r.first {
+		r.first = false
+		line = bytes.TrimPrefix(line, utf8BOM)
+	}
+
+	return line, nil
+}
+
+// next reads the next event from the stream.
+//
+// It returns `io.EOF` when the stream ends.
+func (r *sseReader) next() (ServerSentEvent, error) {
+	event := ServerSentEvent{}
+	var data bytes.Buffer
+	hasData := false
+
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			// NOTE: the spec discards an incomplete event at the end of the stream,
+			// but some servers do not send the last empty line, so it is dispatched here.
+			if err == io.EOF && hasData {
+				event.ID = r.lastEventID
+				event.Data = string(bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+				return event, nil
+			}
+			return ServerSentEvent{}, err
+		}
+
+		// dispatch the event on an empty line
+		if len(line) == 0 {
+			if !hasData {
+				event = ServerSentEvent{}
+				continue
+			}
+			event.ID = r.lastEventID
+			event.Data = string(bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+			return event, nil
+		}
+
+		// comment
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, found := bytes.Cut(line, []byte(":"))
+		if found {
+			value = bytes.TrimPrefix(value, []byte(" "))
+		}
+
+		switch string(field) {
+		case "event":
+			event.Event = string(value)
+		case "data":
+			data.Write(value)
+			data.WriteByte('\n')
+			hasData = true
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				r.lastEventID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 64); err == nil {
+				event.Retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// eventError returns the error in given event, or nil if there is none.
+func eventError(event ServerSentEvent) error {
+	var res CommonResponse
+	if err := event.Decode(&res); err == nil && res.Error != nil {
+		return res.Error.err()
+	}
+
+	if event.Event == "error" {
+		var e Error
+		if err := event.Decode(&e); err != nil || e.Message == "" {
+			e = Error{Message: event.Data}
+		}
+		return e.err()
+	}
+
+	return nil
+}
+
+// newServerSentEventHandler returns a new handler which passes server-sent events through (and errors in them).
+func newServerSentEventHandler() eventHandler[ServerSentEvent] {
+	return eventHandler[ServerSentEvent]{
+		event: func(event ServerSentEvent, emit func(ServerSentEvent, bool, error)) bool {
+			if err := eventError(event); err != nil {
+				emit(event, true, err)
+				return false
+			}
+
+			emit(event, false, nil)
+			return true
+		},
+		end: func(_ bool, err error, emit func(ServerSentEvent, bool, error)) {
+			emit(ServerSentEvent{}, true, err)
+		},
+	}
+}
@@ -0,0 +1,253 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEReader(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+
+	stream := "\xef\xbb\xbf: comment\n" +
+		"event: first\r\n" +
+		"id: 1\r\n" +
+		"retry: 3000\r\n" +
+		"data: line 1\r\n" +
+		"data:line 2\r\n" +
+		"\r\n" +
+		"data: " + long + "\r" +
+		"\r" +
+		"event: ignored\n" +
+		"\n" +
+		"data\n" +
+		"id: 2\x00\n" +
+		"unknown: field\n" +
+		"\n" +
+		"data: {\"last\":true}\n" // without the last empty line
+
+	reader := newSSEReader(strings.NewReader(stream))
+
+	expected := []ServerSentEvent{
+		{ID: "1", Event: "first", Data: "line 1\nline 2", Retry: 3 * time.Second},
+		{ID: "1", Data: long},
+		{ID: "1", Data: ""},
+		{ID: "1", Data: `{"last":true}`},
+	}
+	for i, e := range expected {
+		event, err := reader.next()
+		if err != nil {
+			t.Fatalf("failed to read event #%d: %s", i, err)
+		}
+		if event != e {
+			t.Errorf("event #%d: expected %+v, got %+v", i, truncateString(fmt.Sprintf("%+v", e), 100), truncateString(fmt.Sprintf("%+v", event), 100))
+		}
+	}
+	if _, err := reader.next(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
+
+func TestStreamLongLinesMock(t *testing.T) {
+	arguments := fmt.Sprintf(`{"text":"%s"}`, strings.Repeat("a", 100*1024))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"echo\",\"arguments\":\"\"}}]}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":%q}}]}}]}\n\n", arguments)
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"tool_calls\"}]}\n\n")
+			fmt.Fprintf(w, "data: [DONE]\n\n")
+		case "/v1/responses":
+			fmt.Fprintf(w, "event: response.output_text.delta\ndata: {\"type\":\"response.output_text.delta\",\"delta\":%q}\n\n", strings.Repeat("b", 100*1024))
+			fmt.Fprintf(w, "event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_1\",\"status\":\"completed\"}}\n\n")
+		case "/v1/completions":
+			fmt.Fprintf(w, "data: {\"id\":\"cmpl-test\",\"choices\":[{\"index\":0,\"text\":\"Hello\"}]}\n\n")
+			fmt.Fprintf(w, "data: {\"id\":\"cmpl-test\",\"choices\":[{\"index\":0,\"text\":\" world\",\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprintf(w, "data: [DONE]\n\n")
+		case "/v1/threads/thread_1/runs":
+			fmt.Fprintf(w, "event: thread.run.created\ndata: {\"id\":\"run_1\",\"object\":\"thread.run\",\"status\":\"queued\"}\n\n")
+			fmt.Fprintf(w, "event: thread.run.completed\ndata: {\"id\":\"run_1\",\"object\":\"thread.run\",\"status\":\"completed\"}\n\n")
+			fmt.Fprintf(w, "event: done\ndata: [DONE]\n\n")
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+	ctx := context.Background()
+
+	// chat completion with long tool call arguments
+	chatDone := make(chan ChatCompletion, 1)
+	if err := client.CreateChatCompletionStreamWithContext(ctx, "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil, func(c ChatCompletion, done bool, err error) {
+		if err != nil {
+			t.Errorf("chat stream failed: %s", err)
+		}
+		if done {
+			chatDone <- c
+		}
+	}); err != nil {
+		t.Fatalf("failed to start chat stream: %s", err)
+	}
+	if c := <-chatDone; len(c.Choices) == 0 || len(c.Choices[0].Message.ToolCalls) != 1 || c.Choices[0].Message.ToolCalls[0].Function.Arguments != arguments {
+		t.Errorf("tool call arguments were not accumulated")
+	}
+
+	// responses with long deltas and event names
+	deltas := make(chan int, 10)
+	responseDone := make(chan struct{})
+	if err := client.CreateResponseStreamWithContext(ctx, "test-model", "Hello!", nil, func(e ResponseStreamEvent, done bool, err error) {
+		if err != nil {
+			t.Errorf("response stream failed: %s", err)
+		}
+		if e.Type == "response.output_text.delta" && e.Delta != nil {
+			deltas <- len(*e.Delta)
+		}
+		if done {
+			close(responseDone)
+		}
+	}); err != nil {
+		t.Fatalf("failed to start response stream: %s", err)
+	}
+	<-responseDone
+	if n := <-deltas; n != 100*1024 {
+		t.Errorf("unexpected delta length: %d", n)
+	}
+
+	// legacy completions
+	texts := []string{}
+	completionDone := make(chan struct{})
+	if err := client.CreateCompletionStreamWithContext(ctx, "test-model", CompletionOptions{}.SetPrompt("Say hello"), func(c Completion, done bool, err error) {
+		if err != nil {
+			t.Errorf("completion stream failed: %s", err)
+		}
+		if done {
+			close(completionDone)
+		} else {
+			texts = append(texts, c.Choices[0].Text)
+		}
+	}); err != nil {
+		t.Fatalf("failed to start completion stream: %s", err)
+	}
+	<-completionDone
+	if strings.Join(texts, "") != "Hello world" {
+		t.Errorf("unexpected completion texts: %v", texts)
+	}
+
+	// runs
+	events := []string{}
+	runDone := make(chan struct{})
+	if err := client.CreateRunStreamWithContext(ctx, "thread_1", "asst_1", nil, func(e ServerSentEvent, done bool, err error) {
+		if err != nil {
+			t.Errorf("run stream failed: %s", err)
+		}
+		if done {
+			close(runDone)
+			return
+		}
+		var run Run
+		if err := e.Decode(&run); err != nil || run.ID != "run_1" {
+			t.Errorf("failed to decode run: %+v (%v)", run, err)
+		}
+		events = append(events, e.Event)
+	}); err != nil {
+		t.Fatalf("failed to start run stream: %s", err)
+	}
+	<-runDone
+	if strings.Join(events, ",") != "thread.run.created,thread.run.completed" {
+		t.Errorf("unexpected run events: %v", events)
+	}
+}
+
+func TestStreamUnexpectedEOFMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n"))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+
+	errs := make(chan error, 1)
+	if err := client.CreateChatCompletionStreamWithContext(context.Background(), "test-model", []ChatMessage{NewChatUserMessage("Hello!")}, nil, func(_ ChatCompletion, done bool, err error) {
+		if done {
+			errs <- err
+		}
+	}); err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	select {
+	case err := <-errs:
+		if err != io.ErrUnexpectedEOF {
+			t.Errorf("expected unexpected EOF, got: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("stream did not finish in time")
+	}
+}
+
+func TestStreamCRLineEndingsMock(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("data: {\"first\":true}\r\r"))
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+
+	// events with lines ending with "\r" arrive before the end of the stream
+	events := make(chan ServerSentEvent, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := client.DoStream(ctx, http.MethodPost, "v1/events", nil, func(event ServerSentEvent, done bool, err error) {
+		if !done {
+			events <- event
+		}
+	}); err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	select {
+	case event := <-events:
+		if event.Data != `{"first":true}` {
+			t.Errorf("unexpected event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("event did not arrive before the end of the stream")
+	}
+
+	// "\r\n" split across reads
+	pr, pw := io.Pipe()
+	reader := newSSEReader(pr)
+	go func() {
+		pw.Write([]byte("data: a\r"))
+		pw.Write([]byte("\ndata: b\r"))
+		pw.Write([]byte("\n\r\n"))
+		pw.Close()
+	}()
+	if event, err := reader.next(); err != nil || event.Data != "a\nb" {
+		t.Errorf("unexpected event: %+v, %v", event, err)
+	}
+	if _, err := reader.next(); err != io.EOF {
+		t.Errorf("expected EOF, got %v", err)
+	}
+}
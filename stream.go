@@ -0,0 +1,351 @@
+package openai
+
+// types and functions for reading streams
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// eventHandler struct for decoding the server-sent events of a stream into items
+//
+// Items are emitted with the same arguments as stream callbacks: (event, done, err).
+type eventHandler[T any] struct {
+	// handles an event, and returns false if the stream is finished (after emitting the last item)
+	event func(event ServerSentEvent, emit func(T, bool, error)) bool
+
+	// emits the last item when the stream ends,
+	// with `done` (true if "[DONE]" was received) and the error of reading it (`ctx.Err()` on cancellation)
+	end func(done bool, err error, emit func(T, bool, error))
+}
+
+// streamItem struct for an item emitted by eventHandler
+type streamItem[T any] struct {
+	event T
+	done  bool
+	err   error
+}
+
+// eventStream struct for pulling items from the server-sent events of a response, one by one
+type eventStream[T any] struct {
+	ctx     context.Context
+	res     *http.Response
+	reader  *sseReader
+	handler eventHandler[T]
+	stop    func() bool
+
+	pending  []streamItem[T]
+	finished bool
+}
+
+// newEventStream returns a new eventStream for given response.
+func newEventStream[T any](ctx context.Context, res *http.Response, handler eventHandler[T]) *eventStream[T] {
+	return &eventStream[T]{
+		ctx:     ctx,
+		res:     res,
+		reader:  newSSEReader(res.Body),
+		handler: handler,
+
+		// abort reading on cancellation
+		stop: context.AfterFunc(ctx, func() {
+			res.Body.Close()
+		}),
+	}
+}
+
+// next returns the next item, reading events from the stream as needed.
+//
+// It returns `io.EOF` as an error with `done` after the last item.
+func (s *eventStream[T]) next() (event T, done bool, err error) {
+	for len(s.pending) == 0 {
+		if s.finished {
+			return event, true, io.EOF
+		}
+		s.read()
+	}
+
+	item := s.pending[0]
+	s.pending = s.pending[1:]
+
+	return item.event, item.done, item.err
+}
+
+// read reads an event from the stream, and handles it.
+func (s *eventStream[T]) read() {
+	event, err := s.reader.next()
+	switch {
+	case err != nil:
+		if s.ctx.Err() != nil {
+			err = s.ctx.Err()
+		} else if err == io.EOF {
+			err = nil
+		}
+		s.close()
+		s.handler.end(false, err, s.emit)
+	case event.Data == string(StreamDone):
+		s.close()
+		s.handler.end(true, nil, s.emit)
+	default:
+		if !s.handler.event(event, s.emit) {
+			s.close()
+		}
+	}
+}
+
+// emit queues an item.
+func (s *eventStream[T]) emit(event T, done bool, err error) {
+	s.pending = append(s.pending, streamItem[T]{event: event, done: done, err: err})
+}
+
+// close stops reading the stream, and closes the response body.
+func (s *eventStream[T]) close() {
+	s.finished = true
+	s.stop()
+	s.res.Body.Close()
+}
+
+// streamReader struct for pulling items of a streaming request one by one,
+// re-sending the request when the stream fails with a retryable error before delivering any item
+type streamReader[T any] struct {
+	ctx        context.Context
+	cancel     context.CancelFunc
+	c          *Client
+	endpoint   string
+	params     map[string]any
+	state      *requestState
+	newHandler func() eventHandler[T]
+	stream     *eventStream[T]
+
+	mu         sync.Mutex
+	retryable  bool
+	events     int
+	firstEvent time.Duration
+	finished   bool
+
+	// states of the current attempt
+	delivered  bool
+	usage      int
+	usageEvent any
+}
+
+// openStream sends HTTP request for streaming, and returns a reader of its response.
+//
+// It returns after the response headers arrive.
+func openStream[T any](
+	ctx context.Context,
+	c *Client,
+	method, endpoint string,
+	params map[string]any,
+	newHandler func() eventHandler[T],
+) (r *streamReader[T], err error) {
+	ctx = c.withIdempotencyKey(ctx, method)
+
+	ctx, cancel := requestOptionsFromContext(ctx).withTimeout(ctx)
+	ctx, cancel = withCancel(ctx, cancel)
+
+	state := &requestState{method: method, stream: true, started: time.Now()}
+
+	if state.budget, err = c.reserveBudgets(ctx, endpoint, params); err != nil {
+		cancel()
+		c.logResponse(ctx, endpoint, params, state, nil, nil, err)
+		return nil, err
+	}
+
+	var resp *http.Response
+	if resp, err = c.openStreamWithContext(ctx, endpoint, params, state); err != nil {
+		cancel()
+		state.budget.release()
+		return nil, err
+	}
+
+	return &streamReader[T]{
+		ctx:        ctx,
+		cancel:     cancel,
+		c:          c,
+		endpoint:   endpoint,
+		params:     params,
+		state:      state,
+		newHandler: newHandler,
+		stream:     newEventStream(ctx, resp, newHandler()),
+
+		retryable: c.retryPolicy != nil && isRetryableRequest(ctx, state.method),
+		usage:     -1,
+	}, nil
+}
+
+// startStream sends HTTP request for streaming, and streams its response to `cb` in a new goroutine.
+//
+// It returns after the response headers arrive.
+func startStream[T any, CB ~func(T, bool, error)](
+	ctx context.Context,
+	c *Client,
+	method, endpoint string,
+	params map[string]any,
+	newHandler func() eventHandler[T],
+	cb CB,
+) (err error) {
+	var r *streamReader[T]
+	if r, err = openStream(ctx, c, method, endpoint, params, newHandler); err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			event, done, err := r.recv()
+			cb(event, done, err)
+			if done {
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// withCancel returns a copy of `ctx` which is canceled by the returned function, which also calls `cancel`.
+func withCancel(ctx context.Context, cancel context.CancelFunc) (context.Context, context.CancelFunc) {
+	ctx, cancelCtx := context.WithCancel(ctx)
+	return ctx, func() {
+		cancelCtx()
+		cancel()
+	}
+}
+
+// recv returns the next item of the stream.
+//
+// It returns `io.EOF` as an error with `done` after the last item.
+func (r *streamReader[T]) recv() (event T, done bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.finished {
+		return event, true, io.EOF
+	}
+
+	c := r.c
+	for {
+		event, done, err = r.stream.next()
+
+		if err != nil &&
+			!r.delivered &&
+			r.retryable &&
+			r.state.attempts < c.retryPolicy.maxAttempts() &&
+			c.retryPolicy.isRetryableError(r.ctx, err) {
+			if err = r.retry(); err != nil {
+				c.logStreamFinished(r.ctx, r.endpoint, r.params, r.state, r.firstEvent, r.events, err)
+				r.state.budget.release()
+				r.finish()
+
+				var zero T
+				return zero, true, err
+			}
+			continue
+		}
+		r.delivered = true
+
+		if !done || err == nil {
+			r.events++
+			if r.events == 1 {
+				r.firstEvent = time.Since(r.state.started)
+			}
+		}
+		if done {
+			c.logStreamFinished(r.ctx, r.endpoint, r.params, r.state, r.firstEvent, r.events, err)
+		}
+
+		if c.rateLimiter != nil {
+			if tokens := streamUsageTokens(event); tokens >= 0 {
+				r.usage = tokens
+			}
+			if done {
+				c.rateLimiter.reconcile(r.state.reservation, r.usage)
+			}
+		}
+
+		if c.usageTracker != nil || r.state.budget != nil {
+			if streamUsageTokens(event) >= 0 {
+				r.usageEvent = event
+			}
+			if done {
+				r.settleUsage()
+			}
+		}
+
+		if done {
+			r.finish()
+		}
+
+		return event, done, err
+	}
+}
+
+// retry re-sends the request after the delay of the retry policy.
+func (r *streamReader[T]) retry() (err error) {
+	c := r.c
+
+	r.stream.close()
+	if c.rateLimiter != nil {
+		c.rateLimiter.reconcile(r.state.reservation, 0)
+	}
+
+	if err = sleepWithContext(r.ctx, c.retryPolicy.delay(r.state.attempts, nil)); err != nil {
+		return err
+	}
+
+	// response metadata is not updated here, as the caller may be reading it already
+	retryCtx := context.WithValue(r.ctx, responseMetaContextKey{}, (*ResponseMeta)(nil))
+
+	var resp *http.Response
+	if resp, err = c.openStreamWithContext(retryCtx, r.endpoint, r.params, r.state); err != nil {
+		return err
+	}
+
+	r.stream = newEventStream(r.ctx, resp, r.newHandler())
+	r.delivered, r.usage, r.usageEvent = false, -1, nil
+
+	return nil
+}
+
+// settleUsage tracks the usage of the stream, and settles its budgets.
+func (r *streamReader[T]) settleUsage() {
+	if r.usageEvent != nil {
+		r.c.trackStreamUsage(r.ctx, r.endpoint, r.params, r.usageEvent)
+		r.state.budget.settle(streamUsageResponse(r.usageEvent))
+	} else {
+		r.state.budget.settle(nil) // keep the estimated cost, as the actual one is unknown
+	}
+}
+
+// finish closes the stream, and releases its context.
+func (r *streamReader[T]) finish() {
+	r.finished = true
+	r.stream.close()
+	r.cancel()
+}
+
+// close aborts the stream if it is not finished yet.
+//
+// It can be called concurrently with `recv`, which returns the cancellation error then.
+func (r *streamReader[T]) close() {
+	r.cancel() // abort reading first
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.finished {
+		return
+	}
+
+	c := r.c
+	c.logStreamFinished(r.ctx, r.endpoint, r.params, r.state, r.firstEvent, r.events, context.Canceled)
+	if c.rateLimiter != nil {
+		c.rateLimiter.reconcile(r.state.reservation, r.usage)
+	}
+	if c.usageTracker != nil || r.state.budget != nil {
+		r.settleUsage()
+	}
+	r.finish()
+}
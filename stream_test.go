@@ -0,0 +1,176 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChatCompletionStreamMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		switch r.URL.Query().Get("case") {
+		case "tools":
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"id\":\"call_1\",\"type\":\"function\",\"function\":{\"name\":\"echo\",\"arguments\":\"\"}}]}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"{\\\"text\\\":\"}}]}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"tool_calls\":[{\"index\":0,\"function\":{\"arguments\":\"\\\"hi\\\"}\"}}]}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"tool_calls\"}]}\n\n")
+			fmt.Fprintf(w, "data: [DONE]\n\n")
+		case "error":
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"error\":{\"message\":\"something went wrong\",\"type\":\"server_error\"}}\n\n")
+		default:
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"Hello\"}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\" world\"}}]}\n\n")
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprintf(w, "data: [DONE]\n\n")
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.baseURL = &server.URL
+
+	messages := []ChatMessage{NewChatUserMessage("Hello")}
+
+	// text chunks, and EOF at the end
+	stream, err := client.StreamChatCompletion(context.TODO(), "gpt-4o", messages, nil)
+	if err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	content := ""
+	chunks := 0
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("failed to receive chunk: %s", err)
+		}
+		chunks++
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != nil {
+			text, _ := chunk.Choices[0].Delta.ContentString()
+			content += text
+		}
+	}
+	if chunks != 3 {
+		t.Errorf("expected 3 chunks, got %d", chunks)
+	}
+	if content != "Hello world" {
+		t.Errorf("expected 'Hello world', got '%s'", content)
+	}
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("expected EOF again, got %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Errorf("failed to close finished stream: %s", err)
+	}
+
+	// aggregated tool calls
+	stream, err = client.StreamChatCompletion(context.TODO(), "gpt-4o", messages, nil, WithExtraQuery("case", "tools"))
+	if err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	defer stream.Close()
+	var toolCalls []ToolCall
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("failed to receive chunk: %s", err)
+		}
+		if len(chunk.Choices) > 0 && len(chunk.Choices[0].Message.ToolCalls) > 0 {
+			toolCalls = chunk.Choices[0].Message.ToolCalls
+		}
+	}
+	if len(toolCalls) != 1 {
+		t.Fatalf("expected 1 tool call, got %d", len(toolCalls))
+	}
+	if toolCalls[0].ID != "call_1" || toolCalls[0].Function.Name != "echo" || toolCalls[0].Function.Arguments != `{"text":"hi"}` {
+		t.Errorf("unexpected tool call: %+v", toolCalls[0])
+	}
+
+	// errors in the stream are sticky
+	stream, err = client.StreamChatCompletion(context.TODO(), "gpt-4o", messages, nil, WithExtraQuery("case", "error"))
+	if err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("failed to receive the first chunk: %s", err)
+	}
+	if _, err := stream.Recv(); err == nil || err == io.EOF {
+		t.Errorf("expected an error, got %v", err)
+	} else if _, again := stream.Recv(); again != err {
+		t.Errorf("expected the same error again, got %v", again)
+	}
+}
+
+func TestChatCompletionStreamCloseMock(t *testing.T) {
+	closed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+
+		// hold the stream open until the client goes away
+		<-r.Context().Done()
+		closed <- struct{}{}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.baseURL = &server.URL
+
+	messages := []ChatMessage{NewChatUserMessage("Hello")}
+
+	// close from another goroutine while receiving
+	stream, err := client.StreamChatCompletion(context.TODO(), "gpt-4o", messages, nil)
+	if err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("failed to receive the first chunk: %s", err)
+	}
+	time.AfterFunc(50*time.Millisecond, func() {
+		stream.Close()
+	})
+	if _, err := stream.Recv(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		t.Errorf("connection was not closed")
+	}
+
+	// cancel the context while receiving
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err = client.StreamChatCompletion(ctx, "gpt-4o", messages, nil)
+	if err != nil {
+		t.Fatalf("failed to start stream: %s", err)
+	}
+	defer stream.Close()
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("failed to receive the first chunk: %s", err)
+	}
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := stream.Recv(); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(3 * time.Second):
+		t.Errorf("connection was not closed")
+	}
+}
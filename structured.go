@@ -0,0 +1,141 @@
+package openai
+
+// types and functions for structured outputs
+//
+// https://platform.openai.com/docs/guides/structured-outputs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NewChatCompletionResponseFormatWithJSONSchema returns a `json_schema` response format in strict mode,
+// with the schema generated from the type of `v` (see `NewJSONSchema`).
+//
+// The generated content can be decoded with `ChatCompletion.ParseInto`.
+func NewChatCompletionResponseFormatWithJSONSchema(name string, v any) (ChatCompletionResponseFormat, error) {
+	schema, err := NewJSONSchema(v, true)
+	if err != nil {
+		return ChatCompletionResponseFormat{}, err
+	}
+
+	strict := true
+	return ChatCompletionResponseFormat{
+		Type: ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &ChatCompletionResponseFormatJSONSchema{
+			Name:   name,
+			Schema: schema,
+			Strict: &strict,
+		},
+	}, nil
+}
+
+// ResponseTextFormat struct for the format of text outputs of responses
+//
+// https://platform.openai.com/docs/api-reference/responses/create#responses-create-text
+type ResponseTextFormat struct {
+	Type        string     `json:"type"` // 'text' | 'json_object' | 'json_schema'
+	Name        string     `json:"name,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Schema      JSONSchema `json:"schema,omitempty"`
+	Strict      *bool      `json:"strict,omitempty"`
+}
+
+// NewResponseTextFormatWithJSONSchema returns a `json_schema` text format in strict mode,
+// with the schema generated from the type of `v` (see `NewJSONSchema`).
+//
+// The generated text can be decoded with `Response.ParseInto`.
+func NewResponseTextFormatWithJSONSchema(name string, v any) (ResponseTextFormat, error) {
+	schema, err := NewJSONSchema(v, true)
+	if err != nil {
+		return ResponseTextFormat{}, err
+	}
+
+	strict := true
+	return ResponseTextFormat{
+		Type:   "json_schema",
+		Name:   name,
+		Schema: schema,
+		Strict: &strict,
+	}, nil
+}
+
+// RefusalError struct for errors of structured outputs which were refused by the model
+type RefusalError struct {
+	Refusal string `json:"refusal"`
+}
+
+// Error returns the string representation of RefusalError.
+func (e *RefusalError) Error() string {
+	return fmt.Sprintf("model refused to respond: %s", e.Refusal)
+}
+
+// AsRefusalError returns the `*RefusalError` in given error's chain, or nil if there is none.
+func AsRefusalError(err error) *RefusalError {
+	var refusalErr *RefusalError
+	if errors.As(err, &refusalErr) {
+		return refusalErr
+	}
+	return nil
+}
+
+// ParseInto decodes the JSON content of the message into `out`.
+//
+// It returns `*RefusalError` if the model refused to respond.
+func (m ChatMessage) ParseInto(out any) error {
+	if m.Refusal != nil && *m.Refusal != "" {
+		return &RefusalError{Refusal: *m.Refusal}
+	}
+
+	content, err := m.ContentString()
+	if err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	if err := json.Unmarshal([]byte(content), out); err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	return nil
+}
+
+// ParseInto decodes the JSON content of the first choice's message into `out`.
+//
+// It returns `*RefusalError` if the model refused to respond.
+func (c ChatCompletion) ParseInto(out any) error {
+	if len(c.Choices) == 0 {
+		return fmt.Errorf("parse failed: no choices in chat completion")
+	}
+
+	return c.Choices[0].Message.ParseInto(out)
+}
+
+// ParseInto decodes the JSON text of the response's output messages into `out`.
+//
+// It returns `*RefusalError` if the model refused to respond.
+func (r Response) ParseInto(out any) error {
+	var text strings.Builder
+	found := false
+	for _, output := range r.Output {
+		if output.Type != "message" {
+			continue
+		}
+		for _, content := range output.Content {
+			switch content.Type {
+			case "refusal":
+				return &RefusalError{Refusal: content.Refusal}
+			case "output_text":
+				text.WriteString(content.Text)
+				found = true
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("parse failed: no text in response output")
+	}
+
+	if err := json.Unmarshal([]byte(text.String()), out); err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package openai
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type structuredTestWeather struct {
+	City        string   `json:"city" description:"name of the city"`
+	Temperature float64  `json:"temperature"`
+	Unit        string   `json:"unit" enum:"celsius,fahrenheit"`
+	Alerts      []string `json:"alerts,omitempty"`
+}
+
+func TestStructuredOutputsMock(t *testing.T) {
+	refuse := false
+	requests := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var params map[string]any
+		_ = json.Unmarshal(body, &params)
+		requests <- params
+
+		content := `{"city":"Seoul","temperature":21.5,"unit":"celsius","alerts":null}`
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			if refuse {
+				fmt.Fprint(w, `{"id":"chatcmpl-test","choices":[{"index":0,"message":{"role":"assistant","content":null,"refusal":"I can't help with that."},"finish_reason":"stop"}]}`)
+			} else {
+				fmt.Fprintf(w, `{"id":"chatcmpl-test","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, content)
+			}
+		case "/v1/responses":
+			if refuse {
+				fmt.Fprint(w, `{"id":"resp_test","object":"response","status":"completed","output":[{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"refusal","refusal":"I can't help with that."}]}]}`)
+			} else {
+				fmt.Fprintf(w, `{"id":"resp_test","object":"response","status":"completed","output":[{"type":"message","id":"msg_1","status":"completed","role":"assistant","content":[{"type":"output_text","text":%q}]}]}`, content)
+			}
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.baseURL = &server.URL
+
+	// chat completions
+	format, err := NewChatCompletionResponseFormatWithJSONSchema("weather", structuredTestWeather{})
+	if err != nil {
+		t.Fatalf("failed to create response format: %s", err)
+	}
+	completion, err := client.CreateChatCompletion("gpt-4o", []ChatMessage{NewChatUserMessage("Weather in Seoul?")}, ChatCompletionOptions{}.SetResponseFormat(format))
+	if err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	}
+	params := <-requests
+	responseFormat, _ := params["response_format"].(map[string]any)
+	jsonSchema, _ := responseFormat["json_schema"].(map[string]any)
+	if responseFormat["type"] != "json_schema" || jsonSchema["name"] != "weather" || jsonSchema["strict"] != true {
+		t.Errorf("unexpected response format: %+v", params["response_format"])
+	}
+	if schema, _ := jsonSchema["schema"].(map[string]any); schema["additionalProperties"] != false || len(schema["required"].([]any)) != 4 {
+		t.Errorf("unexpected schema: %+v", jsonSchema["schema"])
+	}
+	var weather structuredTestWeather
+	if err := completion.ParseInto(&weather); err != nil {
+		t.Errorf("failed to parse chat completion: %s", err)
+	} else if weather.City != "Seoul" || weather.Temperature != 21.5 || weather.Unit != "celsius" {
+		t.Errorf("unexpected parsed value: %+v", weather)
+	}
+
+	// responses
+	textFormat, err := NewResponseTextFormatWithJSONSchema("weather", &structuredTestWeather{})
+	if err != nil {
+		t.Fatalf("failed to create text format: %s", err)
+	}
+	response, err := client.CreateResponse("gpt-4o", "Weather in Seoul?", ResponseOptions{}.SetTextFormat(textFormat))
+	if err != nil {
+		t.Fatalf("failed to create response: %s", err)
+	}
+	params = <-requests
+	text, _ := params["text"].(map[string]any)
+	if f, _ := text["format"].(map[string]any); f["type"] != "json_schema" || f["name"] != "weather" || f["strict"] != true || f["schema"] == nil {
+		t.Errorf("unexpected text format: %+v", params["text"])
+	}
+	weather = structuredTestWeather{}
+	if err := response.ParseInto(&weather); err != nil {
+		t.Errorf("failed to parse response: %s", err)
+	} else if weather.City != "Seoul" || weather.Unit != "celsius" {
+		t.Errorf("unexpected parsed value: %+v", weather)
+	}
+
+	// refusals
+	refuse = true
+	completion, err = client.CreateChatCompletion("gpt-4o", []ChatMessage{NewChatUserMessage("Weather in Seoul?")}, ChatCompletionOptions{}.SetResponseFormat(format))
+	if err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	}
+	<-requests
+	if refusal := AsRefusalError(completion.ParseInto(&weather)); refusal == nil || refusal.Refusal != "I can't help with that." {
+		t.Errorf("expected a refusal error, got %v", refusal)
+	}
+	response, err = client.CreateResponse("gpt-4o", "Weather in Seoul?", ResponseOptions{}.SetTextFormat(textFormat))
+	if err != nil {
+		t.Fatalf("failed to create response: %s", err)
+	}
+	<-requests
+	if refusal := AsRefusalError(response.ParseInto(&weather)); refusal == nil {
+		t.Errorf("expected a refusal error")
+	}
+
+	// no content
+	if err := (ChatCompletion{}).ParseInto(&weather); err == nil {
+		t.Errorf("expected an error for empty chat completion")
+	}
+	if err := (Response{}).ParseInto(&weather); err == nil {
+		t.Errorf("expected an error for empty response")
+	}
+}
@@ -1,6 +1,7 @@
 package openai
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 )
@@ -70,13 +71,22 @@ func (o CreateThreadOptions) SetMetadata(metadata map[string]string) CreateThrea
 // CreateThread creates a thread with given `options`.
 //
 // https://platform.openai.com/docs/api-reference/threads/createThread
-func (c *Client) CreateThread(options CreateThreadOptions) (response Thread, err error) {
+func (c *Client) CreateThread(options CreateThreadOptions, opts ...RequestOption) (response Thread, err error) {
+	return c.CreateThreadWithContext(context.Background(), options, opts...)
+}
+
+// CreateThreadWithContext creates a thread with given `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/threads/createThread
+func (c *Client) CreateThreadWithContext(ctx context.Context, options CreateThreadOptions, opts ...RequestOption) (response Thread, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = CreateThreadOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.post("v1/threads", options); err == nil {
+	if bytes, err = c.postWithContext(ctx, "v1/threads", options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -84,11 +94,6 @@ func (c *Client) CreateThread(options CreateThreadOptions) (response Thread, err
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Thread{}, err
@@ -97,9 +102,18 @@ func (c *Client) CreateThread(options CreateThreadOptions) (response Thread, err
 // RetrieveThread retrieves the thread with given `threadID`.
 //
 // https://platform.openai.com/docs/api-reference/threads/getThread
-func (c *Client) RetrieveThread(threadID string) (response Thread, err error) {
+func (c *Client) RetrieveThread(threadID string, opts ...RequestOption) (response Thread, err error) {
+	return c.RetrieveThreadWithContext(context.Background(), threadID, opts...)
+}
+
+// RetrieveThreadWithContext retrieves the thread with given `threadID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/threads/getThread
+func (c *Client) RetrieveThreadWithContext(ctx context.Context, threadID string, opts ...RequestOption) (response Thread, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.get(fmt.Sprintf("v1/threads/%s", threadID), nil); err == nil {
+	if bytes, err = c.getWithContext(ctx, fmt.Sprintf("v1/threads/%s", threadID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -107,11 +121,6 @@ func (c *Client) RetrieveThread(threadID string) (response Thread, err error) {
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Thread{}, err
@@ -131,13 +140,22 @@ func (o ModifyThreadOptions) SetMetadata(metadata map[string]string) ModifyThrea
 // ModifyThread modifies a thread with given `threadID` and `options`.
 //
 // https://platform.openai.com/docs/api-reference/threads/modifyThread
-func (c *Client) ModifyThread(threadID string, options ModifyThreadOptions) (response Thread, err error) {
+func (c *Client) ModifyThread(threadID string, options ModifyThreadOptions, opts ...RequestOption) (response Thread, err error) {
+	return c.ModifyThreadWithContext(context.Background(), threadID, options, opts...)
+}
+
+// ModifyThreadWithContext modifies a thread with given `threadID` and `options` with context support.
+//
+// https://platform.openai.com/docs/api-reference/threads/modifyThread
+func (c *Client) ModifyThreadWithContext(ctx context.Context, threadID string, options ModifyThreadOptions, opts ...RequestOption) (response Thread, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	if options == nil {
 		options = ModifyThreadOptions{}
 	}
 
 	var bytes []byte
-	if bytes, err = c.post(fmt.Sprintf("v1/threads/%s", threadID), options); err == nil {
+	if bytes, err = c.postWithContext(ctx, fmt.Sprintf("v1/threads/%s", threadID), options); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -145,11 +163,6 @@ func (c *Client) ModifyThread(threadID string, options ModifyThreadOptions) (res
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return Thread{}, err
@@ -166,9 +179,18 @@ type ThreadDeletionStatus struct {
 // DeleteThread deletes a thread with given `threadID`.
 //
 // https://platform.openai.com/docs/api-reference/threads/deleteThread
-func (c *Client) DeleteThread(threadID string) (response ThreadDeletionStatus, err error) {
+func (c *Client) DeleteThread(threadID string, opts ...RequestOption) (response ThreadDeletionStatus, err error) {
+	return c.DeleteThreadWithContext(context.Background(), threadID, opts...)
+}
+
+// DeleteThreadWithContext deletes a thread with given `threadID` with context support.
+//
+// https://platform.openai.com/docs/api-reference/threads/deleteThread
+func (c *Client) DeleteThreadWithContext(ctx context.Context, threadID string, opts ...RequestOption) (response ThreadDeletionStatus, err error) {
+	ctx = withRequestOptions(ctx, opts)
+
 	var bytes []byte
-	if bytes, err = c.delete(fmt.Sprintf("v1/threads/%s", threadID), nil); err == nil {
+	if bytes, err = c.deleteWithContext(ctx, fmt.Sprintf("v1/threads/%s", threadID), nil); err == nil {
 		if err = json.Unmarshal(bytes, &response); err == nil {
 			if response.Error == nil {
 				return response, nil
@@ -176,11 +198,6 @@ func (c *Client) DeleteThread(threadID string) (response ThreadDeletionStatus, e
 
 			err = response.Error.err()
 		}
-	} else {
-		var res CommonResponse
-		if e := json.Unmarshal(bytes, &res); e == nil {
-			err = fmt.Errorf("%s: %s", err, res.Error.err())
-		}
 	}
 
 	return ThreadDeletionStatus{}, err
@@ -0,0 +1,452 @@
+package openai
+
+// types and functions for running tool calls of chat completions automatically
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	// default maximum number of chat completions in a run of ToolRunner
+	DefaultToolRunnerMaxIterations = 10
+
+	// default maximum number of tool calls run at the same time
+	DefaultToolRunnerConcurrency = 4
+
+	// default maximum number of timed-out tool handlers which are still running
+	DefaultToolRunnerMaxAbandoned = 16
+)
+
+var (
+	// ErrMaxToolIterations is returned when a run of ToolRunner does not finish within its maximum number of iterations.
+	ErrMaxToolIterations = errors.New("tool runner exceeded the maximum number of iterations")
+
+	// ErrTooManyAbandonedToolHandlers is returned for tool calls when too many timed-out tool handlers are still running.
+	ErrTooManyAbandonedToolHandlers = errors.New("too many timed-out tool handlers are still running")
+)
+
+// ToolHandler type for functions which handle tool calls with their arguments (JSON), and return the results
+//
+// Returned errors are sent to the model as the results of the calls.
+type ToolHandler func(ctx context.Context, arguments string) (result string, err error)
+
+// ToolRunner struct for driving a chat completion conversation with tools:
+// it calls the handlers of the tools which the model requested, sends their results back,
+// and repeats until the model returns a final answer.
+//
+// A ToolRunner should not be modified while it runs, but it can run many conversations concurrently.
+type ToolRunner struct {
+	client *Client
+	model  string
+
+	tools []ChatCompletionTool
+	calls map[string]*runnerTool
+
+	maxIterations int
+	concurrency   int
+	timeout       time.Duration
+	maxAbandoned  int
+
+	beforeCall func(ctx context.Context, call ToolCall) error
+	afterCall  func(ctx context.Context, call ToolCall, result string, err error)
+
+	mu        sync.Mutex
+	abandoned int // number of timed-out handlers which are still running
+}
+
+// runnerTool struct for a tool registered to ToolRunner
+type runnerTool struct {
+	handler ToolHandler
+	timeout time.Duration
+}
+
+// ToolRunResult struct for the result of a run of ToolRunner
+type ToolRunResult struct {
+	// the last chat completion, with the final answer
+	Completion ChatCompletion
+
+	// the whole conversation: given messages, and the messages of the assistant and tools after them
+	Messages []ChatMessage
+
+	// number of chat completions created
+	Iterations int
+
+	// sum of the usages of all chat completions
+	Usage Usage
+}
+
+// NewToolRunner returns a new ToolRunner which creates chat completions with given client and model.
+func NewToolRunner(client *Client, model string) *ToolRunner {
+	return &ToolRunner{
+		client:        client,
+		model:         model,
+		calls:         map[string]*runnerTool{},
+		maxIterations: DefaultToolRunnerMaxIterations,
+		concurrency:   DefaultToolRunnerConcurrency,
+		maxAbandoned:  DefaultToolRunnerMaxAbandoned,
+	}
+}
+
+// Register registers a tool with its handler.
+//
+// A tool with the same name is replaced.
+func (r *ToolRunner) Register(tool ChatCompletionTool, handler ToolHandler) *ToolRunner {
+	name := tool.Function.Name
+	if _, exists := r.calls[name]; exists {
+		for i, t := range r.tools {
+			if t.Function.Name == name {
+				r.tools = append(r.tools[:i:i], r.tools[i+1:]...)
+				break
+			}
+		}
+	}
+
+	r.tools = append(r.tools, tool)
+	r.calls[name] = &runnerTool{handler: handler}
+
+	return r
+}
+
+// RegisterFunc registers a typed handler function as a strict tool, with parameters generated from its argument struct.
+//
+// `fn` should look like `func(args T) (R, error)` or `func(ctx context.Context, args T) (R, error)`,
+// where arguments of calls are decoded into `T`, and `R` is sent to the model as a string (if it is a string) or JSON.
+func (r *ToolRunner) RegisterFunc(name, description string, fn any) error {
+	parameters, err := NewToolFunctionParametersFromFunc(fn, true)
+	if err != nil {
+		return err
+	}
+
+	handler, err := newToolHandler(fn)
+	if err != nil {
+		return err
+	}
+
+	r.Register(NewChatCompletionTool(name, description, parameters).SetStrict(true), handler)
+
+	return nil
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// newToolHandler wraps a typed handler function as a ToolHandler.
+func newToolHandler(fn any) (ToolHandler, error) {
+	argsType, err := toolArgumentsType(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.NumOut() != 2 || t.Out(1) != errorType {
+		return nil, fmt.Errorf("handler should return a result and an error, got: %s", t)
+	}
+
+	return func(ctx context.Context, arguments string) (string, error) {
+		elemType := argsType
+		if elemType.Kind() == reflect.Pointer {
+			elemType = elemType.Elem()
+		}
+		args := reflect.New(elemType)
+		if arguments != "" {
+			if err := json.Unmarshal([]byte(arguments), args.Interface()); err != nil {
+				return "", fmt.Errorf("failed to decode arguments: %w", err)
+			}
+		}
+		if argsType.Kind() != reflect.Pointer {
+			args = args.Elem()
+		}
+
+		in := []reflect.Value{args}
+		if t.NumIn() == 2 {
+			in = []reflect.Value{reflect.ValueOf(ctx), args}
+		}
+		out := v.Call(in)
+		if err, _ := out[1].Interface().(error); err != nil {
+			return "", err
+		}
+
+		switch result := out[0].Interface().(type) {
+		case string:
+			return result, nil
+		default:
+			bytes, err := json.Marshal(result)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode result: %w", err)
+			}
+			return string(bytes), nil
+		}
+	}, nil
+}
+
+// SetMaxIterations sets the maximum number of chat completions in a run (default: `DefaultToolRunnerMaxIterations`).
+func (r *ToolRunner) SetMaxIterations(maxIterations int) *ToolRunner {
+	r.maxIterations = maxIterations
+
+	return r
+}
+
+// SetConcurrency sets the maximum number of parallel tool calls run at the same time (default: `DefaultToolRunnerConcurrency`).
+func (r *ToolRunner) SetConcurrency(concurrency int) *ToolRunner {
+	r.concurrency = concurrency
+
+	return r
+}
+
+// SetTimeout sets the timeout of each tool call (0 for no timeout).
+//
+// Handlers are not stopped when their calls time out, so handlers should return soon after their contexts are done;
+// otherwise they keep running in the background (see `SetMaxAbandonedHandlers`).
+func (r *ToolRunner) SetTimeout(timeout time.Duration) *ToolRunner {
+	r.timeout = timeout
+
+	return r
+}
+
+// SetToolTimeout sets the timeout of the calls of the tool with given name, which overrides the one set with `SetTimeout`.
+//
+// As with `SetTimeout`, the handler keeps running in the background after its call times out until it returns.
+func (r *ToolRunner) SetToolTimeout(name string, timeout time.Duration) *ToolRunner {
+	if tool, exists := r.calls[name]; exists {
+		tool.timeout = timeout
+	}
+
+	return r
+}
+
+// SetMaxAbandonedHandlers sets the maximum number of timed-out tool handlers which may still be running
+// (default: `DefaultToolRunnerMaxAbandoned`, 0 for no limit).
+//
+// When it is reached, tool calls fail with `ErrTooManyAbandonedToolHandlers` without running their handlers,
+// until some of the timed-out handlers return.
+func (r *ToolRunner) SetMaxAbandonedHandlers(max int) *ToolRunner {
+	r.maxAbandoned = max
+
+	return r
+}
+
+// SetBeforeToolCall sets a hook which is called before each tool call, for observing or approving it.
+//
+// If the hook returns an error, the call is not run and the error is sent to the model as its result.
+func (r *ToolRunner) SetBeforeToolCall(hook func(ctx context.Context, call ToolCall) error) *ToolRunner {
+	r.beforeCall = hook
+
+	return r
+}
+
+// SetAfterToolCall sets a hook which is called after each tool call, with its result or error.
+func (r *ToolRunner) SetAfterToolCall(hook func(ctx context.Context, call ToolCall, result string, err error)) *ToolRunner {
+	r.afterCall = hook
+
+	return r
+}
+
+// Run creates chat completions with the registered tools, and runs the tool calls of them
+// until the model returns a final answer.
+//
+// If `options` has no tools, the registered ones are used.
+// It returns `ErrMaxToolIterations` (with the result so far) if the model keeps calling tools after the maximum number of iterations.
+func (r *ToolRunner) Run(ctx context.Context, messages []ChatMessage, options ChatCompletionOptions, opts ...RequestOption) (result ToolRunResult, err error) {
+	return r.run(ctx, messages, options, func(ctx context.Context, messages []ChatMessage, options ChatCompletionOptions) (ChatCompletion, error) {
+		return r.client.CreateChatCompletionWithContext(ctx, r.model, messages, options, opts...)
+	})
+}
+
+// RunStream is the same as `Run`, but creates chat completions with streaming,
+// and calls `onChunk` with each of their chunks.
+func (r *ToolRunner) RunStream(ctx context.Context, messages []ChatMessage, options ChatCompletionOptions, onChunk func(chunk ChatCompletion), opts ...RequestOption) (result ToolRunResult, err error) {
+	return r.run(ctx, messages, options, func(ctx context.Context, messages []ChatMessage, options ChatCompletionOptions) (ChatCompletion, error) {
+		stream, err := r.client.StreamChatCompletion(ctx, r.model, messages, options, opts...)
+		if err != nil {
+			return ChatCompletion{}, err
+		}
+		defer stream.Close()
+
+		for {
+			chunk, err := stream.Recv()
+			if err == io.EOF {
+				return stream.ChatCompletion(), nil
+			} else if err != nil {
+				return ChatCompletion{}, err
+			}
+			if onChunk != nil {
+				onChunk(chunk)
+			}
+		}
+	})
+}
+
+// run drives the conversation, creating chat completions with `create`.
+func (r *ToolRunner) run(
+	ctx context.Context,
+	messages []ChatMessage,
+	options ChatCompletionOptions,
+	create func(ctx context.Context, messages []ChatMessage, options ChatCompletionOptions) (ChatCompletion, error),
+) (result ToolRunResult, err error) {
+	result.Messages = append([]ChatMessage{}, messages...)
+
+	for result.Iterations < r.maxIterations {
+		// copy options, as they are modified on each request
+		params := ChatCompletionOptions{}
+		for k, v := range options {
+			params[k] = v
+		}
+		if _, exists := params["tools"]; !exists && len(r.tools) > 0 {
+			params["tools"] = r.tools
+		}
+
+		var completion ChatCompletion
+		if completion, err = create(ctx, result.Messages, params); err != nil {
+			return result, err
+		}
+		result.Iterations++
+		result.Completion = completion
+		result.Usage.PromptTokens += completion.Usage.PromptTokens
+		result.Usage.CompletionTokens += completion.Usage.CompletionTokens
+		result.Usage.TotalTokens += completion.Usage.TotalTokens
+
+		if len(completion.Choices) == 0 {
+			return result, fmt.Errorf("no choices in chat completion")
+		}
+		message := completion.Choices[0].Message
+		message.Role = ChatMessageRoleAssistant
+		result.Messages = append(result.Messages, message)
+
+		if len(message.ToolCalls) == 0 {
+			return result, nil
+		}
+
+		var results []ChatMessage
+		if results, err = r.callTools(ctx, message.ToolCalls); err != nil {
+			return result, err
+		}
+		result.Messages = append(result.Messages, results...)
+	}
+
+	return result, fmt.Errorf("%w (%d)", ErrMaxToolIterations, r.maxIterations)
+}
+
+// callTools runs given tool calls in parallel (up to the concurrency), and returns their results as tool messages in the same order.
+func (r *ToolRunner) callTools(ctx context.Context, calls []ToolCall) ([]ChatMessage, error) {
+	concurrency := r.concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	semaphore := make(chan struct{}, concurrency)
+
+	results := make([]ChatMessage, len(calls))
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return nil, ctx.Err()
+		}
+
+		wg.Add(1)
+		go func(i int, call ToolCall) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			result, err := r.callTool(ctx, call)
+			if err != nil {
+				result = fmt.Sprintf("error: %s", err)
+			}
+			results[i] = NewChatToolMessage(call.ID, result)
+		}(i, call)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// callTool runs given tool call with the hooks.
+func (r *ToolRunner) callTool(ctx context.Context, call ToolCall) (result string, err error) {
+	if r.beforeCall != nil {
+		if err = r.beforeCall(ctx, call); err != nil {
+			err = fmt.Errorf("tool call was rejected: %w", err)
+		}
+	}
+	if err == nil {
+		result, err = r.handle(ctx, call)
+	}
+	if r.afterCall != nil {
+		r.afterCall(ctx, call, result, err)
+	}
+	return result, err
+}
+
+// handle runs the handler of given tool call within its timeout.
+//
+// If the timeout passes, it returns without waiting for the handler.
+func (r *ToolRunner) handle(ctx context.Context, call ToolCall) (string, error) {
+	tool, exists := r.calls[call.Function.Name]
+	if !exists {
+		return "", fmt.Errorf("no such tool: '%s'", call.Function.Name)
+	}
+
+	timeout := r.timeout
+	if tool.timeout > 0 {
+		timeout = tool.timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	r.mu.Lock()
+	tooMany := r.maxAbandoned > 0 && r.abandoned >= r.maxAbandoned
+	r.mu.Unlock()
+	if tooMany {
+		return "", ErrTooManyAbandonedToolHandlers
+	}
+
+	type handled struct {
+		result string
+		err    error
+	}
+	ch := make(chan handled, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				ch <- handled{err: fmt.Errorf("tool handler panicked: %v", p)}
+			}
+		}()
+
+		result, err := tool.handler(ctx, call.Function.Arguments)
+		ch <- handled{result: result, err: err}
+	}()
+
+	select {
+	case h := <-ch:
+		return h.result, h.err
+	case <-ctx.Done():
+		// the handler is abandoned, and counted until it returns
+		r.mu.Lock()
+		r.abandoned++
+		r.mu.Unlock()
+		go func() {
+			<-ch
+
+			r.mu.Lock()
+			r.abandoned--
+			r.mu.Unlock()
+		}()
+
+		return "", ctx.Err()
+	}
+}
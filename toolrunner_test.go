@@ -0,0 +1,315 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type toolRunnerTestWeatherArgs struct {
+	City string `json:"city"`
+}
+
+type toolRunnerTestWeather struct {
+	City        string  `json:"city"`
+	Temperature float64 `json:"temperature"`
+}
+
+// returns a mock server which requests tool calls until tool results are sent, and then answers with them
+func newToolRunnerTestServer(t *testing.T, toolCalls string, requests *[]map[string]any) *httptest.Server {
+	var mu sync.Mutex
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var params map[string]any
+		if err := json.Unmarshal(body, &params); err != nil {
+			t.Errorf("failed to decode request: %s", err)
+		}
+		mu.Lock()
+		*requests = append(*requests, params)
+		mu.Unlock()
+
+		// collect the results of tools
+		results := []string{}
+		for _, m := range params["messages"].([]any) {
+			if message := m.(map[string]any); message["role"] == "tool" {
+				results = append(results, fmt.Sprintf("%s=%s", message["tool_call_id"], message["content"]))
+			}
+		}
+
+		stream := params["stream"] == true
+		if stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+
+		if len(results) == 0 || toolCalls == "always" {
+			calls := `[{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Seoul\"}"}},{"id":"call_2","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Tokyo\"}"}},{"id":"call_3","type":"function","function":{"name":"unknown","arguments":"{}"}}]`
+			if toolCalls != "" && toolCalls != "always" {
+				calls = toolCalls
+			}
+			if stream {
+				var parsed []map[string]any
+				_ = json.Unmarshal([]byte(calls), &parsed)
+				for i, call := range parsed {
+					call["index"] = i
+					bytes, _ := json.Marshal(call)
+					fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"tool_calls\":[%s]}}]}\n\n", bytes)
+				}
+				fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"tool_calls\"}]}\n\n")
+				fmt.Fprintf(w, "data: [DONE]\n\n")
+			} else {
+				fmt.Fprintf(w, `{"id":"chatcmpl-test","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":%s},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":10,"completion_tokens":5,"total_tokens":15}}`, calls)
+			}
+			return
+		}
+
+		answer := strings.Join(results, "; ")
+		if stream {
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":%q}}]}\n\n", answer)
+			fmt.Fprintf(w, "data: {\"id\":\"chatcmpl-test\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprintf(w, "data: [DONE]\n\n")
+		} else {
+			fmt.Fprintf(w, `{"id":"chatcmpl-test","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}],"usage":{"prompt_tokens":20,"completion_tokens":5,"total_tokens":25}}`, answer)
+		}
+	}))
+}
+
+func TestToolRunnerMock(t *testing.T) {
+	requests := []map[string]any{}
+	server := newToolRunnerTestServer(t, "", &requests)
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.baseURL = &server.URL
+
+	var running, maxRunning int32
+	runner := NewToolRunner(client, "gpt-4o").SetConcurrency(2)
+	if err := runner.RegisterFunc("get_weather", "Get the weather of a city", func(ctx context.Context, args toolRunnerTestWeatherArgs) (toolRunnerTestWeather, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if n <= max || atomic.CompareAndSwapInt32(&maxRunning, max, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+
+		if args.City == "Tokyo" {
+			return toolRunnerTestWeather{}, fmt.Errorf("weather of Tokyo is not available")
+		}
+		return toolRunnerTestWeather{City: args.City, Temperature: 21.5}, nil
+	}); err != nil {
+		t.Fatalf("failed to register tool: %s", err)
+	}
+
+	var mu sync.Mutex
+	observed := []string{}
+	runner.SetAfterToolCall(func(ctx context.Context, call ToolCall, result string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		observed = append(observed, call.ID)
+	})
+
+	result, err := runner.Run(context.TODO(), []ChatMessage{NewChatUserMessage("Weather in Seoul and Tokyo?")}, nil)
+	if err != nil {
+		t.Fatalf("failed to run: %s", err)
+	}
+
+	// tools are sent with strict parameters
+	tools, _ := requests[0]["tools"].([]any)
+	if len(tools) != 1 {
+		t.Fatalf("expected 1 tool, got %+v", requests[0]["tools"])
+	}
+	if function := tools[0].(map[string]any)["function"].(map[string]any); function["name"] != "get_weather" || function["strict"] != true {
+		t.Errorf("unexpected tool: %+v", function)
+	}
+
+	if result.Iterations != 2 || len(requests) != 2 {
+		t.Errorf("expected 2 iterations, got %d (%d requests)", result.Iterations, len(requests))
+	}
+	if result.Usage.TotalTokens != 40 {
+		t.Errorf("expected 40 total tokens, got %d", result.Usage.TotalTokens)
+	}
+	if len(result.Messages) != 6 { // user, assistant, 3 tools, assistant
+		t.Errorf("expected 6 messages, got %d", len(result.Messages))
+	}
+	answer, _ := result.Completion.Choices[0].Message.ContentString()
+	for _, expected := range []string{
+		`call_1={"city":"Seoul","temperature":21.5}`,
+		`call_2=error: weather of Tokyo is not available`,
+		`call_3=error: no such tool: 'unknown'`,
+	} {
+		if !strings.Contains(answer, expected) {
+			t.Errorf("expected '%s' in answer: %s", expected, answer)
+		}
+	}
+	if max := atomic.LoadInt32(&maxRunning); max != 2 {
+		t.Errorf("expected 2 tool calls running at the same time, got %d", max)
+	}
+	if len(observed) != 3 {
+		t.Errorf("expected 3 observed tool calls, got %v", observed)
+	}
+
+	// rejected by the hook, and timed out
+	requests = requests[:0]
+	runner = NewToolRunner(client, "gpt-4o").
+		Register(NewChatCompletionTool("get_weather", "Get the weather of a city", NewToolFunctionParameters()), func(ctx context.Context, arguments string) (string, error) {
+			if strings.Contains(arguments, "Tokyo") {
+				<-ctx.Done()
+				return "", ctx.Err()
+			}
+			return "sunny", nil
+		}).
+		SetToolTimeout("get_weather", 50*time.Millisecond).
+		SetBeforeToolCall(func(ctx context.Context, call ToolCall) error {
+			if call.Function.Name == "unknown" {
+				return fmt.Errorf("not allowed")
+			}
+			return nil
+		})
+	result, err = runner.Run(context.TODO(), []ChatMessage{NewChatUserMessage("Weather in Seoul and Tokyo?")}, nil)
+	if err != nil {
+		t.Fatalf("failed to run: %s", err)
+	}
+	answer, _ = result.Completion.Choices[0].Message.ContentString()
+	for _, expected := range []string{
+		`call_1=sunny`,
+		`call_2=error: context deadline exceeded`,
+		`call_3=error: tool call was rejected: not allowed`,
+	} {
+		if !strings.Contains(answer, expected) {
+			t.Errorf("expected '%s' in answer: %s", expected, answer)
+		}
+	}
+
+	// invalid handlers
+	for _, fn := range []any{
+		func(args toolRunnerTestWeatherArgs) error { return nil },
+		func(args toolRunnerTestWeatherArgs) (string, string) { return "", "" },
+		func(city string) (string, error) { return "", nil },
+	} {
+		if err := runner.RegisterFunc("invalid", "", fn); err == nil {
+			t.Errorf("expected an error for %T", fn)
+		}
+	}
+}
+
+func TestToolRunnerMaxIterationsMock(t *testing.T) {
+	requests := []map[string]any{}
+	server := newToolRunnerTestServer(t, "always", &requests)
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.baseURL = &server.URL
+
+	runner := NewToolRunner(client, "gpt-4o").SetMaxIterations(3)
+	runner.Register(NewChatCompletionTool("get_weather", "", NewToolFunctionParameters()), func(ctx context.Context, arguments string) (string, error) {
+		return "sunny", nil
+	})
+
+	result, err := runner.Run(context.TODO(), []ChatMessage{NewChatUserMessage("Weather?")}, nil)
+	if !errors.Is(err, ErrMaxToolIterations) {
+		t.Errorf("expected max iterations error, got %v", err)
+	}
+	if result.Iterations != 3 || len(requests) != 3 {
+		t.Errorf("expected 3 iterations, got %d (%d requests)", result.Iterations, len(requests))
+	}
+}
+
+func TestToolRunnerStreamMock(t *testing.T) {
+	requests := []map[string]any{}
+	server := newToolRunnerTestServer(t, "", &requests)
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.baseURL = &server.URL
+
+	runner := NewToolRunner(client, "gpt-4o")
+	if err := runner.RegisterFunc("get_weather", "Get the weather of a city", func(args *toolRunnerTestWeatherArgs) (string, error) {
+		return "sunny in " + args.City, nil
+	}); err != nil {
+		t.Fatalf("failed to register tool: %s", err)
+	}
+
+	chunks := 0
+	result, err := runner.RunStream(context.TODO(), []ChatMessage{NewChatUserMessage("Weather in Seoul and Tokyo?")}, nil, func(chunk ChatCompletion) {
+		chunks++
+	})
+	if err != nil {
+		t.Fatalf("failed to run: %s", err)
+	}
+	if result.Iterations != 2 || chunks != 6 {
+		t.Errorf("expected 2 iterations with 6 chunks, got %d iterations with %d chunks", result.Iterations, chunks)
+	}
+	answer, _ := result.Completion.Choices[0].Message.ContentString()
+	for _, expected := range []string{
+		`call_1=sunny in Seoul`,
+		`call_2=sunny in Tokyo`,
+		`call_3=error: no such tool: 'unknown'`,
+	} {
+		if !strings.Contains(answer, expected) {
+			t.Errorf("expected '%s' in answer: %s", expected, answer)
+		}
+	}
+}
+
+func TestToolRunnerAbandonedHandlersMock(t *testing.T) {
+	requests := []map[string]any{}
+	server := newToolRunnerTestServer(t, "", &requests)
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.baseURL = &server.URL
+
+	// handlers which ignore their contexts
+	release := make(chan struct{})
+	runner := NewToolRunner(client, "gpt-4o").
+		Register(NewChatCompletionTool("get_weather", "", NewToolFunctionParameters()), func(ctx context.Context, arguments string) (string, error) {
+			<-release
+			return "sunny", nil
+		}).
+		SetConcurrency(1).
+		SetTimeout(20 * time.Millisecond).
+		SetMaxAbandonedHandlers(1)
+
+	result, err := runner.Run(context.TODO(), []ChatMessage{NewChatUserMessage("Weather in Seoul and Tokyo?")}, nil)
+	if err != nil {
+		t.Fatalf("failed to run: %s", err)
+	}
+	answer, _ := result.Completion.Choices[0].Message.ContentString()
+	for _, expected := range []string{
+		`call_1=error: context deadline exceeded`,
+		`call_2=error: ` + ErrTooManyAbandonedToolHandlers.Error(),
+	} {
+		if !strings.Contains(answer, expected) {
+			t.Errorf("expected '%s' in answer: %s", expected, answer)
+		}
+	}
+
+	// abandoned handlers are not counted after they return
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		runner.mu.Lock()
+		abandoned := runner.abandoned
+		runner.mu.Unlock()
+		if abandoned == 0 {
+			break
+		} else if time.Now().After(deadline) {
+			t.Fatalf("expected no abandoned handlers, got %d", abandoned)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,401 @@
+package openai
+
+// types and functions for streaming multipart uploads
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// number of bytes read from the head of a file for detecting its content type
+const sniffLength = 512
+
+// UploadProgressFunc type for reporting the progress of a file upload
+//
+// `total` is -1 when the size of the file is unknown.
+type UploadProgressFunc func(sent, total int64)
+
+// FileParam struct for multipart requests
+//
+// Files are streamed to the request body, so they are not loaded into memory as a whole.
+type FileParam struct {
+	open       func() (io.ReadCloser, error)
+	size       int64
+	replayable bool
+	progress   UploadProgressFunc
+
+	filename    string
+	contentType string
+}
+
+// NewFileParamFromBytes returns a new FileParam with given bytes
+func NewFileParamFromBytes(bs []byte) FileParam {
+	return FileParam{
+		open: func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(bs)), nil
+		},
+		size:       int64(len(bs)),
+		replayable: true,
+	}
+}
+
+// NewFileParamFromBytesWithFilename returns a new FileParam with given bytes, filename, and content type
+//
+// If `contentType` is empty, it is detected from the filename and bytes.
+func NewFileParamFromBytesWithFilename(bs []byte, filename, contentType string) FileParam {
+	return NewFileParamFromBytes(bs).WithFilename(filename).WithContentType(contentType)
+}
+
+// NewFileParamFromFilepath returns a new FileParam which streams the file at given filepath
+//
+// The file is opened each time a request is sent, and its base name is used as the filename.
+func NewFileParamFromFilepath(path string) (f FileParam, err error) {
+	var stat os.FileInfo
+	if stat, err = os.Stat(path); err != nil {
+		return FileParam{}, err
+	}
+	if stat.IsDir() {
+		return FileParam{}, fmt.Errorf("'%s' is a directory", path)
+	}
+
+	return FileParam{
+		open: func() (io.ReadCloser, error) {
+			return os.Open(path)
+		},
+		size:       stat.Size(),
+		replayable: true,
+		filename:   filepath.Base(path),
+	}, nil
+}
+
+// NewFileParamFromReader returns a new FileParam which streams from given reader,
+// with its `size` in bytes (-1 if unknown).
+//
+// If `r` is not an `io.Seeker`, it can be read only once, so requests with it will not be retried.
+// `r` is not closed by the client.
+func NewFileParamFromReader(r io.Reader, size int64) FileParam {
+	if size < 0 {
+		size = -1
+	}
+
+	if seeker, ok := r.(io.Seeker); ok {
+		var mu sync.Mutex
+		var offset int64 = -1
+		return FileParam{
+			open: func() (io.ReadCloser, error) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				var err error
+				if offset < 0 {
+					offset, err = seeker.Seek(0, io.SeekCurrent)
+				} else {
+					_, err = seeker.Seek(offset, io.SeekStart)
+				}
+				if err != nil {
+					return nil, fmt.Errorf("failed to seek file param: %w", err)
+				}
+				return io.NopCloser(r), nil
+			},
+			size:       size,
+			replayable: true,
+		}
+	}
+
+	var mu sync.Mutex
+	consumed := false
+	return FileParam{
+		open: func() (io.ReadCloser, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if consumed {
+				return nil, errors.New("file param was already read and cannot be read again")
+			}
+			consumed = true
+			return io.NopCloser(r), nil
+		},
+		size: size,
+	}
+}
+
+// NewFileParamFromReaderWithFilename returns a new FileParam which streams from given reader,
+// with its `size` in bytes (-1 if unknown), filename, and content type.
+//
+// If `contentType` is empty, it is detected from the filename and the head of the stream.
+func NewFileParamFromReaderWithFilename(r io.Reader, size int64, filename, contentType string) FileParam {
+	return NewFileParamFromReader(r, size).WithFilename(filename).WithContentType(contentType)
+}
+
+// WithFilename returns a copy of the file param with given filename.
+func (f FileParam) WithFilename(filename string) FileParam {
+	f.filename = filename
+	return f
+}
+
+// WithContentType returns a copy of the file param with given content type.
+func (f FileParam) WithContentType(contentType string) FileParam {
+	f.contentType = contentType
+	return f
+}
+
+// Filename returns the filename of the file, or an empty string if it is not set.
+func (f FileParam) Filename() string {
+	return f.filename
+}
+
+// ContentType returns the content type of the file, or an empty string if it is not set.
+func (f FileParam) ContentType() string {
+	return f.contentType
+}
+
+// resolve returns the filename and content type of the file, with given param key and head bytes.
+//
+// Missing values are detected from the head bytes, and the filename is generated with the param key.
+func (f FileParam) resolve(key string, head []byte) (filename, contentType string) {
+	filename, contentType = f.filename, f.contentType
+	if contentType == "" {
+		contentType = detectContentType(filename, head)
+	}
+	if filename == "" {
+		filename = fmt.Sprintf("%s.%s", key, getExtension(contentType))
+	}
+	return filename, contentType
+}
+
+// WithProgress returns a copy of the file param which reports its upload progress to `fn`.
+//
+// `fn` is called from the goroutine which writes the request body.
+func (f FileParam) WithProgress(fn UploadProgressFunc) FileParam {
+	f.progress = fn
+	return f
+}
+
+// Size returns the size of the file in bytes, or -1 if it is unknown.
+func (f FileParam) Size() int64 {
+	return f.size
+}
+
+// readAll reads all bytes of the file.
+func (f FileParam) readAll() ([]byte, error) {
+	if f.open == nil {
+		return nil, errors.New("file param is not initialized")
+	}
+
+	rc, err := f.open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	return io.ReadAll(rc)
+}
+
+// checks if all file params in given params can be read again
+func isReplayableParams(params map[string]any) bool {
+	for _, v := range params {
+		if f, ok := v.(FileParam); ok && !f.replayable {
+			return false
+		}
+	}
+	return true
+}
+
+// multipartFile struct for a file which is being written to a multipart body
+type multipartFile struct {
+	filename    string
+	contentType string
+	reader      io.Reader
+	closer      io.Closer
+}
+
+// multipartBody struct for a multipart/form-data body which is streamed through a pipe
+//
+// Nothing is written until it is read for the first time.
+type multipartBody struct {
+	params   map[string]any
+	keys     []string
+	files    map[string]*multipartFile
+	boundary string
+	length   int64
+
+	pr    *io.PipeReader
+	pw    *io.PipeWriter
+	start sync.Once
+}
+
+// newMultipartBody opens the files in given params and returns a new multipart body for them.
+func newMultipartBody(params map[string]any) (body *multipartBody, err error) {
+	body = &multipartBody{
+		params: params,
+		files:  map[string]*multipartFile{},
+	}
+	opened := body
+	defer func() {
+		if err != nil {
+			opened.closeFiles()
+		}
+	}()
+
+	for k := range params {
+		body.keys = append(body.keys, k)
+	}
+	sort.Strings(body.keys)
+
+	bs := make([]byte, 30)
+	if _, err = rand.Read(bs); err != nil {
+		return nil, fmt.Errorf("failed to generate multipart boundary: %w", err)
+	}
+	body.boundary = fmt.Sprintf("%x", bs)
+
+	length := int64(0)
+	for _, k := range body.keys {
+		f, ok := params[k].(FileParam)
+		if !ok {
+			continue
+		}
+		if f.open == nil {
+			return nil, fmt.Errorf("file param '%s' is not initialized", k)
+		}
+
+		var rc io.ReadCloser
+		if rc, err = f.open(); err != nil {
+			return nil, fmt.Errorf("could not open file for param '%s': %w", k, err)
+		}
+		file := &multipartFile{closer: rc}
+		body.files[k] = file
+
+		// read the head for detecting content type
+		head := make([]byte, sniffLength)
+		n, e := io.ReadFull(rc, head)
+		if e != nil && e != io.EOF && e != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("could not read file for param '%s': %w", k, e)
+		}
+		head = head[:n]
+		file.filename, file.contentType = f.resolve(k, head)
+		file.reader = io.MultiReader(bytes.NewReader(head), rc)
+
+		if f.progress != nil {
+			file.reader = &progressReader{reader: file.reader, total: f.size, fn: f.progress}
+		}
+
+		if length >= 0 && f.size >= 0 {
+			length += f.size
+		} else {
+			length = -1
+		}
+	}
+
+	// calculate the content length when sizes of all files are known
+	if length >= 0 {
+		counter := &countingWriter{}
+		if err = body.writeTo(counter, false); err != nil {
+			return nil, err
+		}
+		length += counter.n
+	}
+	body.length = length
+
+	body.pr, body.pw = io.Pipe()
+
+	return body, nil
+}
+
+// contentType returns the content type of the body.
+func (b *multipartBody) contentType() string {
+	return fmt.Sprintf("multipart/form-data; boundary=%s", b.boundary)
+}
+
+// writeTo writes the multipart form to `w`, (with contents of files only if `withFiles` is true)
+func (b *multipartBody) writeTo(w io.Writer, withFiles bool) error {
+	writer := multipart.NewWriter(w)
+	if err := writer.SetBoundary(b.boundary); err != nil {
+		return err
+	}
+
+	for _, k := range b.keys {
+		v := b.params[k]
+		if file, ok := b.files[k]; ok {
+			part, err := writer.CreatePart(mimeHeaderForBytes(nil, k, file.filename, file.contentType))
+			if err != nil {
+				return fmt.Errorf("could not create part for param '%s': %s", k, err)
+			}
+			if withFiles {
+				if _, err := io.Copy(part, file.reader); err != nil {
+					return fmt.Errorf("could not write bytes to multipart for param '%s': %s", k, err)
+				}
+			}
+		} else {
+			if err := writer.WriteField(k, fmt.Sprintf("%v", v)); err != nil {
+				return fmt.Errorf("could not write field with key: %s, value: %v", k, v)
+			}
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error while closing multipart form data writer: %s", err)
+	}
+	return nil
+}
+
+// Read starts writing the body on the first call, and reads the written bytes.
+func (b *multipartBody) Read(p []byte) (int, error) {
+	b.start.Do(func() {
+		go func() {
+			err := b.writeTo(b.pw, true)
+			b.closeFiles()
+			b.pw.CloseWithError(err)
+		}()
+	})
+	return b.pr.Read(p)
+}
+
+// Close closes the body (and its files if they were not read yet).
+func (b *multipartBody) Close() error {
+	b.start.Do(b.closeFiles)
+	return b.pr.Close()
+}
+
+// closeFiles closes all opened files.
+func (b *multipartBody) closeFiles() {
+	for _, file := range b.files {
+		_ = file.closer.Close()
+	}
+}
+
+// progressReader struct for reporting the number of bytes read
+type progressReader struct {
+	reader io.Reader
+	sent   int64
+	total  int64
+	fn     UploadProgressFunc
+}
+
+// Read reads from the underlying reader and reports the progress.
+func (r *progressReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.fn(r.sent, r.total)
+	}
+	return n, err
+}
+
+// countingWriter struct for counting written bytes
+type countingWriter struct {
+	n int64
+}
+
+// Write counts the length of `p`.
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
@@ -0,0 +1,212 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStreamingUploadMock(t *testing.T) {
+	audio, err := os.ReadFile("./sample/test.mp3")
+	if err != nil {
+		t.Fatalf("failed to read sample file: %s", err)
+	}
+
+	var calls int32
+	var contentLength int64
+	var filename, contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		contentLength = r.ContentLength
+
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("failed to parse multipart form: %s", err)
+		} else {
+			if r.FormValue("model") != "whisper-1" {
+				t.Errorf("unexpected model: %s", r.FormValue("model"))
+			}
+			if file, header, err := r.FormFile("file"); err != nil {
+				t.Errorf("failed to get file from form: %s", err)
+			} else {
+				defer file.Close()
+
+				if bs, _ := io.ReadAll(file); !bytes.Equal(bs, audio) {
+					t.Errorf("uploaded file does not match the original one (%d bytes)", len(bs))
+				}
+				filename, contentType = header.Filename, header.Header.Get("Content-Type")
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Header.Get("X-Fail") != "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":{"message":"overloaded","type":"server_error"}}`))
+			return
+		}
+		w.Write([]byte(`{"text":"hello"}`))
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+
+	// from filepath, with known size and progress
+	file, err := NewFileParamFromFilepath("./sample/test.mp3")
+	if err != nil {
+		t.Fatalf("failed to open sample file: %s", err)
+	}
+	var sent, total int64
+	file = file.WithProgress(func(s, t int64) {
+		sent, total = s, t
+	})
+	if transcription, err := client.CreateTranscription(file, "whisper-1", nil); err != nil {
+		t.Errorf("failed to create transcription: %s", err)
+	} else if transcription.Text == nil || *transcription.Text != "hello" {
+		t.Errorf("unexpected transcription: %+v", transcription)
+	}
+	if sent != int64(len(audio)) || total != int64(len(audio)) {
+		t.Errorf("unexpected progress: %d / %d", sent, total)
+	}
+	if contentLength <= int64(len(audio)) {
+		t.Errorf("content length should be known, got %d", contentLength)
+	}
+	if filename != "test.mp3" || contentType != "audio/mpeg" {
+		t.Errorf("unexpected filename or content type: %s, %s", filename, contentType)
+	}
+
+	// from reader, with unknown size
+	reader := NewFileParamFromReader(io.MultiReader(bytes.NewReader(audio)), -1)
+	if _, err := client.CreateTranscription(reader, "whisper-1", nil); err != nil {
+		t.Errorf("failed to create transcription: %s", err)
+	}
+	if contentLength != -1 {
+		t.Errorf("content length should be unknown, got %d", contentLength)
+	}
+	if filename != "file.mp3" || contentType != "audio/mpeg" {
+		t.Errorf("unexpected filename or content type: %s, %s", filename, contentType)
+	}
+
+	// with explicit filename and content type
+	reader = NewFileParamFromBytesWithFilename(audio, "voice.m4a", "audio/mp4")
+	if _, err := client.CreateTranscription(reader, "whisper-1", nil); err != nil {
+		t.Errorf("failed to create transcription: %s", err)
+	}
+	if filename != "voice.m4a" || contentType != "audio/mp4" {
+		t.Errorf("unexpected filename or content type: %s, %s", filename, contentType)
+	}
+
+	// non-seekable readers are not retried
+	policy := testRetryPolicy()
+	policy.AutoIdempotencyKey = true
+	client.SetRetryPolicy(policy)
+	client.Use(func(next Doer) Doer {
+		return DoerFunc(func(req *APIRequest) (*http.Response, error) {
+			req.HTTPRequest.Header.Set("X-Fail", "true")
+			return next.Do(req)
+		})
+	})
+
+	atomic.StoreInt32(&calls, 0)
+	reader = NewFileParamFromReader(io.MultiReader(bytes.NewReader(audio)), int64(len(audio)))
+	if _, err := client.CreateTranscription(reader, "whisper-1", nil); !IsServerError(err) {
+		t.Errorf("expected server error, got: %v", err)
+	} else if atomic.LoadInt32(&calls) != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+
+	// seekable readers are retried
+	atomic.StoreInt32(&calls, 0)
+	reader = NewFileParamFromReader(bytes.NewReader(audio), int64(len(audio)))
+	if _, err := client.CreateTranscription(reader, "whisper-1", nil); !IsServerError(err) {
+		t.Errorf("expected server error, got: %v", err)
+	} else if atomic.LoadInt32(&calls) != int32(policy.MaxAttempts) {
+		t.Errorf("expected %d calls, got %d", policy.MaxAttempts, calls)
+	}
+}
+
+// endlessReader struct for an endless and slow stream
+type endlessReader struct {
+	reads int32
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	atomic.AddInt32(&r.reads, 1)
+	time.Sleep(5 * time.Millisecond)
+	return copy(p, bytes.Repeat([]byte{'a'}, len(p))), nil
+}
+
+func TestUploadCancellationMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer server.Close()
+
+	client := NewClient("test-key", "test-org")
+	client.SetBaseURL(server.URL)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	reader := &endlessReader{}
+	started := time.Now()
+	if _, err := client.UploadFileWithContext(ctx, NewFileParamFromReader(reader, -1), "fine-tune"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded error, got: %v", err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("upload was not aborted in time: %s", elapsed)
+	}
+
+	// reading from the file should be stopped
+	time.Sleep(50 * time.Millisecond)
+	reads := atomic.LoadInt32(&reader.reads)
+	time.Sleep(50 * time.Millisecond)
+	if after := atomic.LoadInt32(&reader.reads); after != reads {
+		t.Errorf("file is still being read after cancellation: %d -> %d", reads, after)
+	}
+}
+
+func TestMultipartBody(t *testing.T) {
+	params := map[string]any{
+		"purpose": "fine-tune",
+		"file":    NewFileParamFromBytes([]byte(`{"prompt":"a","completion":"b"}`)),
+	}
+
+	body, err := newMultipartBody(params)
+	if err != nil {
+		t.Fatalf("failed to create multipart body: %s", err)
+	}
+	defer body.Close()
+
+	bs, err := io.ReadAll(body)
+	if err != nil {
+		t.Fatalf("failed to read multipart body: %s", err)
+	}
+	if int64(len(bs)) != body.length {
+		t.Errorf("calculated length %d does not match the actual one %d", body.length, len(bs))
+	}
+	if !strings.Contains(body.contentType(), body.boundary) {
+		t.Errorf("content type does not include boundary: %s", body.contentType())
+	}
+
+	// fine-tuning files with records longer than the sniffed head
+	record := `{"messages":[{"role":"user","content":"` + strings.Repeat("a", 2*sniffLength) + `"}]}`
+	body, err = newMultipartBody(map[string]any{
+		"purpose": "fine-tune",
+		"file":    NewFileParamFromBytes([]byte(record + "\n" + record + "\n")),
+	})
+	if err != nil {
+		t.Fatalf("failed to create multipart body: %s", err)
+	}
+	defer body.Close()
+	if file := body.files["file"]; file.filename != "file.jsonl" || file.contentType != "application/jsonl" {
+		t.Errorf("unexpected file: %s (%s)", file.filename, file.contentType)
+	}
+}
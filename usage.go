@@ -0,0 +1,571 @@
+package openai
+
+// types and functions for tracking usages and costs
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// default time window of UsageTracker
+	DefaultUsageWindow = time.Hour
+)
+
+// endpoints whose usages are tracked by UsageTracker
+var trackedEndpoints = map[string]bool{
+	"v1/chat/completions":     true,
+	"v1/completions":          true,
+	"v1/responses":            true,
+	"v1/embeddings":           true,
+	"v1/moderations":          true,
+	"v1/images/generations":   true,
+	"v1/images/edits":         true,
+	"v1/images/variations":    true,
+	"v1/audio/speech":         true,
+	"v1/audio/transcriptions": true,
+	"v1/audio/translations":   true,
+}
+
+// ModelPricing struct for the prices of a model (in USD)
+type ModelPricing struct {
+	// per 1M input (prompt) tokens
+	InputPerMillion float64 `json:"input_per_million,omitempty"`
+
+	// per 1M cached input tokens (`InputPerMillion` is used if 0)
+	CachedInputPerMillion float64 `json:"cached_input_per_million,omitempty"`
+
+	// per 1M output (completion) tokens, including reasoning tokens
+	OutputPerMillion float64 `json:"output_per_million,omitempty"`
+
+	// per image, keyed with "<quality>:<size>" (eg. "hd:1024x1792") or "<size>" (eg. "1024x1024")
+	PerImage map[string]float64 `json:"per_image,omitempty"`
+
+	// per minute of transcribed or translated audio
+	PerAudioMinute float64 `json:"per_audio_minute,omitempty"`
+
+	// per 1M characters of synthesized speech
+	PerMillionCharacters float64 `json:"per_million_characters,omitempty"`
+}
+
+// PricingTable type for the prices of models, keyed with model names
+//
+// Models which are not in the table are matched with the longest prefix (eg. "gpt-4o-2024-08-06" with "gpt-4o").
+type PricingTable map[string]ModelPricing
+
+// pricing returns the pricing of given model, and whether it was found.
+func (t PricingTable) pricing(model string) (ModelPricing, bool) {
+	if pricing, exists := t[model]; exists {
+		return pricing, true
+	}
+
+	matched := ""
+	for name := range t {
+		if strings.HasPrefix(model, name) && len(name) > len(matched) {
+			matched = name
+		}
+	}
+	if matched == "" {
+		return ModelPricing{}, false
+	}
+	return t[matched], true
+}
+
+// UsageRecord struct for the aggregated usage of a model and a tag in a time window
+type UsageRecord struct {
+	WindowStart time.Time `json:"window_start"`
+	Model       string    `json:"model"`
+	Tag         string    `json:"tag,omitempty"`
+
+	Requests int `json:"requests"`
+
+	InputTokens       int `json:"input_tokens"`
+	CachedInputTokens int `json:"cached_input_tokens"`
+	OutputTokens      int `json:"output_tokens"`
+	ReasoningTokens   int `json:"reasoning_tokens"`
+	TotalTokens       int `json:"total_tokens"`
+
+	// number of generated images, keyed with "<quality>:<size>" or "<size>"
+	Images map[string]int `json:"images,omitempty"`
+
+	AudioSeconds    float64 `json:"audio_seconds,omitempty"`
+	AudioCharacters int     `json:"audio_characters,omitempty"`
+
+	// cost in USD (0 if the model is not in the pricing table)
+	Cost float64 `json:"cost"`
+}
+
+// add adds given record's usage to this record.
+func (r *UsageRecord) add(other UsageRecord) {
+	r.Requests += other.Requests
+	r.InputTokens += other.InputTokens
+	r.CachedInputTokens += other.CachedInputTokens
+	r.OutputTokens += other.OutputTokens
+	r.ReasoningTokens += other.ReasoningTokens
+	r.TotalTokens += other.TotalTokens
+	for key, count := range other.Images {
+		if r.Images == nil {
+			r.Images = map[string]int{}
+		}
+		r.Images[key] += count
+	}
+	r.AudioSeconds += other.AudioSeconds
+	r.AudioCharacters += other.AudioCharacters
+	r.Cost += other.Cost
+}
+
+// cost returns the cost of this record's usage with given pricing.
+func (r UsageRecord) cost(pricing ModelPricing) float64 {
+	cachedPrice := pricing.CachedInputPerMillion
+	if cachedPrice == 0 {
+		cachedPrice = pricing.InputPerMillion
+	}
+
+	cost := float64(r.InputTokens-r.CachedInputTokens)*pricing.InputPerMillion/1_000_000 +
+		float64(r.CachedInputTokens)*cachedPrice/1_000_000 +
+		float64(r.OutputTokens)*pricing.OutputPerMillion/1_000_000 +
+		r.AudioSeconds/60*pricing.PerAudioMinute +
+		float64(r.AudioCharacters)*pricing.PerMillionCharacters/1_000_000
+	for key, count := range r.Images {
+		price, exists := pricing.PerImage[key]
+		if !exists {
+			// "<quality>:<size>" => "<size>"
+			if _, size, found := strings.Cut(key, ":"); found {
+				price = pricing.PerImage[size]
+			}
+		}
+		cost += float64(count) * price
+	}
+	return cost
+}
+
+// UsageSnapshot struct for the usages tracked by UsageTracker
+type UsageSnapshot struct {
+	Since   time.Time     `json:"since"`
+	Until   time.Time     `json:"until"`
+	Records []UsageRecord `json:"records"`
+}
+
+// Total returns the total usage of all records.
+func (s UsageSnapshot) Total() UsageRecord {
+	total := UsageRecord{WindowStart: s.Since}
+	for _, record := range s.Records {
+		total.add(record)
+	}
+	return total
+}
+
+// ByModel returns the usages aggregated per model.
+func (s UsageSnapshot) ByModel() map[string]UsageRecord {
+	return s.groupBy(func(r UsageRecord) UsageRecord {
+		return UsageRecord{WindowStart: s.Since, Model: r.Model}
+	}, func(r UsageRecord) string {
+		return r.Model
+	})
+}
+
+// ByTag returns the usages aggregated per tag.
+func (s UsageSnapshot) ByTag() map[string]UsageRecord {
+	return s.groupBy(func(r UsageRecord) UsageRecord {
+		return UsageRecord{WindowStart: s.Since, Tag: r.Tag}
+	}, func(r UsageRecord) string {
+		return r.Tag
+	})
+}
+
+// groupBy aggregates records with keys from `keyOf`, starting from records generated with `newRecord`.
+func (s UsageSnapshot) groupBy(newRecord func(UsageRecord) UsageRecord, keyOf func(UsageRecord) string) map[string]UsageRecord {
+	grouped := map[string]UsageRecord{}
+	for _, record := range s.Records {
+		key := keyOf(record)
+		group, exists := grouped[key]
+		if !exists {
+			group = newRecord(record)
+		}
+		group.add(record)
+		grouped[key] = group
+	}
+	return grouped
+}
+
+// WriteJSON writes the snapshot to given writer as JSON.
+func (s UsageSnapshot) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(s)
+}
+
+// WriteCSV writes the records of the snapshot to given writer as CSV (with a header row).
+//
+// Image counts are written in one column as "<key>=<count>" pairs separated with ';'.
+func (s UsageSnapshot) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{
+		"window_start",
+		"model",
+		"tag",
+		"requests",
+		"input_tokens",
+		"cached_input_tokens",
+		"output_tokens",
+		"reasoning_tokens",
+		"total_tokens",
+		"images",
+		"audio_seconds",
+		"audio_characters",
+		"cost",
+	}); err != nil {
+		return err
+	}
+
+	for _, r := range s.Records {
+		images := []string{}
+		for key, count := range r.Images {
+			images = append(images, fmt.Sprintf("%s=%d", key, count))
+		}
+		sort.Strings(images)
+
+		if err := writer.Write([]string{
+			r.WindowStart.Format(time.RFC3339),
+			r.Model,
+			r.Tag,
+			strconv.Itoa(r.Requests),
+			strconv.Itoa(r.InputTokens),
+			strconv.Itoa(r.CachedInputTokens),
+			strconv.Itoa(r.OutputTokens),
+			strconv.Itoa(r.ReasoningTokens),
+			strconv.Itoa(r.TotalTokens),
+			strings.Join(images, ";"),
+			strconv.FormatFloat(r.AudioSeconds, 'f', -1, 64),
+			strconv.Itoa(r.AudioCharacters),
+			strconv.FormatFloat(r.Cost, 'f', -1, 64),
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// UsageTracker struct for aggregating usages and costs of requests,
+// per model, per tag, and per time window
+//
+// Usages of chat completions, completions, responses, embeddings, moderations, images, and audio
+// are tracked from their responses (including streams with usages).
+// Responses served from the cache are not tracked.
+type UsageTracker struct {
+	mu      sync.Mutex
+	pricing PricingTable
+	window  time.Duration
+	since   time.Time
+	records map[usageKey]*UsageRecord
+
+	now func() time.Time
+}
+
+// usageKey struct for the key of usage records
+type usageKey struct {
+	windowStart time.Time
+	model       string
+	tag         string
+}
+
+// NewUsageTracker returns a new UsageTracker with given pricing table (can be nil).
+func NewUsageTracker(pricing PricingTable) *UsageTracker {
+	return &UsageTracker{
+		pricing: pricing,
+		window:  DefaultUsageWindow,
+		since:   time.Now(),
+		records: map[usageKey]*UsageRecord{},
+		now:     time.Now,
+	}
+}
+
+// SetPricing sets the pricing table for computing costs of usages tracked from now on.
+func (t *UsageTracker) SetPricing(pricing PricingTable) *UsageTracker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.pricing = pricing
+
+	return t
+}
+
+// SetWindow sets the time window for aggregating usages (0 for aggregating all usages in one window).
+func (t *UsageTracker) SetWindow(window time.Duration) *UsageTracker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.window = window
+
+	return t
+}
+
+// Snapshot returns the usages tracked so far, sorted by time window, model, and tag.
+func (t *UsageTracker) Snapshot() UsageSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.snapshot()
+}
+
+// Reset clears the tracked usages, and returns the usages tracked before.
+func (t *UsageTracker) Reset() UsageSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := t.snapshot()
+	t.records = map[usageKey]*UsageRecord{}
+	t.since = snapshot.Until
+
+	return snapshot
+}
+
+// snapshot returns the usages tracked so far (`t.mu` should be locked).
+func (t *UsageTracker) snapshot() UsageSnapshot {
+	snapshot := UsageSnapshot{
+		Since:   t.since,
+		Until:   t.now(),
+		Records: make([]UsageRecord, 0, len(t.records)),
+	}
+	for _, record := range t.records {
+		copied := *record
+		if record.Images != nil {
+			copied.Images = map[string]int{}
+			for key, count := range record.Images {
+				copied.Images[key] = count
+			}
+		}
+		snapshot.Records = append(snapshot.Records, copied)
+	}
+	sort.Slice(snapshot.Records, func(i, j int) bool {
+		a, b := snapshot.Records[i], snapshot.Records[j]
+		if !a.WindowStart.Equal(b.WindowStart) {
+			return a.WindowStart.Before(b.WindowStart)
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.Tag < b.Tag
+	})
+	return snapshot
+}
+
+// Record adds given usage to the tracker, for usages which are not tracked automatically
+// (eg. requests sent with other clients).
+//
+// `WindowStart` and `Cost` of the usage are filled by the tracker.
+func (t *UsageTracker) Record(usage UsageRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	windowStart := now.UTC()
+	if t.window > 0 {
+		windowStart = windowStart.Truncate(t.window)
+	} else {
+		windowStart = t.since.UTC()
+	}
+
+	if pricing, exists := t.pricing.pricing(usage.Model); exists {
+		usage.Cost = usage.cost(pricing)
+	} else {
+		usage.Cost = 0
+	}
+
+	key := usageKey{windowStart: windowStart, model: usage.Model, tag: usage.Tag}
+	record, exists := t.records[key]
+	if !exists {
+		record = &UsageRecord{WindowStart: windowStart, Model: usage.Model, Tag: usage.Tag}
+		t.records[key] = record
+	}
+	record.add(usage)
+}
+
+// SetUsageTracker makes the client track usages and costs of its requests with given tracker.
+func (c *Client) SetUsageTracker(tracker *UsageTracker) *Client {
+	c.usageTracker = tracker
+
+	return c
+}
+
+// WithUsageTracker makes the client track usages and costs of its requests with given tracker.
+func WithUsageTracker(tracker *UsageTracker) ClientOption {
+	return func(c *Client) {
+		c.SetUsageTracker(tracker)
+	}
+}
+
+type usageTagContextKey struct{}
+
+// ContextWithUsageTag returns a copy of `ctx` which makes usages of requests with it tracked with given tag
+// (eg. name of a feature or a user).
+//
+// If there is no tag in the context, the `user` parameter of the request is used as its tag.
+func ContextWithUsageTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, usageTagContextKey{}, tag)
+}
+
+// usageTagOf returns the usage tag of a request with given context and params.
+func usageTagOf(ctx context.Context, params map[string]any) string {
+	if tag, ok := ctx.Value(usageTagContextKey{}).(string); ok {
+		return tag
+	}
+	if user, ok := params["user"].(string); ok {
+		return user
+	}
+	return ""
+}
+
+// trackUsage tracks the usage in the response of given request.
+func (c *Client) trackUsage(ctx context.Context, endpoint string, params map[string]any, response []byte) {
+	if c.usageTracker == nil || !trackedEndpoints[endpoint] {
+		return
+	}
+
+	record := usageRecordOf(endpoint, params, response)
+	record.Tag = usageTagOf(ctx, params)
+
+	c.usageTracker.Record(record)
+}
+
+// trackStreamUsage tracks the usage in given stream event, which is the last one with usage.
+func (c *Client) trackStreamUsage(ctx context.Context, endpoint string, params map[string]any, event any) {
+	if response := streamUsageResponse(event); response != nil {
+		c.trackUsage(ctx, endpoint, params, response)
+	}
+}
+
+// usageRecordOf returns the usage in the response of given request.
+func usageRecordOf(endpoint string, params map[string]any, response []byte) UsageRecord {
+	record := UsageRecord{
+		Model:    stringParam(params, "model"),
+		Requests: 1,
+	}
+
+	switch {
+	case strings.HasPrefix(endpoint, "v1/images/"):
+		var res struct {
+			Data []json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(response, &res); err == nil && len(res.Data) > 0 {
+			record.Images = map[string]int{imageKey(params): len(res.Data)}
+		}
+	case endpoint == "v1/audio/speech":
+		record.AudioCharacters = utf8.RuneCountInString(stringParam(params, "input"))
+	default:
+		addTokenUsage(&record, response)
+	}
+
+	return record
+}
+
+// imageKey returns the key of images generated with given params ("<quality>:<size>" or "<size>").
+func imageKey(params map[string]any) string {
+	key := stringParam(params, "size")
+	if key == "" {
+		key = "1024x1024" // default size
+	}
+	if quality := stringParam(params, "quality"); quality != "" {
+		key = quality + ":" + key
+	}
+	return key
+}
+
+// streamUsageResponse returns the usage in given stream event as response bytes, or nil if there is none.
+func streamUsageResponse(event any) []byte {
+	switch e := event.(type) {
+	case ResponseStreamEvent:
+		if e.Response == nil {
+			return nil
+		}
+		response, _ := json.Marshal(map[string]any{"usage": e.Response.Usage})
+		return response
+	case ServerSentEvent:
+		return []byte(e.Data)
+	default:
+		response, _ := json.Marshal(event)
+		return response
+	}
+}
+
+// addTokenUsage adds the token usage (or audio duration) in given response bytes to `record`.
+func addTokenUsage(record *UsageRecord, response []byte) {
+	type tokensDetails struct {
+		CachedTokens    int `json:"cached_tokens"`
+		ReasoningTokens int `json:"reasoning_tokens"`
+	}
+	var res struct {
+		Usage *struct {
+			// chat completions, completions, and embeddings
+			PromptTokens            int            `json:"prompt_tokens"`
+			CompletionTokens        int            `json:"completion_tokens"`
+			PromptTokensDetails     *tokensDetails `json:"prompt_tokens_details"`
+			CompletionTokensDetails *tokensDetails `json:"completion_tokens_details"`
+
+			// responses and transcriptions
+			InputTokens         int            `json:"input_tokens"`
+			OutputTokens        int            `json:"output_tokens"`
+			InputTokensDetails  *tokensDetails `json:"input_tokens_details"`
+			OutputTokensDetails *tokensDetails `json:"output_tokens_details"`
+
+			TotalTokens int `json:"total_tokens"`
+
+			// transcriptions billed by duration
+			Seconds float64 `json:"seconds"`
+		} `json:"usage"`
+
+		// transcriptions and translations in `verbose_json` format
+		Duration float64 `json:"duration"`
+	}
+	if err := json.Unmarshal(response, &res); err != nil {
+		return
+	}
+
+	if usage := res.Usage; usage != nil {
+		record.InputTokens = usage.PromptTokens + usage.InputTokens
+		record.OutputTokens = usage.CompletionTokens + usage.OutputTokens
+		record.TotalTokens = usage.TotalTokens
+		if record.TotalTokens == 0 {
+			record.TotalTokens = record.InputTokens + record.OutputTokens
+		}
+		for _, details := range []*tokensDetails{usage.PromptTokensDetails, usage.InputTokensDetails} {
+			if details != nil {
+				record.CachedInputTokens += details.CachedTokens
+			}
+		}
+		for _, details := range []*tokensDetails{usage.CompletionTokensDetails, usage.OutputTokensDetails} {
+			if details != nil {
+				record.ReasoningTokens += details.ReasoningTokens
+			}
+		}
+		record.AudioSeconds = usage.Seconds
+	}
+	if record.AudioSeconds == 0 {
+		record.AudioSeconds = res.Duration
+	}
+}
+
+// stringParam returns the string value of given param (eg. a string, or a type based on string), or an empty string.
+func stringParam(params map[string]any, key string) string {
+	switch v := params[key].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
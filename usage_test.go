@@ -0,0 +1,189 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestUsageTrackerMock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/audio/speech" {
+			w.Header().Set("Content-Type", "audio/mpeg")
+			w.Write([]byte("fake audio"))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/chat/completions":
+			var params map[string]any
+			_ = json.NewDecoder(r.Body).Decode(&params)
+			if params["stream"] == true {
+				w.Header().Set("Content-Type", "text/event-stream")
+				w.Write([]byte("data: {\"id\":\"chatcmpl-2\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"}}]}\n\n"))
+				w.Write([]byte("data: {\"id\":\"chatcmpl-2\",\"object\":\"chat.completion.chunk\",\"choices\":[],\"usage\":{\"prompt_tokens\":10,\"completion_tokens\":2,\"total_tokens\":12}}\n\n"))
+				w.Write([]byte("data: [DONE]\n\n"))
+				return
+			}
+			w.Write([]byte(`{"id":"chatcmpl-1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Hello!"},"finish_reason":"stop"}],"usage":{"prompt_tokens":2000,"completion_tokens":1000,"total_tokens":3000,"prompt_tokens_details":{"cached_tokens":1000},"completion_tokens_details":{"reasoning_tokens":400}}}`))
+		case "/v1/responses":
+			w.Write([]byte(`{"id":"resp_1","object":"response","status":"completed","output":[],"usage":{"input_tokens":100,"input_tokens_details":{"cached_tokens":50},"output_tokens":20,"output_tokens_details":{"reasoning_tokens":10},"total_tokens":120}}`))
+		case "/v1/embeddings":
+			w.Write([]byte(`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]}],"usage":{"prompt_tokens":8,"total_tokens":8}}`))
+		case "/v1/images/generations":
+			w.Write([]byte(`{"created":0,"data":[{"url":"https://example.com/1.png"},{"url":"https://example.com/2.png"}]}`))
+		case "/v1/audio/transcriptions":
+			w.Write([]byte(`{"task":"transcribe","language":"english","duration":90,"text":"hello"}`))
+		default:
+			w.Write([]byte(`{"object":"list","data":[],"usage":{"prompt_tokens":1000,"total_tokens":1000}}`))
+		}
+	}))
+	defer server.Close()
+
+	tracker := NewUsageTracker(PricingTable{
+		"gpt-4o":      {InputPerMillion: 2.5, CachedInputPerMillion: 1.25, OutputPerMillion: 10},
+		"gpt-4o-mini": {InputPerMillion: 0.15, OutputPerMillion: 0.6},
+		"dall-e-3":    {PerImage: map[string]float64{"1024x1024": 0.04, "hd:1024x1024": 0.08}},
+		"tts-1":       {PerMillionCharacters: 15},
+		"whisper-1":   {PerAudioMinute: 0.006},
+	})
+	client := NewClientWithOptions("test-key", WithBaseURL(server.URL), WithUsageTracker(tracker))
+
+	ctx := ContextWithUsageTag(context.Background(), "feature-a")
+	messages := []ChatMessage{NewChatUserMessage("Hello")}
+
+	if _, err := client.CreateChatCompletionWithContext(ctx, "gpt-4o-2024-08-06", messages, nil); err != nil {
+		t.Fatalf("failed to create chat completion: %s", err)
+	}
+	if _, err := client.CreateResponseWithContext(ctx, "gpt-4o-mini", "Hello", nil); err != nil {
+		t.Fatalf("failed to create response: %s", err)
+	}
+	if _, err := client.CreateEmbeddingWithContext(context.Background(), "text-embedding-3-small", "hello", EmbeddingOptions{"user": "user-1"}); err != nil {
+		t.Fatalf("failed to create embedding: %s", err)
+	}
+	if _, err := client.CreateImageWithContext(ctx, "a cat", ImageOptions{}.SetModel("dall-e-3").SetN(2).SetQuality("hd").SetSize(ImageSize1024x1024_DallE3)); err != nil {
+		t.Fatalf("failed to create image: %s", err)
+	}
+	if _, err := client.CreateSpeechWithContext(ctx, "tts-1", "Hello, world!", SpeechVoiceAlloy, nil); err != nil {
+		t.Fatalf("failed to create speech: %s", err)
+	}
+	if _, err := client.CreateTranscriptionWithContext(ctx, NewFileParamFromBytes([]byte("fake audio")), "whisper-1", TranscriptionOptions{}.SetResponseFormat(TranscriptionResponseFormatVerboseJSON)); err != nil {
+		t.Fatalf("failed to create transcription: %s", err)
+	}
+	if _, err := client.ListModelsWithContext(ctx); err != nil { // not tracked
+		t.Fatalf("failed to list models: %s", err)
+	}
+
+	finished := make(chan struct{})
+	if _, err := client.CreateChatCompletionWithContext(ctx, "gpt-4o", messages, ChatCompletionOptions{}.
+		SetStream(func(response ChatCompletion, done bool, err error) {
+			if done {
+				close(finished)
+			}
+		})); err != nil {
+		t.Fatalf("failed to stream chat completion: %s", err)
+	}
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stream did not finish")
+	}
+
+	snapshot := tracker.Snapshot()
+	models := snapshot.ByModel()
+
+	chat := models["gpt-4o-2024-08-06"]
+	if chat.Requests != 1 || chat.InputTokens != 2000 || chat.CachedInputTokens != 1000 || chat.OutputTokens != 1000 || chat.ReasoningTokens != 400 {
+		t.Errorf("unexpected chat usage: %+v", chat)
+	}
+	if expected := 1000*2.5/1e6 + 1000*1.25/1e6 + 1000*10/1e6; !almostEqual(chat.Cost, expected) {
+		t.Errorf("expected chat cost %f, got %f", expected, chat.Cost)
+	}
+	if streamed := models["gpt-4o"]; streamed.Requests != 1 || streamed.TotalTokens != 12 {
+		t.Errorf("unexpected streamed usage: %+v", streamed)
+	}
+	if response := models["gpt-4o-mini"]; response.InputTokens != 100 || response.CachedInputTokens != 50 || response.ReasoningTokens != 10 ||
+		!almostEqual(response.Cost, 100*0.15/1e6+20*0.6/1e6) {
+		t.Errorf("unexpected response usage: %+v", response)
+	}
+	if image := models["dall-e-3"]; image.Images["hd:1024x1024"] != 2 || !almostEqual(image.Cost, 0.16) {
+		t.Errorf("unexpected image usage: %+v", image)
+	}
+	if speech := models["tts-1"]; speech.AudioCharacters != 13 || !almostEqual(speech.Cost, 13*15/1e6) {
+		t.Errorf("unexpected speech usage: %+v", speech)
+	}
+	if transcription := models["whisper-1"]; transcription.AudioSeconds != 90 || !almostEqual(transcription.Cost, 0.009) {
+		t.Errorf("unexpected transcription usage: %+v", transcription)
+	}
+	if embedding := models["text-embedding-3-small"]; embedding.InputTokens != 8 || embedding.Cost != 0 {
+		t.Errorf("unexpected embedding usage: %+v", embedding)
+	}
+
+	tags := snapshot.ByTag()
+	if tags["feature-a"].Requests != 6 || tags["user-1"].Requests != 1 || len(tags) != 2 {
+		t.Errorf("unexpected usages by tag: %+v", tags)
+	}
+	if total := snapshot.Total(); total.Requests != 7 {
+		t.Errorf("unexpected total usage: %+v", total)
+	}
+
+	// export
+	var buf bytes.Buffer
+	if err := snapshot.WriteCSV(&buf); err != nil {
+		t.Fatalf("failed to write csv: %s", err)
+	}
+	if rows, err := csv.NewReader(&buf).ReadAll(); err != nil || len(rows) != 8 || rows[0][0] != "window_start" {
+		t.Errorf("unexpected csv: %v, %v", rows, err)
+	}
+	buf.Reset()
+	if err := snapshot.WriteJSON(&buf); err != nil {
+		t.Fatalf("failed to write json: %s", err)
+	}
+	var decoded UsageSnapshot
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Records) != 7 {
+		t.Errorf("unexpected json: %s, %v", buf.String(), err)
+	}
+
+	// reset
+	if reset := tracker.Reset(); len(reset.Records) != 7 {
+		t.Errorf("reset should return the tracked usages: %+v", reset)
+	}
+	if records := tracker.Snapshot().Records; len(records) != 0 {
+		t.Errorf("usages should be cleared: %+v", records)
+	}
+}
+
+func TestUsageTrackerWindows(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)
+	tracker := NewUsageTracker(nil).SetWindow(time.Hour)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Record(UsageRecord{Model: "gpt-4o", Requests: 1, InputTokens: 10})
+	now = now.Add(20 * time.Minute)
+	tracker.Record(UsageRecord{Model: "gpt-4o", Requests: 1, InputTokens: 20})
+	now = now.Add(20 * time.Minute)
+	tracker.Record(UsageRecord{Model: "gpt-4o", Requests: 1, InputTokens: 30})
+
+	records := tracker.Snapshot().Records
+	if len(records) != 2 {
+		t.Fatalf("expected 2 windows, got %+v", records)
+	}
+	if !records[0].WindowStart.Equal(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)) || records[0].InputTokens != 30 {
+		t.Errorf("unexpected first window: %+v", records[0])
+	}
+	if !records[1].WindowStart.Equal(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)) || records[1].InputTokens != 30 {
+		t.Errorf("unexpected second window: %+v", records[1])
+	}
+}
+
+// almostEqual checks if given floats are equal within a small error.
+func almostEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-12 && d > -1e-12
+}